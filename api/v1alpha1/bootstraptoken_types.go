@@ -0,0 +1,113 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:validation:Enum=authentication;signing
+
+// BootstrapTokenUsage is what a bootstrap token can be used for
+type BootstrapTokenUsage string
+
+// Usages of a BootstrapToken
+const (
+	// The token authenticates against kube-apiserver, to create the certificate signing request of a kubelet
+	BootstrapTokenUsageAuthentication BootstrapTokenUsage = "authentication"
+	// The token signs the cluster-info ConfigMap, to discover the cluster CA
+	BootstrapTokenUsageSigning BootstrapTokenUsage = "signing"
+)
+
+// BootstrapTokenGroupPrefix prefixes the extra groups of a bootstrap token, kube-apiserver rejects the other ones
+const BootstrapTokenGroupPrefix = "system:bootstrappers:"
+
+// BootstrapTokenFinalizer revokes the token from the guest cluster before the BootstrapToken is deleted
+const BootstrapTokenFinalizer = "cluster.kubeception.ulfo.fr/bootstrap-token"
+
+// BootstrapTokenSpec defines the desired state of BootstrapToken
+type BootstrapTokenSpec struct {
+	// Name of the ControlPlane the nodes join
+	ControlPlane string `json:"control-plane"`
+
+	// Description of the token, written to the guest cluster
+	Description string `json:"description,omitempty"`
+
+	// Validity of the token from the creation of the BootstrapToken, 0s for a token that never expires
+	//+kubebuilder:default="24h"
+	TTL metav1.Duration `json:"ttl,omitempty"`
+
+	// What the token can be used for
+	//+kubebuilder:default={authentication,signing}
+	Usages []BootstrapTokenUsage `json:"usages,omitempty"`
+
+	// Extra groups the token authenticates as, on top of system:bootstrappers. They must be prefixed with system:bootstrappers:
+	Groups []string `json:"groups,omitempty"`
+
+	// Secret the token is published to, <name>-token when empty. An existing Secret must be controlled by the
+	// BootstrapToken
+	SecretName string `json:"secret-name,omitempty"`
+}
+
+// BootstrapTokenStatus defines the observed state of BootstrapToken
+type BootstrapTokenStatus struct {
+	// Generation of the BootstrapToken the guest cluster was updated for
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Public part of the token, its Secret in the guest cluster is kube-system/bootstrap-token-<token-id>
+	TokenID string `json:"token-id,omitempty"`
+	// Secret holding the token, token-id, token-secret, ca.crt and endpoint keys
+	SecretName string `json:"secret-name,omitempty"`
+	// Expiry of the token, empty when it never expires
+	ExpirationTime *metav1.Time `json:"expiration-time,omitempty"`
+
+	// Conditions of the BootstrapToken
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Control Plane",type=string,JSONPath=`.spec.control-plane`
+//+kubebuilder:printcolumn:name="Token ID",type=string,JSONPath=`.status.token-id`
+//+kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.status.secret-name`
+//+kubebuilder:printcolumn:name="Expires",type=string,JSONPath=`.status.expiration-time`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BootstrapToken creates a bootstrap token in the guest cluster of a ControlPlane, for the kubelets of the nodes to join it
+type BootstrapToken struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BootstrapTokenSpec   `json:"spec,omitempty"`
+	Status BootstrapTokenStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BootstrapTokenList contains a list of BootstrapToken
+type BootstrapTokenList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BootstrapToken `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BootstrapToken{}, &BootstrapTokenList{})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"regexp"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// bootstrapTokenGroupRegexp is the format kube-apiserver accepts for the extra groups of a bootstrap token
+var bootstrapTokenGroupRegexp = regexp.MustCompile(`^system:bootstrappers:[a-z0-9:-]{0,255}[a-z0-9]$`)
+
+// log is for logging in this package.
+var bootstraptokenlog = logf.Log.WithName("bootstraptoken-resource")
+
+func (r *BootstrapToken) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-bootstraptoken,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=bootstraptokens,verbs=create;update,versions=v1alpha1,name=vbootstraptoken.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &BootstrapToken{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *BootstrapToken) ValidateCreate() error {
+	bootstraptokenlog.Info("validate create", "name", r.Name)
+	return r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *BootstrapToken) ValidateUpdate(old runtime.Object) error {
+	bootstraptokenlog.Info("validate update", "name", r.Name)
+	return r.validate(old.(*BootstrapToken))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *BootstrapToken) ValidateDelete() error {
+	return nil
+}
+
+func (r *BootstrapToken) validate(old *BootstrapToken) error {
+	spec := field.NewPath("spec")
+	errs := r.Spec.validate(spec)
+	// The token would be left behind in the guest cluster of the previous ControlPlane
+	if old != nil {
+		errs = append(errs, validateImmutable(spec.Child("control-plane"), r.Spec.ControlPlane, old.Spec.ControlPlane)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("BootstrapToken").GroupKind(), r.Name, errs)
+}
+
+func (s *BootstrapTokenSpec) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateResourceName(path.Child("control-plane"), s.ControlPlane, true)...)
+	errs = append(errs, validateResourceName(path.Child("secret-name"), s.SecretName, false)...)
+
+	if s.TTL.Duration < 0 {
+		errs = append(errs, field.Invalid(path.Child("ttl"), s.TTL.Duration.String(), "must be positive, or 0s for a token that never expires"))
+	}
+
+	if len(s.Usages) == 0 {
+		errs = append(errs, field.Required(path.Child("usages"), "the token must be usable for authentication or signing"))
+	}
+	usages := map[BootstrapTokenUsage]bool{}
+	for i, usage := range s.Usages {
+		if usage != BootstrapTokenUsageAuthentication && usage != BootstrapTokenUsageSigning {
+			errs = append(errs, field.NotSupported(path.Child("usages").Index(i), usage, []string{string(BootstrapTokenUsageAuthentication), string(BootstrapTokenUsageSigning)}))
+		}
+		if usages[usage] {
+			errs = append(errs, field.Duplicate(path.Child("usages").Index(i), usage))
+		}
+		usages[usage] = true
+	}
+
+	for i, group := range s.Groups {
+		if !bootstrapTokenGroupRegexp.MatchString(group) {
+			errs = append(errs, field.Invalid(path.Child("groups").Index(i), group, "must be prefixed with "+BootstrapTokenGroupPrefix+" and match "+bootstrapTokenGroupRegexp.String()))
+		}
+	}
+	return errs
+}
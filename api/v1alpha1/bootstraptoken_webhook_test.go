@@ -0,0 +1,69 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func validBootstrapToken(name string) *BootstrapToken {
+	return &BootstrapToken{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: BootstrapTokenSpec{
+			ControlPlane: "demo",
+			TTL:          metav1.Duration{Duration: 24 * time.Hour},
+			Groups:       []string{"system:bootstrappers:workers"},
+		},
+	}
+}
+
+var _ = Describe("BootstrapToken webhook", func() {
+
+	It("Accepts a valid BootstrapToken with the default usages", func() {
+		bt := validBootstrapToken("valid")
+		Expect(k8sClient.Create(ctx, bt)).Should(Succeed())
+		Expect(bt.Spec.Usages).Should(Equal([]BootstrapTokenUsage{BootstrapTokenUsageAuthentication, BootstrapTokenUsageSigning}))
+	})
+
+	It("Rejects groups outside of system:bootstrappers and duplicated usages", func() {
+		bt := validBootstrapToken("groups")
+		bt.Spec.Groups = []string{"system:masters", "system:bootstrappers:Workers"}
+		bt.Spec.Usages = []BootstrapTokenUsage{BootstrapTokenUsageSigning, BootstrapTokenUsageSigning}
+
+		err := k8sClient.Create(ctx, bt)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.groups[0]"))
+		Expect(err.Error()).Should(ContainSubstring("spec.groups[1]"))
+		Expect(err.Error()).Should(ContainSubstring("spec.usages[1]: Duplicate value"))
+	})
+
+	It("Rejects a change of ControlPlane", func() {
+		bt := validBootstrapToken("immutable")
+		Expect(k8sClient.Create(ctx, bt)).Should(Succeed())
+
+		bt.Spec.ControlPlane = "other"
+		err := k8sClient.Update(ctx, bt)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.control-plane: Forbidden: field is immutable"))
+	})
+})
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -26,6 +27,75 @@ import (
 // 	ServiceName string `json:"service-name,omitempty"`
 // }
 
+// ControlPlaneNetwork are the networks of the guest cluster, they are immutable.
+// A dual-stack cluster has an IPv4 and an IPv6 range, the first one is the primary family.
+type ControlPlaneNetwork struct {
+	// Ranges of the pod IPs, split by kube-controller-manager into one range per node
+	PodCIDRs []string `json:"pod-cidrs,omitempty"`
+	// Ranges of the ClusterIP Services
+	ServiceCIDRs []string `json:"service-cidrs,omitempty"`
+	// Prefix length of the IPv4 pod range of each node
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=32
+	NodeCIDRMaskSize int32 `json:"node-cidr-mask-size,omitempty"`
+	// Prefix length of the IPv6 pod range of each node
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=128
+	NodeCIDRMaskSizeIPv6 int32 `json:"node-cidr-mask-size-ipv6,omitempty"`
+	// DNS domain of the Services
+	DNSDomain string `json:"dns-domain,omitempty"`
+}
+
+// KubernetesServiceIPs are the IPs of the kubernetes Service, the first IP of each service range
+func (n ControlPlaneNetwork) KubernetesServiceIPs() []string {
+	ips := []string{}
+	for _, cidr := range n.ServiceCIDRs {
+		if ip := firstIP(cidr); ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// DNSServiceIPs are the IPs of the cluster DNS Service, the tenth of each Service range as kubeadm does
+func (n ControlPlaneNetwork) DNSServiceIPs() []string {
+	ips := []string{}
+	for _, cidr := range n.ServiceCIDRs {
+		if ip := nthIP(cidr, 10); ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// KubernetesServiceDNSNames are the names of the kubernetes Service in the guest cluster
+func (n ControlPlaneNetwork) KubernetesServiceDNSNames() []string {
+	names := []string{"kubernetes", "kubernetes.default", "kubernetes.default.svc"}
+	if n.DNSDomain != "" {
+		names = append(names, "kubernetes.default.svc."+n.DNSDomain)
+	}
+	return names
+}
+
+// ControlPlaneAddon is an addon applied to the guest cluster with the admin kubeconfig of the ControlPlane
+type ControlPlaneAddon struct {
+	// Applies the addon to the guest cluster, it is removed from the guest cluster once disabled
+	Enabled bool `json:"enabled,omitempty"`
+	// Version of the addon image, changing it upgrades the addon
+	Version string `json:"version,omitempty"`
+}
+
+// ControlPlaneAddons are the addons managed in the guest cluster, none is enabled by default
+type ControlPlaneAddons struct {
+	// CoreDNS, the cluster DNS answering on the tenth IP of the Service ranges. v1.10.1 when no version is set.
+	CoreDNS ControlPlaneAddon `json:"coredns,omitempty"`
+	// konnectivity-agent, connecting the nodes to the konnectivity server next to kube-apiserver.
+	// The version of the konnectivity server, v0.0.37, when no version is set.
+	KonnectivityAgent ControlPlaneAddon `json:"konnectivity-agent,omitempty"`
+	// kube-proxy, it follows the version of the ControlPlane when no version is set
+	KubeProxy ControlPlaneAddon `json:"kube-proxy,omitempty"`
+}
+
 // ControlPlaneSpec defines the desired state of ControlPlane
 type ControlPlaneSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -34,22 +104,110 @@ type ControlPlaneSpec struct {
 	// Control Plane version
 	Version string `json:"version,omitempty"`
 
+	// Networks of the guest cluster, given to kube-apiserver, kube-controller-manager and the certificates
+	Network ControlPlaneNetwork `json:"network,omitempty"`
+
 	Loadbalancer          LoadbalancerSpec          `json:"loadbalancer,omitempty"`
 	PKI                   PkiSpec                   `json:"pki,omitempty"`
 	KubeApiServer         KubeAPIServerSpec         `json:"kube-apiserver,omitempty"`
 	KubeControllerManager KubeControllerManagerSpec `json:"kube-controller-manager,omitempty"`
 	KubeScheduler         KubeSchedulerSpec         `json:"kube-scheduler,omitempty"`
+
+	// Managed etcd cluster, kube-apiserver etcd-servers is used when empty
+	Etcd *EtcdSpec `json:"etcd,omitempty"`
+
+	// Addons applied to the guest cluster once kube-apiserver is reachable
+	Addons ControlPlaneAddons `json:"addons,omitempty"`
+}
+
+// EtcdNameAnnotation is set on a ControlPlane by an EtcdRestore to point it to the restored Etcd
+const EtcdNameAnnotation = "cluster.kubeception.ulfo.fr/etcd-name"
+
+// Condition types reported on a ControlPlane
+const (
+	// ConditionLoadbalancerReady is true once the Loadbalancer service has an ingress IP
+	ConditionLoadbalancerReady = "LoadbalancerReady"
+	// ConditionPKIReady is true once every certificate of the PKI has been issued
+	ConditionPKIReady = "PKIReady"
+	// ConditionAPIServerAvailable is true once the kube-apiserver Deployment is available
+	ConditionAPIServerAvailable = "APIServerAvailable"
+	// ConditionControllerManagerAvailable is true once the kube-controller-manager Deployment is available
+	ConditionControllerManagerAvailable = "ControllerManagerAvailable"
+	// ConditionSchedulerAvailable is true once the kube-scheduler Deployment is available
+	ConditionSchedulerAvailable = "SchedulerAvailable"
+	// ConditionEtcdAvailable is true once a quorum of managed etcd members is ready
+	ConditionEtcdAvailable = "EtcdAvailable"
+	// ConditionReady is true when all the other conditions are true
+	ConditionReady = "Ready"
+	// ConditionAddonsReady is true once the enabled addons are applied to the guest cluster, it is not part of Ready
+	// as the addons need a ready ControlPlane. It is only reported when an addon has been enabled.
+	ConditionAddonsReady = "AddonsReady"
+	// ConditionUpgrading is true while components are upgraded one after the other, it is false with
+	// the UpgradeRejected reason when the requested version breaks the version skew policy
+	ConditionUpgrading = "Upgrading"
+
+	// ConditionAvailable is reported by component resources (Etcd, KubeAPIServer, KubeControllerManager, KubeScheduler)
+	ConditionAvailable = "Available"
+)
+
+// ControlPlaneUpgrade is the upgrade in progress
+type ControlPlaneUpgrade struct {
+	// Version upgraded from
+	From string `json:"from"`
+	// Version upgraded to
+	To string `json:"to"`
+	// Component being upgraded: KubeAPIServer, KubeControllerManager or KubeScheduler
+	Component string `json:"component,omitempty"`
+	// Time the upgrade started
+	StartTime metav1.Time `json:"start-time"`
+}
+
+// ControlPlaneUpgradeHistory is a completed upgrade
+type ControlPlaneUpgradeHistory struct {
+	// Version upgraded from
+	From string `json:"from"`
+	// Version upgraded to
+	To string `json:"to"`
+	// Time the upgrade started
+	StartTime metav1.Time `json:"start-time"`
+	// Time the last component was upgraded
+	CompletionTime metav1.Time `json:"completion-time"`
 }
 
 // ControlPlaneStatus defines the observed state of ControlPlane
 type ControlPlaneStatus struct {
-	// Current status of the Control Plane
-	Status string `json:"status,omitempty"`
+	// Generation of the ControlPlane observed by the controller
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Endpoint of the kube-apiserver exposed by the Loadbalancer
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Secret holding the admin kubeconfig of the Control Plane
+	KubeconfigSecretRef *corev1.LocalObjectReference `json:"kubeconfig-secret-ref,omitempty"`
+
+	// Version every component has been rolled out to
+	Version string `json:"version,omitempty"`
+
+	// Upgrade in progress
+	Upgrade *ControlPlaneUpgrade `json:"upgrade,omitempty"`
+
+	// Last completed upgrades, most recent last
+	UpgradeHistory []ControlPlaneUpgradeHistory `json:"upgrade-history,omitempty"`
+
+	// Conditions of the Control Plane and its components
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:shortName=cp
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`
+//+kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.endpoint`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ControlPlane is the Schema for the controlplanes API
 type ControlPlane struct {
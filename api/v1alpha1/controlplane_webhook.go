@@ -0,0 +1,460 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var controlplanelog = logf.Log.WithName("controlplane-resource")
+
+func (r *ControlPlane) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// Defaults of a ControlPlane
+const (
+	DefaultServiceClusterIPRange = "10.32.0.0/24"
+	DefaultPodCIDR               = "10.200.0.0/16"
+	DefaultNodeCIDRMaskSize      = 24
+	DefaultNodeCIDRMaskSizeIPv6  = 64
+	DefaultDNSDomain             = "cluster.local"
+	DefaultAPIServerPort         = 6443
+	DefaultReplicas              = 3
+)
+
+// ManagementClusterCIDRs are the networks of the management cluster, set from the operator --management-cluster-cidrs flag.
+// The networks of a ControlPlane must not overlap them.
+var ManagementClusterCIDRs []*net.IPNet
+
+//+kubebuilder:webhook:path=/mutate-cluster-kubeception-ulfo-fr-v1alpha1-controlplane,mutating=true,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=controlplanes,verbs=create;update,versions=v1alpha1,name=mcontrolplane.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &ControlPlane{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *ControlPlane) Default() {
+	controlplanelog.Info("default", "name", r.Name)
+	r.SetDefaults()
+}
+
+// SetDefaults derives the names of the secrets, services and deployments from the ControlPlane name
+// and wires them between components, only empty fields are set. The controllers apply it again
+// for ControlPlanes created while webhooks were disabled.
+func (r *ControlPlane) SetDefaults() {
+	name := func(component string) string {
+		return fmt.Sprintf("%s-%s", r.Name, component)
+	}
+	setDefault := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+
+	// Loadbalancer
+	lb := &r.Spec.Loadbalancer
+	setDefault(&lb.Name, name("kube-apiserver"))
+	if lb.Port == 0 {
+		lb.Port = DefaultAPIServerPort
+	}
+	if len(lb.Selectors) == 0 {
+		lb.Selectors = map[string]string{
+			"app.kubernetes.io/name":     "kube-apiserver",
+			"app.kubernetes.io/instance": r.Name,
+		}
+	}
+
+	// Etcd is managed unless external etcd servers are given
+	if r.Spec.Etcd == nil && r.Spec.KubeApiServer.ETCDservers == "" {
+		r.Spec.Etcd = &EtcdSpec{}
+	}
+	if r.Spec.Etcd != nil && r.Spec.Etcd.Replicas == 0 {
+		r.Spec.Etcd.Replicas = DefaultReplicas
+	}
+
+	// Network, the service range of an existing kube-apiserver is kept
+	kas := &r.Spec.KubeApiServer
+	kcm := &r.Spec.KubeControllerManager
+	network := &r.Spec.Network
+	if len(network.ServiceCIDRs) == 0 {
+		setDefault(&kas.Options.ServiceClusterIpRange, DefaultServiceClusterIPRange)
+		network.ServiceCIDRs = strings.Split(kas.Options.ServiceClusterIpRange, ",")
+	}
+	if len(network.PodCIDRs) == 0 {
+		setDefault(&kcm.Options.ClusterCIDR, DefaultPodCIDR)
+		network.PodCIDRs = strings.Split(kcm.Options.ClusterCIDR, ",")
+	}
+	if network.NodeCIDRMaskSize == 0 && hasIPv4CIDR(network.PodCIDRs) {
+		network.NodeCIDRMaskSize = DefaultNodeCIDRMaskSize
+	}
+	if network.NodeCIDRMaskSizeIPv6 == 0 && hasIPv6CIDR(network.PodCIDRs) {
+		network.NodeCIDRMaskSizeIPv6 = DefaultNodeCIDRMaskSizeIPv6
+	}
+	setDefault(&network.DNSDomain, DefaultDNSDomain)
+	setDefault(&kas.Options.ServiceClusterIpRange, strings.Join(network.ServiceCIDRs, ","))
+	setDefault(&kcm.Options.ServiceClusterIpRange, strings.Join(network.ServiceCIDRs, ","))
+	setDefault(&kcm.Options.ClusterCIDR, strings.Join(network.PodCIDRs, ","))
+	if kcm.Options.NodeCIDRMaskSize == 0 {
+		kcm.Options.NodeCIDRMaskSize = network.NodeCIDRMaskSize
+	}
+	if kcm.Options.NodeCIDRMaskSizeIPv6 == 0 {
+		kcm.Options.NodeCIDRMaskSizeIPv6 = network.NodeCIDRMaskSizeIPv6
+	}
+
+	// PKI
+
+	pki := &r.Spec.PKI
+	setDefault(&pki.Name, name("pki"))
+	setDefault(&pki.CA.Name, name("ca"))
+	setDefault(&pki.Admin.Name, name("admin"))
+	setDefault(&pki.ServiceAccounts.Name, name("service-accounts"))
+	setDefault(&pki.Konnectivity.Name, name("konnectivity"))
+	setDefault(&pki.KubeAPIServer.Name, name("kube-apiserver"))
+	setDefault(&pki.KubeControllerManager.Name, name("kube-controller-manager"))
+	setDefault(&pki.KubeScheduler.Name, name("kube-scheduler"))
+	if r.Spec.Etcd != nil {
+		setDefault(&pki.ETCD.CA, name("etcd-ca"))
+		setDefault(&pki.ETCD.Server, name("etcd-server"))
+		setDefault(&pki.ETCD.Peer, name("etcd-peer"))
+		setDefault(&pki.ETCD.Client, name("etcd-client"))
+	}
+	setDefault(&pki.FrontProxyCA.Name, name("front-proxy-ca"))
+	setDefault(&pki.FrontProxyClient.Name, name("front-proxy-client"))
+
+	if len(pki.KubeAPIServer.IPAddresses) == 0 {
+		pki.KubeAPIServer.IPAddresses = append([]string{"127.0.0.1"}, network.KubernetesServiceIPs()...)
+	}
+	if len(pki.KubeAPIServer.DNSNames) == 0 {
+		pki.KubeAPIServer.DNSNames = append(append([]string{"localhost"}, network.KubernetesServiceDNSNames()...),
+			lb.Name,
+			fmt.Sprintf("%s.%s.svc", lb.Name, r.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", lb.Name, r.Namespace),
+		)
+	}
+
+	// Components
+	apiServerService := Service{Name: lb.Name, Port: uint(lb.Port)}
+
+	setDefault(&kas.Deployment.Name, name("kube-apiserver"))
+	if kas.Deployment.Replicas == 0 {
+		kas.Deployment.Replicas = DefaultReplicas
+	}
+	setDefault(&kas.TLS.CASecretName, pki.CA.Name)
+	setDefault(&kas.TLS.KubeApiServerSecretName, pki.KubeAPIServer.Name)
+	setDefault(&kas.TLS.ServiceAccountsSecretName, pki.ServiceAccounts.Name)
+	setDefault(&kas.TLS.KonnectivitySecretName, pki.Konnectivity.Name)
+	setDefault(&kas.TLS.FrontProxyClientSecretName, pki.FrontProxyClient.Name)
+
+	setDefault(&kcm.Deployment.Name, name("kube-controller-manager"))
+	if kcm.Deployment.Replicas == 0 {
+		kcm.Deployment.Replicas = DefaultReplicas
+	}
+	setDefault(&kcm.TLS.CA, pki.CA.Name)
+	setDefault(&kcm.TLS.KubeControllerManager, pki.KubeControllerManager.Name)
+	setDefault(&kcm.TLS.ServiceAccountsTLS, pki.ServiceAccounts.Name)
+	if kcm.KubeAPIServerService.Name == "" {
+		kcm.KubeAPIServerService = apiServerService
+	}
+
+	ks := &r.Spec.KubeScheduler
+	setDefault(&ks.Deployment.Name, name("kube-scheduler"))
+	if ks.Deployment.Replicas == 0 {
+		ks.Deployment.Replicas = DefaultReplicas
+	}
+	setDefault(&ks.KubeSchedulerTls, pki.KubeScheduler.Name)
+	if ks.KubeAPIServerService.Name == "" {
+		ks.KubeAPIServerService = apiServerService
+	}
+}
+
+// firstIP returns the first usable IP of a CIDR, it is the IP of the kubernetes service
+func firstIP(cidr string) string {
+	return nthIP(cidr, 1)
+}
+
+// nthIP returns the IP n addresses after the network address of a CIDR
+func nthIP(cidr string, n int) string {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return ""
+	}
+
+	ip := make(net.IP, len(ipnet.IP))
+	copy(ip, ipnet.IP)
+	for ; n > 0; n-- {
+		for i := len(ip) - 1; i >= 0; i-- {
+			ip[i]++
+			if ip[i] != 0 {
+				break
+			}
+		}
+	}
+	if !ipnet.Contains(ip) {
+		return ""
+	}
+	return ip.String()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-controlplane,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=controlplanes,verbs=create;update,versions=v1alpha1,name=vcontrolplane.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ControlPlane{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ControlPlane) ValidateCreate() error {
+	controlplanelog.Info("validate create", "name", r.Name)
+	return r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ControlPlane) ValidateUpdate(old runtime.Object) error {
+	controlplanelog.Info("validate update", "name", r.Name)
+	return r.validate(old.(*ControlPlane))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ControlPlane) ValidateDelete() error {
+	return nil
+}
+
+func (r *ControlPlane) validate(old *ControlPlane) error {
+	spec := field.NewPath("spec")
+	errs := field.ErrorList{}
+
+	versionErrs := validateVersion(spec.Child("version"), r.Spec.Version, true)
+	errs = append(errs, versionErrs...)
+	// The components running a version cannot go back nor skip a minor, an upgrade in progress runs its target
+	if old != nil && len(versionErrs) == 0 && r.Spec.Version != old.Spec.Version {
+		running := old.Spec.Version
+		switch {
+		case old.Status.Upgrade != nil:
+			running = old.Status.Upgrade.To
+		case old.Status.Version != "":
+			running = old.Status.Version
+		}
+		if err := ValidateUpgrade(running, r.Spec.Version); err != nil {
+			errs = append(errs, field.Forbidden(spec.Child("version"), err.Error()))
+		}
+	}
+	errs = append(errs, r.Spec.Network.validate(spec.Child("network"))...)
+	errs = append(errs, r.Spec.Loadbalancer.validate(spec.Child("loadbalancer"))...)
+	errs = append(errs, r.Spec.PKI.validate(spec.Child("pki"))...)
+	errs = append(errs, r.Spec.KubeApiServer.validate(spec.Child("kube-apiserver"), false)...)
+	kasVersion := r.Spec.KubeApiServer.Version
+	if kasVersion == "" {
+		kasVersion = r.Spec.Version
+	}
+	errs = append(errs, r.Spec.KubeApiServer.Authentication.validate(spec.Child("kube-apiserver", "authentication"), kasVersion)...)
+	if r.Spec.KubeApiServer.Encryption != nil {
+		errs = append(errs, r.Spec.KubeApiServer.Encryption.validate(spec.Child("kube-apiserver", "encryption"), kasVersion)...)
+	}
+	errs = append(errs, r.Spec.KubeApiServer.validateArgs(spec.Child("kube-apiserver"), kasVersion)...)
+	errs = append(errs, r.Spec.KubeControllerManager.validate(spec.Child("kube-controller-manager"), false)...)
+	kcmVersion := r.Spec.KubeControllerManager.Version
+	if kcmVersion == "" {
+		kcmVersion = r.Spec.Version
+	}
+	errs = append(errs, r.Spec.KubeControllerManager.validateArgs(spec.Child("kube-controller-manager"), kcmVersion)...)
+	errs = append(errs, r.Spec.KubeScheduler.validate(spec.Child("kube-scheduler"), false)...)
+	ksVersion := r.Spec.KubeScheduler.Version
+	if ksVersion == "" {
+		ksVersion = r.Spec.Version
+	}
+	errs = append(errs, r.Spec.KubeScheduler.validateArgs(spec.Child("kube-scheduler"), ksVersion)...)
+	errs = append(errs, r.Spec.Addons.validate(spec.Child("addons"), r.Spec.Version)...)
+
+	// Etcd servers are generated for a managed etcd
+	errs = append(errs, validateURLs(spec.Child("kube-apiserver", "etcd-servers"), r.Spec.KubeApiServer.ETCDservers, r.Spec.Etcd == nil)...)
+	if r.Spec.Etcd != nil {
+		errs = append(errs, r.Spec.Etcd.validate(spec.Child("etcd"))...)
+		if old != nil && old.Spec.Etcd != nil {
+			errs = append(errs, r.Spec.Etcd.validateUpdate(spec.Child("etcd"), old.Spec.Etcd)...)
+		}
+
+		pki := spec.Child("pki", "etcd")
+		errs = append(errs, validateRequired(pki.Child("server"), r.Spec.PKI.ETCD.Server)...)
+		errs = append(errs, validateRequired(pki.Child("peer"), r.Spec.PKI.ETCD.Peer)...)
+		errs = append(errs, validateRequired(pki.Child("client"), r.Spec.PKI.ETCD.Client)...)
+	}
+
+	// Components must use certificates issued by the PKI
+	issued := map[string]bool{}
+	for _, name := range r.Spec.PKI.secretNames(spec.Child("pki")) {
+		issued[name] = true
+	}
+	references := map[*field.Path]string{
+		spec.Child("kube-apiserver", "tls", "ca-secret-name"):                       r.Spec.KubeApiServer.TLS.CASecretName,
+		spec.Child("kube-apiserver", "tls", "kube-apiserver-secret-name"):           r.Spec.KubeApiServer.TLS.KubeApiServerSecretName,
+		spec.Child("kube-apiserver", "tls", "service-accounts-secret-name"):         r.Spec.KubeApiServer.TLS.ServiceAccountsSecretName,
+		spec.Child("kube-apiserver", "tls", "konnectivity-secret-name"):             r.Spec.KubeApiServer.TLS.KonnectivitySecretName,
+		spec.Child("kube-apiserver", "tls", "etcd-client-secret-name"):              r.Spec.KubeApiServer.TLS.ETCDClientSecretName,
+		spec.Child("kube-apiserver", "tls", "front-proxy-client-secret-name"):       r.Spec.KubeApiServer.TLS.FrontProxyClientSecretName,
+		spec.Child("kube-controller-manager", "tls", "ca"):                          r.Spec.KubeControllerManager.TLS.CA,
+		spec.Child("kube-controller-manager", "tls", "kube-controller-manager-tls"): r.Spec.KubeControllerManager.TLS.KubeControllerManager,
+		spec.Child("kube-controller-manager", "tls", "service-accounts-tls"):        r.Spec.KubeControllerManager.TLS.ServiceAccountsTLS,
+		spec.Child("kube-scheduler", "kube-scheduler-tls"):                          r.Spec.KubeScheduler.KubeSchedulerTls,
+	}
+	for _, p := range sortedPaths(references) {
+		if name := references[p]; name != "" && !issued[name] {
+			errs = append(errs, field.NotFound(p, name))
+		}
+	}
+
+	// Deployments are created in the same namespace
+	errs = append(errs, validateUniqueNames(map[*field.Path]string{
+		spec.Child("kube-apiserver", "deployment", "name"):          r.Spec.KubeApiServer.Deployment.Name,
+		spec.Child("kube-controller-manager", "deployment", "name"): deploymentName(r.Spec.KubeControllerManager.Deployment, "kube-controller-manager"),
+		spec.Child("kube-scheduler", "deployment", "name"):          deploymentName(r.Spec.KubeScheduler.Deployment, "kube-scheduler"),
+	})...)
+
+	// The components are given the networks of the ControlPlane
+	serviceCIDRs, podCIDRs := strings.Join(r.Spec.Network.ServiceCIDRs, ","), strings.Join(r.Spec.Network.PodCIDRs, ",")
+	mismatches := []struct {
+		path     *field.Path
+		value    string
+		expected string
+		from     string
+	}{
+		{spec.Child("kube-apiserver", "options", "service-cluster-ip-range"), r.Spec.KubeApiServer.Options.ServiceClusterIpRange, serviceCIDRs, "service-cidrs"},
+		{spec.Child("kube-controller-manager", "options", "service-cluster-ip-range"), r.Spec.KubeControllerManager.Options.ServiceClusterIpRange, serviceCIDRs, "service-cidrs"},
+		{spec.Child("kube-controller-manager", "options", "cluster-cidr"), r.Spec.KubeControllerManager.Options.ClusterCIDR, podCIDRs, "pod-cidrs"},
+	}
+	for _, m := range mismatches {
+		if m.value != "" && m.value != m.expected {
+			errs = append(errs, field.Invalid(m.path, m.value, "must match spec.network."+m.from+", set it instead"))
+		}
+	}
+	if size := r.Spec.KubeControllerManager.Options.NodeCIDRMaskSize; size != 0 && size != r.Spec.Network.NodeCIDRMaskSize {
+		errs = append(errs, field.Invalid(spec.Child("kube-controller-manager", "options", "node-cidr-mask-size"), size, "must match spec.network.node-cidr-mask-size, set it instead"))
+	}
+	if size := r.Spec.KubeControllerManager.Options.NodeCIDRMaskSizeIPv6; size != 0 && size != r.Spec.Network.NodeCIDRMaskSizeIPv6 {
+		errs = append(errs, field.Invalid(spec.Child("kube-controller-manager", "options", "node-cidr-mask-size-ipv6"), size, "must match spec.network.node-cidr-mask-size-ipv6, set it instead"))
+	}
+
+	if old != nil {
+		errs = append(errs, r.Spec.Network.validateUpdate(spec.Child("network"), old.Spec.Network)...)
+		errs = append(errs, r.Spec.KubeApiServer.validateUpdate(spec.Child("kube-apiserver"), old.Spec.KubeApiServer)...)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ControlPlane").GroupKind(), r.Name, errs)
+}
+
+// validate checks the versions of the addons, kube-proxy must not be newer than the ControlPlane
+func (a ControlPlaneAddons) validate(path *field.Path, cpVersion string) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateVersion(path.Child("coredns", "version"), a.CoreDNS.Version, false)...)
+	errs = append(errs, validateVersion(path.Child("konnectivity-agent", "version"), a.KonnectivityAgent.Version, false)...)
+
+	kubeProxy := path.Child("kube-proxy", "version")
+	errs = append(errs, validateVersion(kubeProxy, a.KubeProxy.Version, false)...)
+	proxyVersion, err := version.ParseSemantic(a.KubeProxy.Version)
+	if err != nil {
+		return errs
+	}
+	if v, err := version.ParseSemantic(cpVersion); err == nil && (proxyVersion.Major() != v.Major() || proxyVersion.Minor() > v.Minor()) {
+		errs = append(errs, field.Invalid(kubeProxy, a.KubeProxy.Version, fmt.Sprintf("must not be newer than the ControlPlane version %s", cpVersion)))
+	}
+	return errs
+}
+
+// validate checks the ranges of the network, they must not overlap each other nor the management cluster
+func (n *ControlPlaneNetwork) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	podErrs := validateDualStackCIDRs(path.Child("pod-cidrs"), n.PodCIDRs)
+	serviceErrs := validateDualStackCIDRs(path.Child("service-cidrs"), n.ServiceCIDRs)
+	errs = append(append(errs, podErrs...), serviceErrs...)
+	if len(podErrs) > 0 || len(serviceErrs) > 0 {
+		return errs
+	}
+
+	ranges := map[*field.Path]*net.IPNet{}
+	for i, cidr := range n.PodCIDRs {
+		_, ipnet, _ := net.ParseCIDR(cidr)
+		ranges[path.Child("pod-cidrs").Index(i)] = ipnet
+
+		// kube-controller-manager refuses more than 2^16 node ranges
+		sizePath, size := path.Child("node-cidr-mask-size"), int(n.NodeCIDRMaskSize)
+		if ipnet.IP.To4() == nil {
+			sizePath, size = path.Child("node-cidr-mask-size-ipv6"), int(n.NodeCIDRMaskSizeIPv6)
+		}
+		ones, bits := ipnet.Mask.Size()
+		switch {
+		case size <= ones || size > bits:
+			errs = append(errs, field.Invalid(sizePath, size, fmt.Sprintf("must be between %d and %d for %s", ones+1, bits, cidr)))
+		case size-ones > 16:
+			errs = append(errs, field.Invalid(sizePath, size, fmt.Sprintf("must be at most %d for %s, the range would be split into more than 65536 nodes", ones+16, cidr)))
+		}
+	}
+	for i, cidr := range n.ServiceCIDRs {
+		p := path.Child("service-cidrs").Index(i)
+		_, ipnet, _ := net.ParseCIDR(cidr)
+		ranges[p] = ipnet
+
+		// kube-apiserver refuses service ranges of more than 2^20 IPs
+		if ones, bits := ipnet.Mask.Size(); bits-ones > 20 {
+			errs = append(errs, field.Invalid(p, cidr, fmt.Sprintf("must have a prefix length of at least %d", bits-20)))
+		}
+	}
+	errs = append(errs, validateNoOverlap(ranges, ManagementClusterCIDRs)...)
+
+	if msgs := validation.IsDNS1123Subdomain(n.DNSDomain); len(msgs) > 0 {
+		errs = append(errs, field.Invalid(path.Child("dns-domain"), n.DNSDomain, strings.Join(msgs, ", ")))
+	}
+	return errs
+}
+
+// validateUpdate forbids changing the networks, the IPs already allocated would be outside of the new ranges
+func (n *ControlPlaneNetwork) validateUpdate(path *field.Path, old ControlPlaneNetwork) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateImmutable(path.Child("pod-cidrs"), strings.Join(n.PodCIDRs, ","), strings.Join(old.PodCIDRs, ","))...)
+	errs = append(errs, validateImmutable(path.Child("service-cidrs"), strings.Join(n.ServiceCIDRs, ","), strings.Join(old.ServiceCIDRs, ","))...)
+	if old.NodeCIDRMaskSize != 0 {
+		errs = append(errs, validateImmutable(path.Child("node-cidr-mask-size"), strconv.Itoa(int(n.NodeCIDRMaskSize)), strconv.Itoa(int(old.NodeCIDRMaskSize)))...)
+	}
+	if old.NodeCIDRMaskSizeIPv6 != 0 {
+		errs = append(errs, validateImmutable(path.Child("node-cidr-mask-size-ipv6"), strconv.Itoa(int(n.NodeCIDRMaskSizeIPv6)), strconv.Itoa(int(old.NodeCIDRMaskSizeIPv6)))...)
+	}
+	errs = append(errs, validateImmutable(path.Child("dns-domain"), n.DNSDomain, old.DNSDomain)...)
+	return errs
+}
+
+func (s *EtcdSpec) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if s.Replicas < 1 {
+		errs = append(errs, field.Invalid(path.Child("replicas"), s.Replicas, "must be greater than or equal to 1"))
+	}
+	if s.Storage.Size.Sign() < 0 {
+		errs = append(errs, field.Invalid(path.Child("storage", "size"), s.Storage.Size.String(), "must be positive"))
+	}
+	return errs
+}
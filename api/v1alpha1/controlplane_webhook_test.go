@@ -0,0 +1,412 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func validControlPlane(name string) *ControlPlane {
+	return &ControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: ControlPlaneSpec{
+			Version:      "v1.27.5",
+			Loadbalancer: LoadbalancerSpec{Name: "kube-apiserver", Port: 6443},
+			PKI: PkiSpec{
+				Name:                  "pki",
+				CA:                    PKICA{Name: "ca"},
+				Admin:                 PKIAdmin{Name: "admin"},
+				ServiceAccounts:       PKIServiceAccounts{Name: "service-accounts"},
+				Konnectivity:          PKIKonnectivity{Name: "konnectivity"},
+				KubeAPIServer:         PKIKubeAPIServer{Name: "kube-apiserver", IPAddresses: []string{"10.32.0.1"}},
+				KubeControllerManager: PKIKubeControllerManager{Name: "kube-controller-manager"},
+				KubeScheduler:         PKIKubeScheduler{Name: "kube-scheduler"},
+			},
+			KubeApiServer: KubeAPIServerSpec{
+				ETCDservers: "http://etcd-client:2379",
+				Deployment:  Deployment{Name: "kube-apiserver", Replicas: 3},
+				TLS: KubeAPIServerTLS{
+					CASecretName:              "ca",
+					KubeApiServerSecretName:   "kube-apiserver",
+					ServiceAccountsSecretName: "service-accounts",
+					KonnectivitySecretName:    "konnectivity",
+				},
+				Options: KubeAPIServerOptions{ServiceClusterIpRange: "10.32.0.0/24"},
+			},
+			KubeControllerManager: KubeControllerManagerSpec{
+				Deployment:           Deployment{Replicas: 3},
+				TLS:                  KubeControllerManagerTLS{CA: "ca", KubeControllerManager: "kube-controller-manager", ServiceAccountsTLS: "service-accounts"},
+				KubeAPIServerService: Service{Name: "kube-apiserver", Port: 6443},
+			},
+			KubeScheduler: KubeSchedulerSpec{
+				Deployment:           Deployment{Replicas: 3},
+				KubeSchedulerTls:     "kube-scheduler",
+				KubeAPIServerService: Service{Name: "kube-apiserver", Port: 6443},
+			},
+		},
+	}
+}
+
+var _ = Describe("ControlPlane webhook", func() {
+
+	It("Accepts a valid ControlPlane", func() {
+		Expect(k8sClient.Create(ctx, validControlPlane("valid"))).Should(Succeed())
+	})
+
+	It("Rejects invalid fields", func() {
+		cp := validControlPlane("invalid")
+		cp.Spec.Version = "1.27"
+		cp.Spec.KubeApiServer.Options.ServiceClusterIpRange = "10.32.0.0/33"
+		cp.Spec.KubeApiServer.ETCDservers = ""
+		cp.Spec.PKI.KubeAPIServer.IPAddresses = []string{"10.32.0.300"}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.version"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.options.service-cluster-ip-range"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.etcd-servers"))
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.kube-apiserver.IPAddresses[0]"))
+	})
+
+	It("Rejects colliding secret names", func() {
+		cp := validControlPlane("collision")
+		cp.Spec.PKI.Admin.Name = "ca"
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.ca.name"))
+	})
+
+	It("Rejects invalid certificate profiles", func() {
+		cp := validControlPlane("profiles")
+		cp.Spec.PKI.Profile = CertificateProfile{
+			Duration:    &metav1.Duration{Duration: 24 * time.Hour},
+			RenewBefore: &metav1.Duration{Duration: 48 * time.Hour},
+		}
+		cp.Spec.PKI.CA.Profile = &CertificateProfile{Algorithm: KeyAlgorithmECDSA, Size: 2048, Usages: []string{"server auth"}}
+		cp.Spec.PKI.ServiceAccounts.Profile = &CertificateProfile{Algorithm: KeyAlgorithmEd25519}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.profile.renew-before"))
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.ca.profile.size"))
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.ca.profile.usages"))
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.service-accounts.profile.algorithm"))
+	})
+
+	It("Rejects conflicting CA sources", func() {
+		cp := validControlPlane("ca-sources")
+		cp.Spec.PKI.Backend = PkiBackendNative
+		cp.Spec.PKI.CA.ImportSecretName = "company-ca"
+		cp.Spec.PKI.CA.IssuerRef = &PKIIssuerRef{Name: "vault", Kind: "Vault"}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.ca.issuer-ref: Forbidden: cannot be set with import-secret-name"))
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.ca.issuer-ref.kind"))
+		Expect(err.Error()).Should(ContainSubstring("external issuers require the cert-manager backend"))
+	})
+
+	It("Rejects etcd certificates without a dedicated etcd CA", func() {
+		cp := validControlPlane("etcd-ca")
+		cp.Spec.PKI.ETCD = PKIEtcd{Server: "etcd-server", Peer: "etcd-peer", Client: "etcd-client"}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.pki.etcd.ca: Required value"))
+	})
+
+	It("Rejects multiple OIDC issuers before v1.30 and invalid issuers", func() {
+		cp := validControlPlane("oidc")
+		cp.Spec.Version = "v1.29.4"
+		cp.Spec.KubeApiServer.Authentication.OIDC = []OIDCIssuer{
+			{IssuerURL: "https://sso.example.com", ClientID: "kubernetes"},
+			{IssuerURL: "http://partner.example.com", GroupsPrefix: "system:"},
+		}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.authentication.oidc: Forbidden: multiple issuers require kube-apiserver v1.30.0 or later"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.authentication.oidc[1].client-id: Required value"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.authentication.oidc[1].issuer-url: Invalid value"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.authentication.oidc[1].groups-prefix: Forbidden"))
+
+		cp.Spec.Version = "v1.30.2"
+		cp.Spec.KubeApiServer.Authentication.OIDC[1] = OIDCIssuer{IssuerURL: "https://partner.example.com", ClientID: "tenant"}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+	})
+
+	It("Rejects an audit configuration without policy or backend", func() {
+		cp := validControlPlane("audit")
+		cp.Spec.KubeApiServer.Audit = &KubeAPIServerAudit{Policy: "apiVersion: v1\nkind: ConfigMap\n"}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.audit.policy: Invalid value: \"<policy>\": must be an audit.k8s.io/v1 Policy"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.audit: Required value: log or webhook backend is required"))
+	})
+
+	It("Rejects removing the encryption", func() {
+		cp := validControlPlane("encryption")
+		cp.Spec.KubeApiServer.Encryption = &KubeAPIServerEncryption{Provider: EncryptionProviderSecretbox}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		cp.Spec.KubeApiServer.Encryption = nil
+		err := k8sClient.Update(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.encryption: Forbidden: cannot be removed once set"))
+	})
+
+	It("Rejects a KMS plugin before v1.27 and the kms provider without plugin", func() {
+		cp := validControlPlane("kms")
+		cp.Spec.Version = "v1.26.9"
+		cp.Spec.KubeApiServer.Encryption = &KubeAPIServerEncryption{Provider: EncryptionProviderKMS}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.encryption.kms: Required value"))
+
+		cp.Spec.KubeApiServer.Encryption.KMS = &KMSPlugin{Name: "vault", Image: "vault-kms-plugin:v1"}
+		err = k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.encryption.kms: Forbidden: KMS v2 requires kube-apiserver v1.27.0 or later"))
+	})
+
+	It("Rejects managed flags and flags or feature gates missing from the version", func() {
+		cp := validControlPlane("flags")
+		cp.Spec.Version = "v1.27.4"
+		cp.Spec.KubeApiServer.ExtraArgs = map[string]string{"tls-cert-file": "/tmp/tls.crt", "oidc-issuer-url": "https://sso.example.com", "insecure-port": "8080", "--profiling": "false", "enable-swagger": "true"}
+		cp.Spec.KubeApiServer.FeatureGates = map[string]bool{"InPlacePodVerticalScaling": true, "SidecarContainers": true}
+		cp.Spec.KubeApiServer.AdmissionPlugins = KubeAPIServerAdmissionPlugins{Enable: []string{"PodNodeSelector"}, Disable: []string{"PodNodeSelector"}}
+		cp.Spec.KubeControllerManager.ExtraArgs = map[string]string{"pod-eviction-timeout": "1m", "controllers": "*,-ttl"}
+		cp.Spec.KubeControllerManager.FeatureGates = map[string]bool{"CronJobTimeZone": true, "NotAGate": true}
+		cp.Spec.KubeScheduler.FeatureGates = map[string]bool{"not-a-gate": true}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.extra-args[tls-cert-file]: Forbidden: the flag is managed by the operator"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.extra-args[oidc-issuer-url]: Forbidden: the flag is managed by the operator"))
+		Expect(err.Error()).Should(ContainSubstring("kube-apiserver does not have this flag since v1.24.0"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.extra-args[enable-swagger]: Invalid value: \"enable-swagger\": kube-apiserver v1.27.4 does not have this flag"))
+		Expect(err.Error()).ShouldNot(ContainSubstring("feature-gates[InPlacePodVerticalScaling]"))
+		Expect(err.Error()).Should(ContainSubstring("kube-apiserver does not have this feature gate before v1.28.0"))
+		Expect(err.Error()).Should(ContainSubstring("must be a flag name without the leading --"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-apiserver.admission-plugins.disable[0]: Invalid value: \"PodNodeSelector\": cannot be enabled and disabled"))
+		Expect(err.Error()).Should(ContainSubstring("kube-controller-manager does not have this flag since v1.27.0"))
+		Expect(err.Error()).ShouldNot(ContainSubstring("extra-args[controllers]"))
+		Expect(err.Error()).ShouldNot(ContainSubstring("feature-gates[CronJobTimeZone]"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-controller-manager.feature-gates[NotAGate]: Invalid value: \"NotAGate\": kube-controller-manager v1.27.4 does not have this feature gate"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-scheduler.feature-gates[not-a-gate]"))
+	})
+
+	It("Rejects extra args and feature gates of the versions whose flags are not known", func() {
+		cp := validControlPlane("flags-unknown-version")
+		cp.Spec.Version = "v1.31.0"
+		cp.Spec.KubeControllerManager.ExtraArgs = map[string]string{"controllers": "*,-ttl"}
+		cp.Spec.KubeScheduler.FeatureGates = map[string]bool{"SidecarContainers": true}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-controller-manager.extra-args[controllers]: Forbidden: the flags of v1.31.0 are not known"))
+		Expect(err.Error()).Should(ContainSubstring("spec.kube-scheduler.feature-gates[SidecarContainers]: Forbidden: the flags of v1.31.0 are not known"))
+	})
+
+	It("Rejects overlapping networks", func() {
+		ManagementClusterCIDRs = []*net.IPNet{{IP: net.IPv4(10, 96, 0, 0).To4(), Mask: net.CIDRMask(12, 32)}}
+		defer func() { ManagementClusterCIDRs = nil }()
+
+		cp := validControlPlane("overlap")
+		cp.Spec.KubeApiServer.Options.ServiceClusterIpRange = ""
+		cp.Spec.Network = ControlPlaneNetwork{
+			PodCIDRs:         []string{"10.32.0.0/16"},
+			ServiceCIDRs:     []string{"10.100.0.0/24"},
+			NodeCIDRMaskSize: 12,
+			DNSDomain:        "Cluster_Local",
+		}
+
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.network.node-cidr-mask-size: Invalid value: 12: must be between 17 and 32 for 10.32.0.0/16"))
+		Expect(err.Error()).Should(ContainSubstring("spec.network.service-cidrs[0]: Invalid value: \"10.100.0.0/24\": overlaps the management cluster network 10.96.0.0/12"))
+		Expect(err.Error()).Should(ContainSubstring("spec.network.dns-domain"))
+
+		cp.Spec.Network = ControlPlaneNetwork{PodCIDRs: []string{"10.32.0.0/16"}, ServiceCIDRs: []string{"10.32.0.0/24"}}
+		err = k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.network.service-cidrs[0]: Invalid value: \"10.32.0.0/24\": overlaps spec.network.pod-cidrs[0]"))
+	})
+
+	It("Accepts a dual-stack network and rejects two ranges of the same family", func() {
+		cp := validControlPlane("dual-stack")
+		cp.Spec.KubeApiServer.Options.ServiceClusterIpRange = ""
+		cp.Spec.Network = ControlPlaneNetwork{
+			PodCIDRs:     []string{"10.200.0.0/16", "fd00:200::/56"},
+			ServiceCIDRs: []string{"10.32.0.0/24", "fd00:32::/112"},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+		Expect(cp.Spec.Network.NodeCIDRMaskSize).Should(Equal(int32(DefaultNodeCIDRMaskSize)))
+		Expect(cp.Spec.Network.NodeCIDRMaskSizeIPv6).Should(Equal(int32(DefaultNodeCIDRMaskSizeIPv6)))
+		Expect(cp.Spec.KubeApiServer.Options.ServiceClusterIpRange).Should(Equal("10.32.0.0/24,fd00:32::/112"))
+		Expect(cp.Spec.KubeControllerManager.Options.ClusterCIDR).Should(Equal("10.200.0.0/16,fd00:200::/56"))
+
+		invalid := validControlPlane("same-family")
+		invalid.Spec.KubeApiServer.Options.ServiceClusterIpRange = ""
+		invalid.Spec.Network = ControlPlaneNetwork{
+			PodCIDRs:             []string{"10.200.0.0/16", "fd00:200::/56"},
+			ServiceCIDRs:         []string{"10.32.0.0/24", "10.33.0.0/24"},
+			NodeCIDRMaskSizeIPv6: 48,
+		}
+		err := k8sClient.Create(ctx, invalid)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.network.service-cidrs[1]: Invalid value: \"10.33.0.0/24\": must be of another IP family than 10.32.0.0/24 for dual-stack"))
+
+		invalid.Spec.Network.ServiceCIDRs = []string{"10.32.0.0/24", "fd00:32::/112"}
+		err = k8sClient.Create(ctx, invalid)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.network.node-cidr-mask-size-ipv6: Invalid value: 48: must be between 57 and 128 for fd00:200::/56"))
+	})
+
+	It("Rejects an addon version newer than the ControlPlane", func() {
+		cp := validControlPlane("addons")
+		cp.Spec.Addons = ControlPlaneAddons{
+			CoreDNS:   ControlPlaneAddon{Enabled: true, Version: "1.10.1"},
+			KubeProxy: ControlPlaneAddon{Enabled: true, Version: "v1.28.0"},
+		}
+		err := k8sClient.Create(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.addons.coredns.version: Invalid value: \"1.10.1\": must start with v"))
+		Expect(err.Error()).Should(ContainSubstring("spec.addons.kube-proxy.version: Invalid value: \"v1.28.0\": must not be newer than the ControlPlane version"))
+
+		cp.Spec.Addons.CoreDNS.Version = "v1.10.1"
+		cp.Spec.Addons.KubeProxy.Version = "v1.26.9"
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+	})
+
+	It("Rejects a service CIDR change", func() {
+		cp := validControlPlane("immutable")
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		cp.Spec.KubeApiServer.Options.ServiceClusterIpRange = "10.96.0.0/12"
+		err := k8sClient.Update(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("field is immutable"))
+	})
+
+	DescribeTable("Validates the version skew policy",
+		func(from, to string, allowed bool) {
+			err := ValidateUpgrade(from, to)
+			if allowed {
+				Expect(err).ShouldNot(HaveOccurred())
+			} else {
+				Expect(err).Should(HaveOccurred())
+			}
+		},
+		Entry("patch upgrade", "v1.27.5", "v1.27.6", true),
+		Entry("minor upgrade", "v1.27.5", "v1.28.0", true),
+		Entry("minor skip", "v1.27.5", "v1.29.0", false),
+		Entry("major upgrade", "v1.27.5", "v2.0.0", false),
+		Entry("patch downgrade", "v1.27.5", "v1.27.4", false),
+		Entry("minor downgrade", "v1.28.0", "v1.27.5", false),
+		Entry("invalid version", "v1.27.5", "latest", false),
+	)
+
+	It("Rejects downgrades and minor skips from the running version", func() {
+		cp := validControlPlane("upgrade")
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		By("Skipping a minor before the first rollout completes")
+		cp.Spec.Version = "v1.29.0"
+		err := k8sClient.Update(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.version: Forbidden: upgrade from v1.27.5 to v1.29.0 skips a minor version"))
+
+		By("Rolling back an upgrade in progress")
+		cp.Spec.Version = "v1.28.2"
+		Expect(k8sClient.Update(ctx, cp)).Should(Succeed())
+		cp.Status.Version = "v1.27.5"
+		cp.Status.Upgrade = &ControlPlaneUpgrade{From: "v1.27.5", To: "v1.28.2", Component: "KubeControllerManager", StartTime: metav1.Now()}
+		Expect(k8sClient.Status().Update(ctx, cp)).Should(Succeed())
+		cp.Spec.Version = "v1.27.5"
+		err = k8sClient.Update(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.version: Forbidden: downgrade from v1.28.2 to v1.27.5 is not supported"))
+
+		By("Retargeting an upgrade in progress to the next minor")
+		cp.Spec.Version = "v1.29.0"
+		Expect(k8sClient.Update(ctx, cp)).Should(Succeed())
+	})
+
+	It("Rejects scaling the managed etcd", func() {
+		cp := &ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd-replicas", Namespace: "default"},
+			Spec:       ControlPlaneSpec{Version: "v1.27.5"},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		cp.Spec.Etcd.Replicas = 5
+		err := k8sClient.Update(ctx, cp)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.etcd.replicas: Forbidden: field is immutable"))
+	})
+
+})
+
+var _ = Describe("ControlPlane defaulting webhook", func() {
+
+	It("Derives names and ports from the ControlPlane name", func() {
+		cp := &ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "minimal", Namespace: "default"},
+			Spec:       ControlPlaneSpec{Version: "v1.29.0"},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		Expect(cp.Spec.Loadbalancer.Name).Should(Equal("minimal-kube-apiserver"))
+		Expect(cp.Spec.Loadbalancer.Port).Should(Equal(int32(DefaultAPIServerPort)))
+		Expect(cp.Spec.PKI.CA.Name).Should(Equal("minimal-ca"))
+		Expect(cp.Spec.PKI.KubeAPIServer.IPAddresses).Should(ContainElement("10.32.0.1"))
+		Expect(cp.Spec.PKI.ETCD.Client).Should(Equal("minimal-etcd-client"))
+		Expect(cp.Spec.Etcd).ShouldNot(BeNil())
+		Expect(cp.Spec.Etcd.Replicas).Should(Equal(int32(DefaultReplicas)))
+
+		Expect(cp.Spec.KubeApiServer.Deployment.Name).Should(Equal("minimal-kube-apiserver"))
+		Expect(cp.Spec.KubeApiServer.TLS.KubeApiServerSecretName).Should(Equal("minimal-kube-apiserver"))
+		Expect(cp.Spec.KubeControllerManager.TLS.ServiceAccountsTLS).Should(Equal("minimal-service-accounts"))
+		Expect(cp.Spec.KubeControllerManager.KubeAPIServerService).Should(Equal(Service{Name: "minimal-kube-apiserver", Port: 6443}))
+		Expect(cp.Spec.KubeScheduler.KubeSchedulerTls).Should(Equal("minimal-kube-scheduler"))
+
+		Expect(cp.Spec.Network).Should(Equal(ControlPlaneNetwork{PodCIDRs: []string{"10.200.0.0/16"}, ServiceCIDRs: []string{"10.32.0.0/24"}, NodeCIDRMaskSize: 24, DNSDomain: "cluster.local"}))
+		Expect(cp.Spec.KubeControllerManager.Options).Should(Equal(KubeControllerManagerOptions{ClusterCIDR: "10.200.0.0/16", ServiceClusterIpRange: "10.32.0.0/24", NodeCIDRMaskSize: 24}))
+		Expect(cp.Spec.PKI.KubeAPIServer.DNSNames).Should(ContainElement("kubernetes.default.svc.cluster.local"))
+	})
+
+	It("Keeps the values set by the user", func() {
+		cp := validControlPlane("explicit")
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		Expect(cp.Spec.Loadbalancer.Name).Should(Equal("kube-apiserver"))
+		Expect(cp.Spec.PKI.CA.Name).Should(Equal("ca"))
+		Expect(cp.Spec.Etcd).Should(BeNil())
+		Expect(cp.Spec.KubeApiServer.Deployment.Name).Should(Equal("kube-apiserver"))
+	})
+})
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type EtcdTLS struct {
+	// Secret holding the etcd server certificate, its key and the CA
+	ServerSecretName string `json:"server-secret-name,omitempty"`
+	// Secret holding the etcd peer certificate, its key and the CA
+	PeerSecretName string `json:"peer-secret-name,omitempty"`
+}
+
+type EtcdStorage struct {
+	// Size of the persistent volume claim of each member
+	Size resource.Quantity `json:"size,omitempty"`
+	// Storage class of the persistent volume claims, the cluster default is used when empty
+	StorageClassName *string `json:"storage-class-name,omitempty"`
+}
+
+// EtcdSpec defines the desired state of Etcd
+type EtcdSpec struct {
+	// Etcd version, used as registry.k8s.io/etcd image tag
+	Version string `json:"version,omitempty"`
+
+	// Number of etcd members. It is only used to bootstrap the cluster and
+	// is immutable, scaling an existing cluster is not supported yet.
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Persistent storage of the members
+	Storage EtcdStorage `json:"storage,omitempty"`
+
+	// Peer and client TLS secret names
+	TLS EtcdTLS `json:"tls,omitempty"`
+
+	// Snapshot restored into the data directory of members that have no data yet
+	Snapshot *EtcdSnapshotSource `json:"snapshot,omitempty"`
+}
+
+// EtcdStatus defines the observed state of Etcd
+type EtcdStatus struct {
+	// Generation observed by the controller
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Number of members
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Number of ready members
+	ReadyReplicas int32 `json:"ready-replicas,omitempty"`
+
+	// Comma separated client URLs of the members, ready to be used as kube-apiserver --etcd-servers
+	Endpoints string `json:"endpoints,omitempty"`
+
+	// Conditions of the etcd cluster
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`
+//+kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.ready-replicas`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Etcd is the Schema for the etcds API
+type Etcd struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EtcdSpec   `json:"spec,omitempty"`
+	Status EtcdStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EtcdList contains a list of Etcd
+type EtcdList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Etcd `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Etcd{}, &EtcdList{})
+}
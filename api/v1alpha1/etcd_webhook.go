@@ -0,0 +1,76 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var etcdlog = logf.Log.WithName("etcd-resource")
+
+func (r *Etcd) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-etcd,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=etcds,verbs=create;update,versions=v1alpha1,name=vetcd.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Etcd{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Etcd) ValidateCreate() error {
+	etcdlog.Info("validate create", "name", r.Name)
+	return r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Etcd) ValidateUpdate(old runtime.Object) error {
+	etcdlog.Info("validate update", "name", r.Name)
+	return r.validate(old.(*Etcd))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Etcd) ValidateDelete() error {
+	return nil
+}
+
+func (r *Etcd) validate(old *Etcd) error {
+	spec := field.NewPath("spec")
+	errs := r.Spec.validate(spec)
+	if old != nil {
+		errs = append(errs, r.Spec.validateUpdate(spec, &old.Spec)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Etcd").GroupKind(), r.Name, errs)
+}
+
+// validateUpdate rejects the changes the members of a running cluster cannot follow
+func (s *EtcdSpec) validateUpdate(path *field.Path, old *EtcdSpec) field.ErrorList {
+	// Members are never added to or removed from the cluster, new members could not join it
+	return validateImmutable(path.Child("replicas"), strconv.Itoa(int(s.Replicas)), strconv.Itoa(int(old.Replicas)))
+}
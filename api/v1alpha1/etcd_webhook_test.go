@@ -0,0 +1,51 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Etcd webhook", func() {
+
+	It("Rejects a change of the number of members", func() {
+		etcd := &Etcd{
+			ObjectMeta: metav1.ObjectMeta{Name: "members", Namespace: "default"},
+			Spec:       EtcdSpec{Version: "3.5.9-0", Replicas: 3},
+		}
+		Expect(k8sClient.Create(ctx, etcd)).Should(Succeed())
+
+		etcd.Spec.Replicas = 1
+		err := k8sClient.Update(ctx, etcd)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.replicas: Forbidden: field is immutable"))
+	})
+
+	It("Rejects an empty cluster", func() {
+		etcd := &Etcd{
+			ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "default"},
+			Spec:       EtcdSpec{Version: "3.5.9-0"},
+		}
+		err := k8sClient.Create(ctx, etcd)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.replicas"))
+	})
+})
@@ -0,0 +1,132 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type EtcdSnapshotPVC struct {
+	// Persistent volume claim the snapshots are written to
+	ClaimName string `json:"claim-name"`
+}
+
+type EtcdSnapshotS3 struct {
+	// URL of the S3 compatible endpoint, e.g. https://s3.amazonaws.com or http://minio.minio.svc:9000
+	Endpoint string `json:"endpoint"`
+	// Bucket the snapshots are uploaded to
+	Bucket string `json:"bucket"`
+	// Key prefix of the snapshots inside the bucket
+	Prefix string `json:"prefix,omitempty"`
+	// Secret holding the access-key-id and secret-access-key keys
+	CredentialsSecretName string `json:"credentials-secret-name"`
+	// MinIO client image used to transfer snapshots
+	Image string `json:"image,omitempty"`
+}
+
+// EtcdSnapshotStorage is where snapshots are stored, exactly one of PVC or S3 must be set
+type EtcdSnapshotStorage struct {
+	PVC *EtcdSnapshotPVC `json:"pvc,omitempty"`
+	S3  *EtcdSnapshotS3  `json:"s3,omitempty"`
+}
+
+// EtcdSnapshotSource references a snapshot file in a storage
+type EtcdSnapshotSource struct {
+	EtcdSnapshotStorage `json:",inline"`
+
+	// Snapshot file name, relative to the PVC root or the S3 prefix
+	Snapshot string `json:"snapshot"`
+}
+
+// EtcdBackupSpec defines the desired state of EtcdBackup
+type EtcdBackupSpec struct {
+	// Name of the ControlPlane whose etcd is backed up
+	ControlPlane string `json:"control-plane"`
+
+	// Cron schedule of the backups, a single backup is taken when empty
+	Schedule string `json:"schedule,omitempty"`
+
+	// Storage the snapshots are streamed to
+	Storage EtcdSnapshotStorage `json:"storage"`
+
+	// Image providing etcdctl, etcdutl and a shell
+	Image string `json:"image,omitempty"`
+}
+
+// Phases of an EtcdBackup
+const (
+	EtcdBackupPending   = "Pending"
+	EtcdBackupRunning   = "Running"
+	EtcdBackupScheduled = "Scheduled"
+	EtcdBackupCompleted = "Completed"
+	EtcdBackupFailed    = "Failed"
+)
+
+// EtcdBackupStatus defines the observed state of EtcdBackup
+type EtcdBackupStatus struct {
+	// Pending, Running, Completed or Failed for single backups, Scheduled for cron backups
+	Phase string `json:"phase,omitempty"`
+
+	// File name of the last successful snapshot
+	Snapshot string `json:"snapshot,omitempty"`
+	// Location of the last successful snapshot, pvc://<claim>/<file> or s3://<bucket>/<key>
+	Location string `json:"location,omitempty"`
+	// Size of the last successful snapshot in bytes
+	Size int64 `json:"size,omitempty"`
+	// Etcd revision of the last successful snapshot
+	Revision int64 `json:"revision,omitempty"`
+	// Checksum of the last successful snapshot, sha256:<hex>
+	Checksum string `json:"checksum,omitempty"`
+
+	// Start time of the last snapshot job
+	StartTime *metav1.Time `json:"start-time,omitempty"`
+	// Completion time of the last successful snapshot job
+	CompletionTime *metav1.Time `json:"completion-time,omitempty"`
+	// Last time a scheduled backup was started
+	LastScheduleTime *metav1.Time `json:"last-schedule-time,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Control Plane",type=string,JSONPath=`.spec.control-plane`
+//+kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Revision",type=integer,JSONPath=`.status.revision`
+//+kubebuilder:printcolumn:name="Location",type=string,JSONPath=`.status.location`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// EtcdBackup is the Schema for the etcdbackups API
+type EtcdBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EtcdBackupSpec   `json:"spec,omitempty"`
+	Status EtcdBackupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EtcdBackupList contains a list of EtcdBackup
+type EtcdBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EtcdBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EtcdBackup{}, &EtcdBackupList{})
+}
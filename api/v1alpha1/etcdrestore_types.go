@@ -0,0 +1,92 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EtcdRestoreSpec defines the desired state of EtcdRestore
+type EtcdRestoreSpec struct {
+	// Name of the ControlPlane restored
+	ControlPlane string `json:"control-plane"`
+
+	// EtcdBackup whose last successful snapshot is restored
+	BackupName string `json:"backup-name,omitempty"`
+
+	// Snapshot restored, used when backup-name is empty
+	Source *EtcdSnapshotSource `json:"source,omitempty"`
+}
+
+// Phases of an EtcdRestore
+const (
+	EtcdRestorePending   = "Pending"
+	EtcdRestoreRestoring = "Restoring"
+	EtcdRestoreSwitching = "Switching"
+	EtcdRestoreCompleted = "Completed"
+	EtcdRestoreFailed    = "Failed"
+)
+
+// EtcdRestoreStatus defines the observed state of EtcdRestore
+type EtcdRestoreStatus struct {
+	// Pending, Restoring (seeding the new etcd), Switching (rolling kube-apiserver), Completed or Failed
+	Phase string `json:"phase,omitempty"`
+
+	// Details about the current phase
+	Message string `json:"message,omitempty"`
+
+	// Name of the Etcd seeded from the snapshot
+	EtcdName string `json:"etcd-name,omitempty"`
+
+	// Etcd the ControlPlane was using before the restore
+	PreviousEtcdName string `json:"previous-etcd-name,omitempty"`
+
+	// Snapshot restored
+	Snapshot string `json:"snapshot,omitempty"`
+
+	// Completion time of the restore
+	CompletionTime *metav1.Time `json:"completion-time,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Control Plane",type=string,JSONPath=`.spec.control-plane`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Etcd",type=string,JSONPath=`.status.etcd-name`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// EtcdRestore is the Schema for the etcdrestores API
+type EtcdRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EtcdRestoreSpec   `json:"spec,omitempty"`
+	Status EtcdRestoreStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EtcdRestoreList contains a list of EtcdRestore
+type EtcdRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EtcdRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EtcdRestore{}, &EtcdRestoreList{})
+}
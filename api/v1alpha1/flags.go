@@ -0,0 +1,188 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Components whose flags can be extended
+const (
+	ComponentKubeAPIServer         = "kube-apiserver"
+	ComponentKubeControllerManager = "kube-controller-manager"
+	ComponentKubeScheduler         = "kube-scheduler"
+)
+
+// managedFlags are set by the operator from the spec, they cannot be overridden by the extra args.
+// Names ending with - protect every flag starting with them.
+var managedFlags = map[string][]string{
+	ComponentKubeAPIServer: {
+		"advertise-address", "secure-port", "service-cluster-ip-range",
+		"client-ca-file", "tls-cert-file", "tls-private-key-file",
+		"etcd-servers", "etcd-cafile", "etcd-certfile", "etcd-keyfile", "etcd-prefix",
+		"service-account-issuer", "service-account-key-file", "service-account-signing-key-file",
+		"egress-selector-config-file", "enable-bootstrap-token-auth",
+		"kubelet-client-certificate", "kubelet-client-key",
+		"requestheader-", "proxy-client-cert-file", "proxy-client-key-file", "enable-aggregator-routing",
+		"audit-", "encryption-provider-config", "authentication-config", "oidc-",
+		"enable-admission-plugins", "disable-admission-plugins", "feature-gates",
+	},
+	ComponentKubeControllerManager: {
+		"secure-port", "service-cluster-ip-range", "cluster-cidr", "node-cidr-mask-size",
+		"node-cidr-mask-size-ipv4", "node-cidr-mask-size-ipv6",
+		"client-ca-file", "tls-cert-file", "tls-private-key-file", "root-ca-file",
+		"kubeconfig", "authentication-kubeconfig", "authorization-kubeconfig",
+		"service-account-private-key-file", "cluster-signing-cert-file", "cluster-signing-key-file",
+		"feature-gates",
+	},
+	ComponentKubeScheduler: {
+		"secure-port", "config",
+		"client-ca-file", "tls-cert-file", "tls-private-key-file",
+		"authentication-kubeconfig", "authorization-kubeconfig",
+		"feature-gates",
+	},
+}
+
+// IsManagedFlag reports whether the operator sets the flag of the component
+func IsManagedFlag(component, name string) bool {
+	for _, managed := range managedFlags[component] {
+		if name == managed || strings.HasSuffix(managed, "-") && strings.HasPrefix(name, managed) {
+			return true
+		}
+	}
+	return false
+}
+
+// exists reports why the name does not exist in the minor, empty when it does
+func (l flagLifecycle) exists(minor *version.Version) string {
+	if l.added != "" && !minor.AtLeast(version.MustParseSemantic(l.added)) {
+		return "before " + l.added
+	}
+	if l.removed != "" && minor.AtLeast(version.MustParseSemantic(l.removed)) {
+		return "since " + l.removed
+	}
+	return ""
+}
+
+// flagsMinor returns the minor of the version when its flags are known, nil with the reason otherwise. Invalid versions
+// are reported by the version validation.
+func flagsMinor(v string) (*version.Version, string) {
+	parsed, err := version.ParseSemantic(v)
+	if err != nil {
+		return nil, ""
+	}
+	minor := version.MustParseSemantic(fmt.Sprintf("v%d.%d.0", parsed.Major(), parsed.Minor()))
+	if !minor.AtLeast(version.MustParseGeneric(flagsMinVersion)) || version.MustParseGeneric(flagsMaxVersion).LessThan(minor) {
+		return nil, fmt.Sprintf("the flags of %s are not known, extra args and feature gates are accepted from %s to %s", v, flagsMinVersion, flagsMaxVersion)
+	}
+	return minor, ""
+}
+
+var (
+	flagNameRegexp    = regexp.MustCompile("^[a-z0-9]+(-[a-z0-9]+)*$")
+	featureGateRegexp = regexp.MustCompile("^[A-Z][A-Za-z0-9]*$")
+)
+
+// validateArgs checks the extra args and the feature gates of a component running the version against the flags and
+// gates the version knows
+func validateArgs(path *field.Path, component, v string, extraArgs map[string]string, featureGates map[string]bool) field.ErrorList {
+	errs := field.ErrorList{}
+	minor, unknown := flagsMinor(v)
+	for _, name := range sortedKeys(extraArgs) {
+		p := path.Child("extra-args").Key(name)
+		switch {
+		case !flagNameRegexp.MatchString(name):
+			errs = append(errs, field.Invalid(p, name, "must be a flag name without the leading --, e.g. profiling"))
+		case IsManagedFlag(component, name):
+			errs = append(errs, field.Forbidden(p, "the flag is managed by the operator"))
+		case unknown != "":
+			errs = append(errs, field.Forbidden(p, unknown))
+		case minor != nil:
+			lifecycle, ok := knownFlags[component][name]
+			if !ok {
+				errs = append(errs, field.Invalid(p, name, fmt.Sprintf("%s %s does not have this flag", component, v)))
+			} else if missing := lifecycle.exists(minor); missing != "" {
+				errs = append(errs, field.Invalid(p, name, fmt.Sprintf("%s does not have this flag %s", component, missing)))
+			}
+		}
+	}
+	for _, name := range sortedKeys(featureGates) {
+		p := path.Child("feature-gates").Key(name)
+		switch {
+		case !featureGateRegexp.MatchString(name):
+			errs = append(errs, field.Invalid(p, name, "must be a feature gate name, e.g. APIPriorityAndFairness"))
+		case unknown != "":
+			errs = append(errs, field.Forbidden(p, unknown))
+		case minor != nil:
+			lifecycle, ok := knownFeatureGates[name]
+			if !ok {
+				errs = append(errs, field.Invalid(p, name, fmt.Sprintf("%s %s does not have this feature gate", component, v)))
+			} else if missing := lifecycle.exists(minor); missing != "" {
+				errs = append(errs, field.Invalid(p, name, fmt.Sprintf("%s does not have this feature gate %s", component, missing)))
+			}
+		}
+	}
+	return errs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateArgs checks the flags of kube-apiserver running the version
+func (s *KubeAPIServerSpec) validateArgs(path *field.Path, v string) field.ErrorList {
+	errs := validateArgs(path, ComponentKubeAPIServer, v, s.ExtraArgs, s.FeatureGates)
+
+	plugins := path.Child("admission-plugins")
+	enabled := map[string]bool{}
+	for i, name := range s.AdmissionPlugins.Enable {
+		if !featureGateRegexp.MatchString(name) {
+			errs = append(errs, field.Invalid(plugins.Child("enable").Index(i), name, "must be an admission plugin name, e.g. PodNodeSelector"))
+		}
+		enabled[name] = true
+	}
+	for i, name := range s.AdmissionPlugins.Disable {
+		switch {
+		case !featureGateRegexp.MatchString(name):
+			errs = append(errs, field.Invalid(plugins.Child("disable").Index(i), name, "must be an admission plugin name, e.g. PodNodeSelector"))
+		case enabled[name]:
+			errs = append(errs, field.Invalid(plugins.Child("disable").Index(i), name, "cannot be enabled and disabled"))
+		}
+	}
+	return errs
+}
+
+// validateArgs checks the flags of kube-controller-manager running the version
+func (s *KubeControllerManagerSpec) validateArgs(path *field.Path, v string) field.ErrorList {
+	return validateArgs(path, ComponentKubeControllerManager, v, s.ExtraArgs, s.FeatureGates)
+}
+
+// validateArgs checks the flags of kube-scheduler running the version
+func (s *KubeSchedulerSpec) validateArgs(path *field.Path, v string) field.ErrorList {
+	return validateArgs(path, ComponentKubeScheduler, v, s.ExtraArgs, s.FeatureGates)
+}
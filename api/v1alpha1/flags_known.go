@@ -0,0 +1,348 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// The known flags and feature gates cover the minors from flagsMinVersion to flagsMaxVersion included, the extra args
+// and feature gates of the other versions cannot be validated and are rejected.
+const (
+	flagsMinVersion = "v1.26"
+	flagsMaxVersion = "v1.30"
+)
+
+// flagLifecycle is the range of versions a flag or feature gate exists in, from added included to removed excluded.
+// An empty bound is beyond the supported minors.
+type flagLifecycle struct {
+	added   string
+	removed string
+}
+
+// flagSet maps the names of flags or feature gates to the versions they exist in
+type flagSet map[string]flagLifecycle
+
+// always lists names present in every supported minor
+func always(names ...string) flagSet {
+	set := flagSet{}
+	for _, name := range names {
+		set[name] = flagLifecycle{}
+	}
+	return set
+}
+
+// merge returns the union of the sets, the later sets win
+func merge(sets ...flagSet) flagSet {
+	merged := flagSet{}
+	for _, set := range sets {
+		for name, lifecycle := range set {
+			merged[name] = lifecycle
+		}
+	}
+	return merged
+}
+
+// Flags shared by the components, from the component-base and apiserver option groups
+var (
+	secureServingFlags = always(
+		"bind-address", "cert-dir", "http2-max-streams-per-connection", "permit-address-sharing", "permit-port-sharing",
+		"secure-port", "tls-cert-file", "tls-cipher-suites", "tls-min-version", "tls-private-key-file", "tls-sni-cert-key",
+	)
+	delegatingAuthFlags = always(
+		"authentication-kubeconfig", "authentication-skip-lookup", "authentication-token-webhook-cache-ttl",
+		"authentication-tolerate-lookup-failure", "client-ca-file",
+		"requestheader-allowed-names", "requestheader-client-ca-file", "requestheader-extra-headers-prefix",
+		"requestheader-group-headers", "requestheader-username-headers",
+		"authorization-always-allow-paths", "authorization-kubeconfig",
+		"authorization-webhook-cache-authorized-ttl", "authorization-webhook-cache-unauthorized-ttl",
+	)
+	clientConnectionFlags = always(
+		"kubeconfig", "master", "kube-api-burst", "kube-api-content-type", "kube-api-qps",
+		"leader-elect", "leader-elect-lease-duration", "leader-elect-renew-deadline", "leader-elect-resource-lock",
+		"leader-elect-resource-name", "leader-elect-resource-namespace", "leader-elect-retry-period",
+	)
+	debuggingFlags = always("contention-profiling", "profiling")
+	metricsFlags   = merge(
+		always("allow-metric-labels", "disabled-metrics", "show-hidden-metrics-for-version"),
+		flagSet{"allow-metric-labels-manifest": {added: "v1.28.0"}},
+	)
+	// klog flags were removed by the structured logging migration
+	logsFlags = merge(
+		always("log-flush-frequency", "log-json-info-buffer-size", "log-json-split-stream", "logging-format", "v", "vmodule"),
+		flagSet{
+			"log-text-info-buffer-size": {added: "v1.28.0"},
+			"log-text-split-stream":     {added: "v1.28.0"},
+			"add-dir-header":            {removed: "v1.26.0"},
+			"alsologtostderr":           {removed: "v1.26.0"},
+			"log-backtrace-at":          {removed: "v1.26.0"},
+			"log-dir":                   {removed: "v1.26.0"},
+			"log-file":                  {removed: "v1.26.0"},
+			"log-file-max-size":         {removed: "v1.26.0"},
+			"logtostderr":               {removed: "v1.26.0"},
+			"one-output":                {removed: "v1.26.0"},
+			"skip-headers":              {removed: "v1.26.0"},
+			"skip-log-headers":          {removed: "v1.26.0"},
+			"stderrthreshold":           {removed: "v1.26.0"},
+		},
+	)
+)
+
+// knownFlags lists the flags of each component across the supported minors, the flags removed before them are kept to
+// tell the user when they went away
+var knownFlags = map[string]flagSet{
+	ComponentKubeAPIServer: merge(secureServingFlags, debuggingFlags, metricsFlags, logsFlags,
+		always(
+			// generic
+			"advertise-address", "cloud-provider-gce-l7lb-src-cidrs", "cloud-provider-gce-lb-src-cidrs", "cors-allowed-origins",
+			"default-not-ready-toleration-seconds", "default-unreachable-toleration-seconds", "enable-priority-and-fairness",
+			"external-hostname", "goaway-chance", "livez-grace-period", "max-mutating-requests-inflight",
+			"max-requests-inflight", "min-request-timeout", "request-timeout", "shutdown-delay-duration",
+			"shutdown-send-retry-after", "strict-transport-security-directives",
+			// etcd
+			"delete-collection-workers", "enable-garbage-collector", "encryption-provider-config", "etcd-cafile",
+			"etcd-certfile", "etcd-compaction-interval", "etcd-count-metric-poll-period", "etcd-db-metric-poll-interval",
+			"etcd-healthcheck-timeout", "etcd-keyfile", "etcd-prefix", "etcd-readycheck-timeout", "etcd-servers",
+			"etcd-servers-overrides", "lease-reuse-duration-seconds", "storage-backend", "storage-media-type",
+			"watch-cache", "watch-cache-sizes",
+			// authentication
+			"anonymous-auth", "api-audiences", "authentication-token-webhook-cache-ttl",
+			"authentication-token-webhook-config-file", "authentication-token-webhook-version", "client-ca-file",
+			"enable-bootstrap-token-auth", "oidc-ca-file", "oidc-client-id", "oidc-groups-claim", "oidc-groups-prefix",
+			"oidc-issuer-url", "oidc-required-claim", "oidc-signing-algs", "oidc-username-claim", "oidc-username-prefix",
+			"requestheader-allowed-names", "requestheader-client-ca-file", "requestheader-extra-headers-prefix",
+			"requestheader-group-headers", "requestheader-username-headers",
+			"service-account-extend-token-expiration", "service-account-issuer", "service-account-jwks-uri",
+			"service-account-key-file", "service-account-lookup", "service-account-max-token-expiration",
+			"token-auth-file",
+			// authorization
+			"authorization-mode", "authorization-policy-file", "authorization-webhook-cache-authorized-ttl",
+			"authorization-webhook-cache-unauthorized-ttl", "authorization-webhook-config-file",
+			"authorization-webhook-version",
+			// admission and API enablement
+			"admission-control", "admission-control-config-file", "disable-admission-plugins", "enable-admission-plugins",
+			"runtime-config",
+			// misc
+			"aggregator-reject-forwarding-redirect", "allow-privileged", "apiserver-count", "cloud-config",
+			"cloud-provider", "egress-selector-config-file", "enable-aggregator-routing", "endpoint-reconciler-type",
+			"event-ttl", "identity-lease-duration-seconds", "identity-lease-renew-interval-seconds",
+			"kubelet-certificate-authority", "kubelet-client-certificate", "kubelet-client-key",
+			"kubelet-preferred-address-types", "kubelet-timeout", "kubernetes-service-node-port",
+			"max-connection-bytes-per-sec", "proxy-client-cert-file", "proxy-client-key-file",
+			"service-account-signing-key-file", "service-cluster-ip-range", "service-node-port-range",
+			"tracing-config-file",
+		),
+		flagSet{
+			"kubelet-https":         {removed: "v1.22.0"},
+			"insecure-bind-address": {removed: "v1.24.0"},
+			"insecure-port":         {removed: "v1.24.0"},
+			"encryption-provider-config-automatic-reload": {added: "v1.26.0"},
+			"shutdown-watch-termination-grace-period":     {added: "v1.27.0"},
+			"peer-advertise-ip":                           {added: "v1.28.0"},
+			"peer-advertise-port":                         {added: "v1.28.0"},
+			"peer-ca-file":                                {added: "v1.28.0"},
+			"authentication-config":                       {added: "v1.29.0"},
+			"authorization-config":                        {added: "v1.29.0"},
+		},
+	),
+	ComponentKubeControllerManager: merge(secureServingFlags, delegatingAuthFlags, clientConnectionFlags, debuggingFlags, metricsFlags, logsFlags,
+		always(
+			// generic
+			"allocate-node-cidrs", "cidr-allocator-type", "cloud-config", "cloud-provider", "cluster-cidr", "cluster-name",
+			"configure-cloud-routes", "controller-start-interval", "controllers", "enable-leader-migration",
+			"external-cloud-volume-plugin", "leader-migration-config", "min-resync-period", "node-monitor-period",
+			"route-reconciliation-period", "use-service-account-credentials",
+			// controllers
+			"attach-detach-reconcile-sync-period", "disable-attach-detach-reconcile-sync",
+			"cluster-signing-cert-file", "cluster-signing-duration", "cluster-signing-key-file",
+			"cluster-signing-kube-apiserver-client-cert-file", "cluster-signing-kube-apiserver-client-key-file",
+			"cluster-signing-kubelet-client-cert-file", "cluster-signing-kubelet-client-key-file",
+			"cluster-signing-kubelet-serving-cert-file", "cluster-signing-kubelet-serving-key-file",
+			"cluster-signing-legacy-unknown-cert-file", "cluster-signing-legacy-unknown-key-file",
+			"concurrent-daemonset-syncs", "concurrent-deployment-syncs", "concurrent-endpoint-syncs",
+			"concurrent-ephemeralvolume-syncs", "concurrent-gc-syncs", "concurrent-namespace-syncs",
+			"concurrent-rc-syncs", "concurrent-replicaset-syncs", "concurrent-resource-quota-syncs",
+			"concurrent-service-endpoint-syncs", "concurrent-service-syncs", "concurrent-serviceaccount-token-syncs",
+			"concurrent-statefulset-syncs", "concurrent-ttl-after-finished-syncs", "concurrent-horizontal-pod-autoscaler-syncs",
+			"enable-garbage-collector", "endpoint-updates-batch-period", "endpointslice-updates-batch-period",
+			"max-endpoints-per-slice", "mirroring-concurrent-service-endpoint-syncs",
+			"mirroring-endpointslice-updates-batch-period", "mirroring-max-endpoints-per-subset",
+			"horizontal-pod-autoscaler-cpu-initialization-period", "horizontal-pod-autoscaler-downscale-stabilization",
+			"horizontal-pod-autoscaler-initial-readiness-delay", "horizontal-pod-autoscaler-sync-period",
+			"horizontal-pod-autoscaler-tolerance", "namespace-sync-period",
+			"node-cidr-mask-size", "node-cidr-mask-size-ipv4", "node-cidr-mask-size-ipv6", "service-cluster-ip-range",
+			"large-cluster-size-threshold", "node-eviction-rate", "node-monitor-grace-period", "node-startup-grace-period",
+			"secondary-node-eviction-rate", "unhealthy-zone-threshold",
+			"enable-dynamic-provisioning", "enable-hostpath-provisioner", "flex-volume-plugin-dir",
+			"pv-recycler-increment-timeout-nfs", "pv-recycler-minimum-timeout-hostpath", "pv-recycler-minimum-timeout-nfs",
+			"pv-recycler-pod-template-filepath-hostpath", "pv-recycler-pod-template-filepath-nfs",
+			"pv-recycler-timeout-increment-hostpath", "pvclaimbinder-sync-period",
+			"volume-host-allow-local-loopback", "volume-host-cidr-denylist",
+			"terminated-pod-gc-threshold", "resource-quota-sync-period", "root-ca-file", "service-account-private-key-file",
+		),
+		flagSet{
+			"experimental-cluster-signing-duration":               {removed: "v1.25.0"},
+			"enable-taint-manager":                                {removed: "v1.27.0"},
+			"pod-eviction-timeout":                                {removed: "v1.27.0"},
+			"concurrent-cron-job-syncs":                           {added: "v1.28.0"},
+			"concurrent-job-syncs":                                {added: "v1.28.0"},
+			"legacy-service-account-token-clean-up-period":        {added: "v1.28.0"},
+			"concurrent-validating-admission-policy-status-syncs": {added: "v1.28.0"},
+			"disable-force-detach-on-timeout":                     {added: "v1.30.0"},
+		},
+	),
+	ComponentKubeScheduler: merge(secureServingFlags, delegatingAuthFlags, clientConnectionFlags, debuggingFlags, metricsFlags, logsFlags,
+		always("config", "pod-max-in-unschedulable-pods-duration", "write-config-to"),
+		flagSet{
+			"policy-config-file":         {removed: "v1.23.0"},
+			"policy-configmap":           {removed: "v1.23.0"},
+			"policy-configmap-namespace": {removed: "v1.23.0"},
+			"use-legacy-policy-config":   {removed: "v1.23.0"},
+			"lock-object-name":           {removed: "v1.27.0"},
+			"lock-object-namespace":      {removed: "v1.27.0"},
+		},
+	),
+}
+
+// knownFeatureGates lists the feature gates across the supported minors, the components register the same Kubernetes
+// gates so every component accepts them. The gates removed before the supported minors are not listed.
+var knownFeatureGates = merge(
+	always(
+		"APIListChunking", "APIPriorityAndFairness", "APIResponseCompression", "APIServerIdentity", "APIServerTracing",
+		"AllAlpha", "AllBeta", "AnyVolumeDataSource", "AppArmor", "CPUManager", "CPUManagerPolicyAlphaOptions",
+		"CPUManagerPolicyBetaOptions", "CPUManagerPolicyOptions", "CSIMigrationPortworx", "CSIMigrationRBD",
+		"CSINodeExpandSecret", "CSIVolumeHealth", "CloudControllerManagerWebhook", "ComponentSLIs",
+		"ContainerCheckpoint", "ContextualLogging", "CrossNamespaceVolumeDataSource", "CustomCPUCFSQuotaPeriod",
+		"CustomResourceValidationExpressions", "DisableCloudProviders", "DisableKubeletCloudCredentialProviders",
+		"DynamicResourceAllocation", "EfficientWatchResumption", "EventedPLEG", "ExpandedDNSConfig",
+		"GracefulNodeShutdown", "GracefulNodeShutdownBasedOnPodPriority", "HPAContainerMetrics", "HPAScaleToZero",
+		"HonorPVReclaimPolicy", "InTreePluginAWSUnregister", "InTreePluginAzureDiskUnregister",
+		"InTreePluginAzureFileUnregister", "InTreePluginGCEUnregister", "InTreePluginOpenStackUnregister",
+		"InTreePluginPortworxUnregister", "InTreePluginRBDUnregister", "InTreePluginvSphereUnregister",
+		"JobPodFailurePolicy", "JobReadyPods", "KMSv2", "KubeletInUserNamespace", "KubeletPodResourcesGetAllocatable",
+		"KubeletTracing", "LegacyServiceAccountTokenTracking", "LocalStorageCapacityIsolationFSQuotaMonitoring",
+		"LogarithmicScaleDown", "LoggingAlphaOptions", "LoggingBetaOptions", "MatchLabelKeysInPodTopologySpread",
+		"MaxUnavailableStatefulSet", "MemoryManager", "MemoryQoS", "MinDomainsInPodTopologySpread",
+		"MinimizeIPTablesRestore", "NodeInclusionPolicyInPodTopologySpread", "NodeOutOfServiceVolumeDetach", "NodeSwap",
+		"OpenAPIEnums", "PDBUnhealthyPodEvictionPolicy", "PodAndContainerStatsFromCRI", "PodDeletionCost",
+		"PodDisruptionConditions", "PodSchedulingReadiness", "ProcMountType", "ProxyTerminatingEndpoints", "QOSReserved",
+		"ReadWriteOncePod", "RecoverVolumeExpansionFailure", "RemainingItemCount", "RotateKubeletServerCertificate",
+		"SELinuxMountReadWriteOncePod", "ServerSideApply", "ServerSideFieldValidation", "SizeMemoryBackedVolumes",
+		"StatefulSetAutoDeletePVC", "StatefulSetStartOrdinal", "StorageVersionAPI", "StorageVersionHash",
+		"TopologyAwareHints", "TopologyManagerPolicyAlphaOptions", "TopologyManagerPolicyBetaOptions",
+		"TopologyManagerPolicyOptions", "ValidatingAdmissionPolicy", "VolumeCapacityPriority", "WinDSR", "WinOverlay",
+		"WindowsHostNetwork",
+	),
+	flagSet{
+		"CSIMigrationvSphere":                       {removed: "v1.30.0"},
+		"ConsistentHTTPGetHandlers":                 {removed: "v1.30.0"},
+		"ControllerManagerLeaderMigration":          {removed: "v1.30.0"},
+		"CronJobTimeZone":                           {removed: "v1.29.0"},
+		"DownwardAPIHugePages":                      {removed: "v1.29.0"},
+		"GRPCContainerProbe":                        {removed: "v1.29.0"},
+		"JobMutableNodeSchedulingDirectives":        {removed: "v1.29.0"},
+		"LegacyServiceAccountTokenNoAutoGeneration": {removed: "v1.29.0"},
+		"MultiCIDRRangeAllocator":                   {removed: "v1.29.0"},
+		"ProbeTerminationGracePeriod":               {removed: "v1.29.0"},
+		"RetroactiveDefaultStorageClass":            {removed: "v1.29.0"},
+		"SeccompDefault":                            {removed: "v1.29.0"},
+		"TopologyManager":                           {removed: "v1.29.0"},
+		"UserNamespacesStatelessPodsSupport":        {removed: "v1.28.0"},
+		"CSIMigrationGCE":                           {removed: "v1.28.0"},
+		"CSIStorageCapacity":                        {removed: "v1.28.0"},
+		"DelegateFSGroupToCSIDriver":                {removed: "v1.28.0"},
+		"DevicePlugins":                             {removed: "v1.28.0"},
+		"DryRun":                                    {removed: "v1.28.0"},
+		"JobTrackingWithFinalizers":                 {removed: "v1.28.0"},
+		"KubeletCredentialProviders":                {removed: "v1.28.0"},
+		"MixedProtocolLBService":                    {removed: "v1.28.0"},
+		"NetworkPolicyStatus":                       {removed: "v1.28.0"},
+		"WindowsHostProcessContainers":              {removed: "v1.28.0"},
+		"CSIInlineVolume":                           {removed: "v1.27.0"},
+		"CSIMigration":                              {removed: "v1.27.0"},
+		"CSIMigrationAWS":                           {removed: "v1.27.0"},
+		"DaemonSetUpdateSurge":                      {removed: "v1.27.0"},
+		"EphemeralContainers":                       {removed: "v1.27.0"},
+		"ExpandCSIVolumes":                          {removed: "v1.27.0"},
+		"ExpandInUsePersistentVolumes":              {removed: "v1.27.0"},
+		"ExpandPersistentVolumes":                   {removed: "v1.27.0"},
+		"IdentifyPodOS":                             {removed: "v1.27.0"},
+		"LocalStorageCapacityIsolation":             {removed: "v1.27.0"},
+		"NetworkPolicyEndPort":                      {removed: "v1.27.0"},
+		"StatefulSetMinReadySeconds":                {removed: "v1.27.0"},
+		"APISelfSubjectReview":                      {added: "v1.26.0", removed: "v1.30.0"},
+		"AggregatedDiscoveryEndpoint":               {added: "v1.26.0"},
+		"AdmissionWebhookMatchConditions":           {added: "v1.27.0"},
+		"CloudDualStackNodeIPs":                     {added: "v1.27.0"},
+		"ClusterTrustBundle":                        {added: "v1.27.0"},
+		"ElasticIndexedJob":                         {added: "v1.27.0"},
+		"InPlacePodVerticalScaling":                 {added: "v1.27.0"},
+		"KubeletPodResourcesDynamicResources":       {added: "v1.27.0"},
+		"KubeletPodResourcesGet":                    {added: "v1.27.0"},
+		"MultiCIDRServiceAllocator":                 {added: "v1.27.0"},
+		"NewVolumeManagerReconstruction":            {added: "v1.27.0"},
+		"NodeLogQuery":                              {added: "v1.27.0"},
+		"SecurityContextDeny":                       {added: "v1.27.0", removed: "v1.30.0"},
+		"ServiceNodePortStaticSubrange":             {added: "v1.27.0"},
+		"StableLoadBalancerNodeSet":                 {added: "v1.27.0"},
+		"WatchList":                                 {added: "v1.27.0"},
+		"CRDValidationRatcheting":                   {added: "v1.28.0"},
+		"ConsistentListFromCache":                   {added: "v1.28.0"},
+		"DevicePluginCDIDevices":                    {added: "v1.28.0"},
+		"JobBackoffLimitPerIndex":                   {added: "v1.28.0"},
+		"JobPodReplacementPolicy":                   {added: "v1.28.0"},
+		"KMSv1":                                     {added: "v1.28.0"},
+		"KMSv2KDF":                                  {added: "v1.28.0"},
+		"KubeProxyDrainingTerminatingNodes":         {added: "v1.28.0"},
+		"KubeletCgroupDriverFromCRI":                {added: "v1.28.0"},
+		"LegacyServiceAccountTokenCleanUp":          {added: "v1.28.0"},
+		"PersistentVolumeLastPhaseTransitionTime":   {added: "v1.28.0"},
+		"PodHostIPs":                                {added: "v1.28.0"},
+		"PodIndexLabel":                             {added: "v1.28.0"},
+		"PodReadyToStartContainersCondition":        {added: "v1.28.0"},
+		"SchedulerQueueingHints":                    {added: "v1.28.0"},
+		"SidecarContainers":                         {added: "v1.28.0"},
+		"SkipReadOnlyValidationGCE":                 {added: "v1.28.0"},
+		"UnknownVersionInteroperabilityProxy":       {added: "v1.28.0"},
+		"UserNamespacesSupport":                     {added: "v1.28.0"},
+		"ClusterTrustBundleProjection":              {added: "v1.29.0"},
+		"DisableNodeKubeProxyVersion":               {added: "v1.29.0"},
+		"ImageMaximumGCAge":                         {added: "v1.29.0"},
+		"KubeletSeparateDiskGC":                     {added: "v1.29.0"},
+		"LoadBalancerIPMode":                        {added: "v1.29.0"},
+		"MatchLabelKeysInPodAffinity":               {added: "v1.29.0"},
+		"NFTablesProxyMode":                         {added: "v1.29.0"},
+		"PodLifecycleSleepAction":                   {added: "v1.29.0"},
+		"RuntimeClassInImageCriApi":                 {added: "v1.29.0"},
+		"SeparateTaintEvictionController":           {added: "v1.29.0"},
+		"ServiceAccountTokenJTI":                    {added: "v1.29.0"},
+		"ServiceAccountTokenNodeBinding":            {added: "v1.29.0"},
+		"ServiceAccountTokenNodeBindingValidation":  {added: "v1.29.0"},
+		"ServiceAccountTokenPodNodeInfo":            {added: "v1.29.0"},
+		"StructuredAuthenticationConfiguration":     {added: "v1.29.0"},
+		"StructuredAuthorizationConfiguration":      {added: "v1.29.0"},
+		"TranslateStreamCloseWebsocketRequests":     {added: "v1.29.0"},
+		"UserNamespacesPodSecurityStandards":        {added: "v1.29.0"},
+		"VolumeAttributesClass":                     {added: "v1.29.0"},
+		"ZeroLimitedNominalConcurrencyShares":       {added: "v1.29.0"},
+		"AppArmorFields":                            {added: "v1.30.0"},
+		"InformerResourceVersion":                   {added: "v1.30.0"},
+		"JobManagedBy":                              {added: "v1.30.0"},
+		"JobSuccessPolicy":                          {added: "v1.30.0"},
+		"PortForwardWebsockets":                     {added: "v1.30.0"},
+		"RecursiveReadOnlyMounts":                   {added: "v1.30.0"},
+		"RelaxedEnvironmentVariableValidation":      {added: "v1.30.0"},
+		"RetryGenerateName":                         {added: "v1.30.0"},
+		"ServiceTrafficDistribution":                {added: "v1.30.0"},
+	},
+)
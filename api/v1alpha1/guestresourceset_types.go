@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//+kubebuilder:validation:Enum=ApplyOnce;Reconcile
+
+// GuestResourceSetMode is how often the resources are applied to a guest cluster
+type GuestResourceSetMode string
+
+// Modes of a GuestResourceSet
+const (
+	// The resources are applied once to each guest cluster, later changes are not applied
+	GuestResourceSetModeApplyOnce GuestResourceSetMode = "ApplyOnce"
+	// The resources are applied again whenever they change, and periodically to revert the changes made in the guest cluster
+	GuestResourceSetModeReconcile GuestResourceSetMode = "Reconcile"
+)
+
+//+kubebuilder:validation:Enum=ConfigMap;Secret
+
+// GuestResourceKind is the kind of object holding manifests
+type GuestResourceKind string
+
+// Kinds of objects holding manifests
+const (
+	GuestResourceKindConfigMap GuestResourceKind = "ConfigMap"
+	GuestResourceKindSecret    GuestResourceKind = "Secret"
+)
+
+// GuestResource is a ConfigMap or a Secret of the namespace of the GuestResourceSet. Each of its keys holds YAML or
+// JSON manifests, several YAML documents are separated by ---.
+type GuestResource struct {
+	// Kind of the object holding the manifests
+	Kind GuestResourceKind `json:"kind"`
+	// Name of the object holding the manifests
+	Name string `json:"name"`
+}
+
+// GuestResourceSetSpec defines the desired state of GuestResourceSet
+type GuestResourceSetSpec struct {
+	// Labels of the ControlPlanes of the namespace the resources are applied to, an empty selector selects them all
+	ControlPlaneSelector metav1.LabelSelector `json:"control-plane-selector"`
+
+	// ConfigMaps and Secrets holding the manifests, applied in order. Namespaces and CustomResourceDefinitions are
+	// applied first, namespaced objects without a namespace are applied to default.
+	Resources []GuestResource `json:"resources"`
+
+	// How often the resources are applied to a guest cluster
+	//+kubebuilder:default=Reconcile
+	Mode GuestResourceSetMode `json:"mode,omitempty"`
+}
+
+// GuestResourceSetControlPlane is the result of the last apply to the guest cluster of a ControlPlane
+type GuestResourceSetControlPlane struct {
+	// Name of the ControlPlane
+	Name string `json:"name"`
+	// Whether every resource was applied by the last attempt
+	Applied bool `json:"applied"`
+	// Hash of the manifests last applied
+	Hash string `json:"hash,omitempty"`
+	// Number of objects last applied
+	Objects int32 `json:"objects,omitempty"`
+	// Time the manifests were last applied
+	LastAppliedTime *metav1.Time `json:"last-applied-time,omitempty"`
+	// Reason of the failure of the last attempt
+	Message string `json:"message,omitempty"`
+}
+
+// GuestResourceSetStatus defines the observed state of GuestResourceSet
+type GuestResourceSetStatus struct {
+	// Generation of the GuestResourceSet observed by the controller
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Results of the selected ControlPlanes
+	//+listType=map
+	//+listMapKey=name
+	ControlPlanes []GuestResourceSetControlPlane `json:"control-planes,omitempty"`
+
+	// Conditions of the GuestResourceSet
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=grs
+//+kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// GuestResourceSet applies manifests to the guest clusters of the ControlPlanes it selects
+type GuestResourceSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GuestResourceSetSpec   `json:"spec,omitempty"`
+	Status GuestResourceSetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GuestResourceSetList contains a list of GuestResourceSet
+type GuestResourceSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GuestResourceSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GuestResourceSet{}, &GuestResourceSetList{})
+}
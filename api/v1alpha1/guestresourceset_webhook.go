@@ -0,0 +1,90 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var guestresourcesetlog = logf.Log.WithName("guestresourceset-resource")
+
+func (r *GuestResourceSet) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-guestresourceset,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=guestresourcesets,verbs=create;update,versions=v1alpha1,name=vguestresourceset.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &GuestResourceSet{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *GuestResourceSet) ValidateCreate() error {
+	guestresourcesetlog.Info("validate create", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *GuestResourceSet) ValidateUpdate(old runtime.Object) error {
+	guestresourcesetlog.Info("validate update", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *GuestResourceSet) ValidateDelete() error {
+	return nil
+}
+
+func (r *GuestResourceSet) validate() error {
+	errs := r.Spec.validate(field.NewPath("spec"))
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("GuestResourceSet").GroupKind(), r.Name, errs)
+}
+
+func (s *GuestResourceSetSpec) validate(path *field.Path) field.ErrorList {
+	errs := metav1validation.ValidateLabelSelector(&s.ControlPlaneSelector, metav1validation.LabelSelectorValidationOptions{}, path.Child("control-plane-selector"))
+
+	if len(s.Resources) == 0 {
+		errs = append(errs, field.Required(path.Child("resources"), "at least one ConfigMap or Secret must hold the manifests"))
+	}
+	resources := map[GuestResource]bool{}
+	for i, resource := range s.Resources {
+		p := path.Child("resources").Index(i)
+		if resource.Kind != GuestResourceKindConfigMap && resource.Kind != GuestResourceKindSecret {
+			errs = append(errs, field.NotSupported(p.Child("kind"), resource.Kind, []string{string(GuestResourceKindConfigMap), string(GuestResourceKindSecret)}))
+		}
+		errs = append(errs, validateResourceName(p.Child("name"), resource.Name, true)...)
+		if resources[resource] {
+			errs = append(errs, field.Duplicate(p, resource))
+		}
+		resources[resource] = true
+	}
+
+	if s.Mode != "" && s.Mode != GuestResourceSetModeApplyOnce && s.Mode != GuestResourceSetModeReconcile {
+		errs = append(errs, field.NotSupported(path.Child("mode"), s.Mode, []string{string(GuestResourceSetModeApplyOnce), string(GuestResourceSetModeReconcile)}))
+	}
+	return errs
+}
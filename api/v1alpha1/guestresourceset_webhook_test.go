@@ -0,0 +1,58 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func validGuestResourceSet(name string) *GuestResourceSet {
+	return &GuestResourceSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: GuestResourceSetSpec{
+			ControlPlaneSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "standard"}},
+			Resources: []GuestResource{
+				{Kind: GuestResourceKindConfigMap, Name: "namespaces"},
+				{Kind: GuestResourceKindSecret, Name: "rbac"},
+			},
+		},
+	}
+}
+
+var _ = Describe("GuestResourceSet webhook", func() {
+
+	It("Accepts a valid GuestResourceSet in the Reconcile mode by default", func() {
+		grs := validGuestResourceSet("valid")
+		Expect(k8sClient.Create(ctx, grs)).Should(Succeed())
+		Expect(grs.Spec.Mode).Should(Equal(GuestResourceSetModeReconcile))
+	})
+
+	It("Rejects an invalid selector and duplicated resources", func() {
+		grs := validGuestResourceSet("invalid")
+		grs.Spec.ControlPlaneSelector = metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: metav1.LabelSelectorOpIn}}}
+		grs.Spec.Resources = append(grs.Spec.Resources, GuestResource{Kind: GuestResourceKindSecret, Name: "rbac"})
+
+		err := k8sClient.Create(ctx, grs)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.control-plane-selector.matchExpressions[0].values: Required value"))
+		Expect(err.Error()).Should(ContainSubstring("spec.resources[2]: Duplicate value"))
+	})
+})
@@ -17,7 +17,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -39,13 +41,179 @@ type KubeAPIServerTLS struct {
 	KubeApiServerSecretName   string `json:"kube-apiserver-secret-name,omitempty"`
 	ServiceAccountsSecretName string `json:"service-accounts-secret-name,omitempty"`
 	KonnectivitySecretName    string `json:"konnectivity-secret-name,omitempty"`
+	// Client certificate used to reach etcd, etcd is reached without TLS when empty
+	ETCDClientSecretName string `json:"etcd-client-secret-name,omitempty"`
+	// Client certificate, signed by the front-proxy CA, used to proxy requests to aggregated API servers.
+	// The kube-apiserver certificate and its CA are used when empty.
+	FrontProxyClientSecretName string `json:"front-proxy-client-secret-name,omitempty"`
 }
 
 type KubeAPIServerOptions struct {
-	AdvertiseAddress      string `json:"advertise-address,omitempty"`
+	// IP kube-apiserver advertises to the cluster, of the family of the primary service range
+	AdvertiseAddress string `json:"advertise-address,omitempty"`
+	// Ranges of the ClusterIP Services, an IPv4 and an IPv6 one separated by a comma with dual-stack
 	ServiceClusterIpRange string `json:"service-cluster-ip-range,omitempty"`
 }
 
+// OIDCIssuer is an OpenID Connect provider whose ID tokens authenticate users
+type OIDCIssuer struct {
+	// URL of the issuer, it must use https and match the iss claim of the tokens
+	IssuerURL string `json:"issuer-url"`
+	// Client ID the tokens must be issued for, matched against their aud claim
+	ClientID string `json:"client-id"`
+	// PEM encoded CA bundle verifying the issuer, the CAs of the kube-apiserver image are used when empty
+	CABundle string `json:"ca-bundle,omitempty"`
+
+	// Claim used as the user name, sub when empty
+	UsernameClaim string `json:"username-claim,omitempty"`
+	// Prefix of the user names, <issuer-url># when empty unless the username claim is email. - disables it.
+	UsernamePrefix string `json:"username-prefix,omitempty"`
+	// Claim holding the groups of the user, the user has no groups when empty
+	GroupsClaim string `json:"groups-claim,omitempty"`
+	// Prefix of the groups
+	GroupsPrefix string `json:"groups-prefix,omitempty"`
+
+	// Claims the tokens must hold with the given values
+	RequiredClaims map[string]string `json:"required-claims,omitempty"`
+}
+
+// EffectiveUsernamePrefix is the prefix prepended to the user names, following the defaults of the kube-apiserver --oidc-username-prefix flag
+func (o OIDCIssuer) EffectiveUsernamePrefix() string {
+	switch {
+	case o.UsernamePrefix == "-":
+		return ""
+	case o.UsernamePrefix != "":
+		return o.UsernamePrefix
+	case o.UsernameClaim == "email":
+		return ""
+	}
+	return o.IssuerURL + "#"
+}
+
+// KubeAPIServerAuthentication configures the authenticators of kube-apiserver, on top of client certificates and service account tokens
+type KubeAPIServerAuthentication struct {
+	// OIDC issuers, rendered to an AuthenticationConfiguration file from v1.30 and to the --oidc-* flags before.
+	// Versions before v1.30 only support one issuer.
+	OIDC []OIDCIssuer `json:"oidc,omitempty"`
+}
+
+// StructuredAuthenticationVersion is the first kube-apiserver version loading an AuthenticationConfiguration by default
+const StructuredAuthenticationVersion = "v1.30.0"
+
+// SupportsStructuredAuthentication reports whether kube-apiserver supports the --authentication-config flag, false when the version is invalid
+func SupportsStructuredAuthentication(v string) bool {
+	parsed, err := version.ParseSemantic(v)
+	return err == nil && parsed.AtLeast(version.MustParseSemantic(StructuredAuthenticationVersion))
+}
+
+// AuditLogBackend writes the audit events to files on a PersistentVolumeClaim, one file per kube-apiserver pod
+type AuditLogBackend struct {
+	// PersistentVolumeClaim the events are written to, it must be ReadWriteMany with more than one replica
+	ClaimName string `json:"claim-name"`
+
+	// Format of the events
+	//+kubebuilder:validation:Enum=json;legacy
+	//+kubebuilder:default=json
+	Format string `json:"format,omitempty"`
+
+	// Days the rotated files are kept, forever when empty
+	//+kubebuilder:validation:Minimum=0
+	MaxAge int32 `json:"max-age,omitempty"`
+	// Number of rotated files kept, all when empty
+	//+kubebuilder:validation:Minimum=0
+	MaxBackups int32 `json:"max-backups,omitempty"`
+	// Size in megabytes a file is rotated at
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default=100
+	MaxSize int32 `json:"max-size,omitempty"`
+}
+
+// AuditWebhookBackend sends the audit events to an HTTP server
+type AuditWebhookBackend struct {
+	// URL the events are posted to
+	Server string `json:"server"`
+	// PEM encoded CA bundle verifying the server, the CAs of the kube-apiserver image are used when empty
+	CABundle string `json:"ca-bundle,omitempty"`
+	// Secret holding the tls.crt and tls.key of a client certificate authenticating to the server
+	ClientSecretName string `json:"client-secret-name,omitempty"`
+
+	// Sending strategy: batch buffers events and sends them asynchronously, blocking sends each event before answering
+	// the request and blocking-strict also fails the request when sending fails
+	//+kubebuilder:validation:Enum=batch;blocking;blocking-strict
+	//+kubebuilder:default=batch
+	Mode string `json:"mode,omitempty"`
+	// Maximum number of events in a batch
+	//+kubebuilder:validation:Minimum=0
+	BatchMaxSize int32 `json:"batch-max-size,omitempty"`
+	// Time a batch waits for more events before being sent
+	BatchMaxWait *metav1.Duration `json:"batch-max-wait,omitempty"`
+	// Time waited before retrying a failed request
+	InitialBackoff *metav1.Duration `json:"initial-backoff,omitempty"`
+}
+
+// KubeAPIServerAudit configures the audit events recorded by kube-apiserver
+type KubeAPIServerAudit struct {
+	// audit.k8s.io/v1 Policy in YAML
+	Policy string `json:"policy,omitempty"`
+	// ConfigMap holding the audit.k8s.io/v1 Policy under the policy.yaml key, exclusive with policy
+	PolicyConfigMapName string `json:"policy-config-map-name,omitempty"`
+
+	Log     *AuditLogBackend     `json:"log,omitempty"`
+	Webhook *AuditWebhookBackend `json:"webhook,omitempty"`
+}
+
+// Providers encrypting the Secrets stored in etcd
+const (
+	EncryptionProviderAESCBC    = "aescbc"
+	EncryptionProviderSecretbox = "secretbox"
+	EncryptionProviderKMS       = "kms"
+)
+
+// KMSPluginVersion is the first kube-apiserver version enabling the KMS v2 API by default
+const KMSPluginVersion = "v1.27.0"
+
+// KMSPlugin is a KMS v2 plugin run as a sidecar of kube-apiserver, the keys stay in the external KMS it reaches
+type KMSPlugin struct {
+	// Name of the provider, stored with the encrypted Secrets so it cannot be changed
+	Name string `json:"name"`
+
+	// Image of the plugin, it must serve the KMS v2 API on the unix socket of the KMS_SOCKET environment variable
+	Image string `json:"image"`
+	// Arguments of the plugin, $(KMS_SOCKET) is replaced by the socket
+	Args []string `json:"args,omitempty"`
+	// Environment of the plugin, such as the credentials of the KMS
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Timeout of the calls of kube-apiserver to the plugin, 3s when empty
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// KubeAPIServerEncryption encrypts the Secrets stored in etcd with keys generated by the operator
+type KubeAPIServerEncryption struct {
+	// Provider of the keys, changing it rotates the keys. kms keeps the keys in an external KMS.
+	//+kubebuilder:validation:Enum=aescbc;secretbox;kms
+	//+kubebuilder:default=aescbc
+	Provider string `json:"provider,omitempty"`
+
+	// KMS v2 plugin, required by the kms provider. Remove it once the rotation to another provider is complete.
+	KMS *KMSPlugin `json:"kms,omitempty"`
+
+	// Any new value starts a key rotation
+	RotateKeys string `json:"rotate-keys,omitempty"`
+
+	// Secret holding the kubeconfig.yml of an administrator of the cluster, used to rewrite the Secrets with the new key
+	// during a rotation. Set by the ControlPlane to its admin kubeconfig.
+	AdminKubeconfigSecretName string `json:"admin-kubeconfig-secret-name,omitempty"`
+}
+
+// KubeAPIServerAdmissionPlugins changes the admission plugins enabled by the operator
+type KubeAPIServerAdmissionPlugins struct {
+	// Plugins enabled on top of the defaults
+	Enable []string `json:"enable,omitempty"`
+	// Plugins disabled, including the ones kube-apiserver enables by default
+	Disable []string `json:"disable,omitempty"`
+}
+
 // KubeAPIServerSpec defines the desired state of KubeAPIServer
 type KubeAPIServerSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -60,12 +228,90 @@ type KubeAPIServerSpec struct {
 	TLS KubeAPIServerTLS `json:"tls,omitempty"`
 
 	Options KubeAPIServerOptions `json:"options,omitempty"`
+
+	// Authentication of users with external identity providers
+	Authentication KubeAPIServerAuthentication `json:"authentication,omitempty"`
+
+	// Audit events, kube-apiserver records none when empty
+	Audit *KubeAPIServerAudit `json:"audit,omitempty"`
+
+	// Encryption of the Secrets at rest, they are stored in plaintext when empty. It cannot be removed once set.
+	Encryption *KubeAPIServerEncryption `json:"encryption,omitempty"`
+
+	// Flags of kube-apiserver without the leading --, merged over the defaults of the operator.
+	// The flags set from the spec cannot be overridden, the flags missing from the version of the
+	// component are rejected.
+	ExtraArgs map[string]string `json:"extra-args,omitempty"`
+
+	// Feature gates of kube-apiserver, the gates missing from its version are rejected
+	FeatureGates map[string]bool `json:"feature-gates,omitempty"`
+
+	// Admission plugins enabled or disabled on top of the defaults of the operator
+	AdmissionPlugins KubeAPIServerAdmissionPlugins `json:"admission-plugins,omitempty"`
+}
+
+// Steps of a key rotation, each one waits for kube-apiserver to be rolled out before the next one
+const (
+	// The new key can decrypt but does not encrypt yet, so that no replica fails to read data written by another one
+	EncryptionPhaseAddingKey = "AddingKey"
+	// The new key encrypts the new data
+	EncryptionPhasePromotingKey = "PromotingKey"
+	// The Secrets are rewritten through the cluster API to be encrypted with the new key
+	EncryptionPhaseRewritingSecrets = "RewritingSecrets"
+	// The old keys are removed
+	EncryptionPhaseRemovingOldKeys = "RemovingOldKeys"
+)
+
+// ConditionRotatingKeys is true while the encryption keys are rotated
+const ConditionRotatingKeys = "RotatingKeys"
+
+// EncryptionKey is a key of the EncryptionConfiguration, its material is only stored in the encryption Secret.
+// A kms key is named after its plugin and has no material.
+type EncryptionKey struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+}
+
+// KubeAPIServerEncryptionStatus is the state of the encryption keys
+type KubeAPIServerEncryptionStatus struct {
+	// Keys of the EncryptionConfiguration, the first one encrypts the new data and all of them decrypt
+	Keys []EncryptionKey `json:"keys,omitempty"`
+
+	// Step of the current key rotation, empty when there is none
+	//+kubebuilder:validation:Enum=AddingKey;PromotingKey;RewritingSecrets;RemovingOldKeys
+	Phase string `json:"phase,omitempty"`
+
+	// Value of rotate-keys of the last started rotation
+	ObservedRotation string `json:"observed-rotation,omitempty"`
+
+	// Time the last rotation started
+	LastRotationTime *metav1.Time `json:"last-rotation-time,omitempty"`
 }
 
 // KubeAPIServerStatus defines the observed state of KubeAPIServer
 type KubeAPIServerStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Generation observed by the controller
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Number of replicas of the Deployment
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Number of available replicas of the Deployment
+	AvailableReplicas int32 `json:"available-replicas,omitempty"`
+
+	// Number of replicas running the latest Deployment template
+	UpdatedReplicas int32 `json:"updated-replicas,omitempty"`
+
+	// Version running on every replica, updated once a rollout is complete
+	Version string `json:"version,omitempty"`
+
+	// Encryption keys and progress of their rotation
+	Encryption *KubeAPIServerEncryptionStatus `json:"encryption,omitempty"`
+
+	// Conditions of the component
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
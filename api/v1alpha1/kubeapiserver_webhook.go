@@ -0,0 +1,288 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var kubeapiserverlog = logf.Log.WithName("kubeapiserver-resource")
+
+func (r *KubeAPIServer) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-kubeapiserver,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=kubeapiservers,verbs=create;update,versions=v1alpha1,name=vkubeapiserver.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &KubeAPIServer{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeAPIServer) ValidateCreate() error {
+	kubeapiserverlog.Info("validate create", "name", r.Name)
+	return r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeAPIServer) ValidateUpdate(old runtime.Object) error {
+	kubeapiserverlog.Info("validate update", "name", r.Name)
+	return r.validate(old.(*KubeAPIServer))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeAPIServer) ValidateDelete() error {
+	return nil
+}
+
+func (r *KubeAPIServer) validate(old *KubeAPIServer) error {
+	spec := field.NewPath("spec")
+	errs := r.Spec.validate(spec, true)
+	errs = append(errs, r.Spec.Authentication.validate(spec.Child("authentication"), r.Spec.Version)...)
+	errs = append(errs, r.Spec.validateArgs(spec, r.Spec.Version)...)
+	errs = append(errs, validateURLs(spec.Child("etcd-servers"), r.Spec.ETCDservers, true)...)
+	if r.Spec.Encryption != nil {
+		errs = append(errs, r.Spec.Encryption.validate(spec.Child("encryption"), r.Spec.Version)...)
+		// A ControlPlane sets its admin kubeconfig
+		if r.Spec.Encryption.AdminKubeconfigSecretName == "" {
+			errs = append(errs, field.Required(spec.Child("encryption", "admin-kubeconfig-secret-name"), "the Secrets are rewritten with it during a key rotation"))
+		}
+	}
+	if old != nil {
+		errs = append(errs, r.Spec.validateUpdate(spec, old.Spec)...)
+		if r.Spec.Encryption != nil && old.Status.Encryption != nil {
+			errs = append(errs, r.Spec.Encryption.validateKMSKeys(spec.Child("encryption"), old.Status.Encryption.Keys)...)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("KubeAPIServer").GroupKind(), r.Name, errs)
+}
+
+// validate checks the spec, the version may be inherited from the ControlPlane
+func (s *KubeAPIServerSpec) validate(path *field.Path, versionRequired bool) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateVersion(path.Child("version"), s.Version, versionRequired)...)
+	errs = append(errs, s.Deployment.validate(path.Child("deployment"), true)...)
+
+	tls := path.Child("tls")
+	errs = append(errs, validateResourceName(tls.Child("ca-secret-name"), s.TLS.CASecretName, true)...)
+	errs = append(errs, validateResourceName(tls.Child("kube-apiserver-secret-name"), s.TLS.KubeApiServerSecretName, true)...)
+	errs = append(errs, validateResourceName(tls.Child("service-accounts-secret-name"), s.TLS.ServiceAccountsSecretName, true)...)
+	errs = append(errs, validateResourceName(tls.Child("konnectivity-secret-name"), s.TLS.KonnectivitySecretName, true)...)
+	errs = append(errs, validateResourceName(tls.Child("etcd-client-secret-name"), s.TLS.ETCDClientSecretName, false)...)
+	errs = append(errs, validateResourceName(tls.Child("front-proxy-client-secret-name"), s.TLS.FrontProxyClientSecretName, false)...)
+
+	options := path.Child("options")
+	errs = append(errs, validateIP(options.Child("advertise-address"), s.Options.AdvertiseAddress)...)
+	errs = append(errs, validateDualStackCIDRList(options.Child("service-cluster-ip-range"), s.Options.ServiceClusterIpRange, true)...)
+
+	if s.Audit != nil {
+		errs = append(errs, s.Audit.validate(path.Child("audit"))...)
+	}
+	return errs
+}
+
+// validate checks the audit policy is set once and is an audit.k8s.io/v1 Policy with rules, events need a backend
+func (a *KubeAPIServerAudit) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	switch {
+	case a.Policy != "" && a.PolicyConfigMapName != "":
+		errs = append(errs, field.Forbidden(path.Child("policy-config-map-name"), "cannot be set with policy"))
+	case a.Policy == "" && a.PolicyConfigMapName == "":
+		errs = append(errs, field.Required(path.Child("policy"), "policy or policy-config-map-name is required"))
+	case a.Policy != "":
+		policy := struct {
+			APIVersion string        `json:"apiVersion"`
+			Kind       string        `json:"kind"`
+			Rules      []interface{} `json:"rules"`
+		}{}
+		if err := yaml.Unmarshal([]byte(a.Policy), &policy); err != nil {
+			errs = append(errs, field.Invalid(path.Child("policy"), "<policy>", err.Error()))
+		} else if policy.APIVersion != "audit.k8s.io/v1" || policy.Kind != "Policy" {
+			errs = append(errs, field.Invalid(path.Child("policy"), "<policy>", "must be an audit.k8s.io/v1 Policy"))
+		} else if len(policy.Rules) == 0 {
+			errs = append(errs, field.Required(path.Child("policy"), "the policy must have rules"))
+		}
+	}
+	errs = append(errs, validateResourceName(path.Child("policy-config-map-name"), a.PolicyConfigMapName, false)...)
+
+	if a.Log == nil && a.Webhook == nil {
+		errs = append(errs, field.Required(path, "log or webhook backend is required"))
+	}
+	if a.Log != nil {
+		errs = append(errs, validateResourceName(path.Child("log", "claim-name"), a.Log.ClaimName, true)...)
+	}
+	if w := a.Webhook; w != nil {
+		webhook := path.Child("webhook")
+		if w.Server == "" {
+			errs = append(errs, field.Required(webhook.Child("server"), ""))
+		} else if u, err := url.Parse(w.Server); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, field.Invalid(webhook.Child("server"), w.Server, "must be an http(s) URL"))
+		}
+		if w.CABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(w.CABundle)) {
+			errs = append(errs, field.Invalid(webhook.Child("ca-bundle"), "<PEM>", "must hold PEM encoded certificates"))
+		}
+		errs = append(errs, validateResourceName(webhook.Child("client-secret-name"), w.ClientSecretName, false)...)
+	}
+	return errs
+}
+
+// validate checks the identity providers are supported by the kube-apiserver version, when it is known
+func (a *KubeAPIServerAuthentication) validate(path *field.Path, version string) field.ErrorList {
+	errs := field.ErrorList{}
+	oidc := path.Child("oidc")
+	if len(a.OIDC) > 1 && version != "" && !SupportsStructuredAuthentication(version) {
+		errs = append(errs, field.Forbidden(oidc, "multiple issuers require kube-apiserver "+StructuredAuthenticationVersion+" or later"))
+	}
+
+	issuers := map[string]bool{}
+	prefixes := map[string]int{}
+	for i, o := range a.OIDC {
+		p := oidc.Index(i)
+		errs = append(errs, validateRequired(p.Child("client-id"), o.ClientID)...)
+
+		if o.IssuerURL == "" {
+			errs = append(errs, field.Required(p.Child("issuer-url"), ""))
+		} else if u, err := url.Parse(o.IssuerURL); err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			errs = append(errs, field.Invalid(p.Child("issuer-url"), o.IssuerURL, "must be an https URL without query, fragment or credentials"))
+		} else if issuers[o.IssuerURL] {
+			errs = append(errs, field.Duplicate(p.Child("issuer-url"), o.IssuerURL))
+		}
+		issuers[o.IssuerURL] = true
+
+		if o.CABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(o.CABundle)) {
+			errs = append(errs, field.Invalid(p.Child("ca-bundle"), "<PEM>", "must hold PEM encoded certificates"))
+		}
+
+		// Identities of the Kubernetes components must not be reachable through a prefix
+		if strings.HasPrefix(o.UsernamePrefix, ReservedIdentityPrefix) {
+			errs = append(errs, field.Forbidden(p.Child("username-prefix"), "cannot start with "+ReservedIdentityPrefix))
+		}
+		if strings.HasPrefix(o.GroupsPrefix, ReservedIdentityPrefix) {
+			errs = append(errs, field.Forbidden(p.Child("groups-prefix"), "cannot start with "+ReservedIdentityPrefix))
+		}
+		// Issuers sharing a prefix could authenticate each other's users
+		if j, ok := prefixes[o.EffectiveUsernamePrefix()]; ok {
+			errs = append(errs, field.Invalid(p.Child("username-prefix"), o.UsernamePrefix, fmt.Sprintf("must differ from the user name prefix of issuer %d", j)))
+		}
+		prefixes[o.EffectiveUsernamePrefix()] = i
+
+		if _, ok := o.RequiredClaims[""]; ok {
+			errs = append(errs, field.Required(p.Child("required-claims"), "claim names cannot be empty"))
+		}
+	}
+	return errs
+}
+
+// validate checks the KMS plugin is set with the kms provider and supported by the kube-apiserver version, when it is known
+func (e *KubeAPIServerEncryption) validate(path *field.Path, version string) field.ErrorList {
+	errs := validateResourceName(path.Child("admin-kubeconfig-secret-name"), e.AdminKubeconfigSecretName, false)
+	if e.Provider == EncryptionProviderKMS && e.KMS == nil {
+		errs = append(errs, field.Required(path.Child("kms"), "the kms provider requires a plugin"))
+	}
+	if e.KMS == nil {
+		return errs
+	}
+
+	kms := path.Child("kms")
+	errs = append(errs, validateResourceName(kms.Child("name"), e.KMS.Name, true)...)
+	errs = append(errs, validateRequired(kms.Child("image"), e.KMS.Image)...)
+	if v, err := utilversion.ParseSemantic(version); err == nil && !v.AtLeast(utilversion.MustParseSemantic(KMSPluginVersion)) {
+		errs = append(errs, field.Forbidden(kms, "KMS v2 requires kube-apiserver "+KMSPluginVersion+" or later"))
+	}
+	if e.KMS.Timeout != nil && e.KMS.Timeout.Duration <= 0 {
+		errs = append(errs, field.Invalid(kms.Child("timeout"), e.KMS.Timeout.Duration.String(), "must be positive"))
+	}
+	return errs
+}
+
+// validateKMSKeys requires the plugin of the kms keys of the EncryptionConfiguration, a rotation to another provider
+// keeps them to decrypt the Secrets until they are all rewritten
+func (e *KubeAPIServerEncryption) validateKMSKeys(path *field.Path, keys []EncryptionKey) field.ErrorList {
+	errs := field.ErrorList{}
+	kms := path.Child("kms")
+	for _, key := range keys {
+		if key.Provider != EncryptionProviderKMS {
+			continue
+		}
+		if e.KMS == nil {
+			errs = append(errs, field.Forbidden(kms, fmt.Sprintf("cannot be removed while its key %s decrypts Secrets, remove it once the rotation is complete", key.Name)))
+		} else if e.KMS.Name != key.Name {
+			errs = append(errs, field.Forbidden(kms.Child("name"), fmt.Sprintf("cannot be changed while the key %s decrypts Secrets", key.Name)))
+		}
+	}
+	return errs
+}
+
+func (s *KubeAPIServerSpec) validateUpdate(path *field.Path, old KubeAPIServerSpec) field.ErrorList {
+	// Service IPs already allocated would be outside of a new range
+	errs := validateImmutable(path.Child("options", "service-cluster-ip-range"), s.Options.ServiceClusterIpRange, old.Options.ServiceClusterIpRange)
+	// The encrypted Secrets would become unreadable
+	if old.Encryption != nil && s.Encryption == nil {
+		errs = append(errs, field.Forbidden(path.Child("encryption"), "cannot be removed once set"))
+	}
+	// The Secrets encrypted by the plugin reference its name, and need it until they are rewritten with another provider
+	if old.Encryption != nil && old.Encryption.Provider == EncryptionProviderKMS && old.Encryption.KMS != nil && s.Encryption != nil {
+		kms := path.Child("encryption", "kms")
+		if s.Encryption.KMS == nil {
+			errs = append(errs, field.Forbidden(kms, "cannot be removed with the kms provider, remove it once the rotation to another provider is complete"))
+		} else {
+			errs = append(errs, validateImmutable(kms.Child("name"), s.Encryption.KMS.Name, old.Encryption.KMS.Name)...)
+		}
+	}
+	return errs
+}
+
+func (d *Deployment) validate(path *field.Path, nameRequired bool) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateResourceName(path.Child("name"), d.Name, nameRequired)...)
+	errs = append(errs, validateReplicas(path.Child("replicas"), d.Replicas)...)
+	errs = append(errs, metav1validation.ValidateLabels(d.Labels, path.Child("labels"))...)
+	return errs
+}
+
+// deploymentName returns the name of a Deployment created with a fallback name
+func deploymentName(d Deployment, fallback string) string {
+	if d.Name == "" {
+		return fallback
+	}
+	return d.Name
+}
+
+func (s *Service) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateResourceName(path.Child("name"), s.Name, true)...)
+	errs = append(errs, validatePort(path.Child("port"), int64(s.Port))...)
+	return errs
+}
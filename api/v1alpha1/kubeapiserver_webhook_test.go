@@ -0,0 +1,69 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("KubeAPIServer webhook", func() {
+
+	It("Keeps the KMS plugin while its key decrypts Secrets", func() {
+		kas := &KubeAPIServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "kms-rotation", Namespace: "default"},
+			Spec:       validControlPlane("kms-rotation").Spec.KubeApiServer,
+		}
+		kas.Spec.Version = "v1.28.2"
+		kas.Spec.Encryption = &KubeAPIServerEncryption{
+			Provider:                  EncryptionProviderKMS,
+			KMS:                       &KMSPlugin{Name: "vault", Image: "vault-kms-plugin:v1"},
+			AdminKubeconfigSecretName: "admin-kubeconfig",
+		}
+		Expect(k8sClient.Create(ctx, kas)).Should(Succeed())
+		kas.Status.Encryption = &KubeAPIServerEncryptionStatus{Keys: []EncryptionKey{{Name: "vault", Provider: EncryptionProviderKMS}}}
+		Expect(k8sClient.Status().Update(ctx, kas)).Should(Succeed())
+
+		By("Rotating to aescbc")
+		kas.Spec.Encryption.Provider = EncryptionProviderAESCBC
+		Expect(k8sClient.Update(ctx, kas)).Should(Succeed())
+		kas.Status.Encryption.Keys = []EncryptionKey{{Name: "key-1", Provider: EncryptionProviderAESCBC}, {Name: "vault", Provider: EncryptionProviderKMS}}
+		Expect(k8sClient.Status().Update(ctx, kas)).Should(Succeed())
+
+		By("Removing the plugin before the rotation completes")
+		plugin := kas.Spec.Encryption.KMS
+		kas.Spec.Encryption.KMS = nil
+		err := k8sClient.Update(ctx, kas)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.encryption.kms: Forbidden: cannot be removed while its key vault decrypts Secrets"))
+
+		kas.Spec.Encryption.KMS = &KMSPlugin{Name: "vault-v2", Image: "vault-kms-plugin:v2"}
+		err = k8sClient.Update(ctx, kas)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.encryption.kms.name: Forbidden"))
+
+		By("Removing the plugin once its key is removed")
+		kas.Spec.Encryption.KMS = plugin
+		kas.Status.Encryption.Keys = []EncryptionKey{{Name: "key-1", Provider: EncryptionProviderAESCBC}}
+		Expect(k8sClient.Status().Update(ctx, kas)).Should(Succeed())
+		kas.Spec.Encryption.KMS = nil
+		Expect(k8sClient.Update(ctx, kas)).Should(Succeed())
+	})
+})
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Expiration policies of a KubeconfigRequest
+const (
+	// The certificate is renewed once two thirds of its TTL have passed
+	KubeconfigExpirationRenew = "Renew"
+	// The KubeconfigRequest and its Secret are deleted once the certificate expires
+	KubeconfigExpirationDelete = "Delete"
+)
+
+// ReservedIdentityPrefix prefixes the users and groups of the Kubernetes components, such as system:masters or system:nodes.
+// A KubeconfigRequest cannot request them, cluster-admin access is granted through RBAC.
+const ReservedIdentityPrefix = "system:"
+
+// KubeconfigRequestSpec defines the desired state of KubeconfigRequest
+type KubeconfigRequestSpec struct {
+	// Name of the ControlPlane the kubeconfig gives access to
+	ControlPlane string `json:"control-plane"`
+
+	// User name of the certificate (its common name)
+	Username string `json:"username"`
+	// Groups of the user (the organizations of the certificate)
+	Groups []string `json:"groups,omitempty"`
+
+	// Validity of the certificate
+	//+kubebuilder:default="24h"
+	TTL metav1.Duration `json:"ttl,omitempty"`
+
+	// What happens when the TTL passes, Renew the certificate or Delete the request and its Secret
+	//+kubebuilder:validation:Enum=Renew;Delete
+	//+kubebuilder:default=Renew
+	ExpirationPolicy string `json:"expiration-policy,omitempty"`
+
+	// Secret the kubeconfig is written to, <name>-kubeconfig when empty. An existing Secret must be controlled by the
+	// KubeconfigRequest
+	SecretName string `json:"secret-name,omitempty"`
+}
+
+// KubeconfigRequestStatus defines the observed state of KubeconfigRequest
+type KubeconfigRequestStatus struct {
+	// Generation of the KubeconfigRequest the certificate was issued for
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Secret holding the kubeconfig.yml, tls.crt, tls.key and ca.crt keys
+	SecretName string `json:"secret-name,omitempty"`
+	// Endpoint of the kube-apiserver the kubeconfig points to
+	Endpoint string `json:"endpoint,omitempty"`
+	// Serial number of the certificate, in hexadecimal
+	SerialNumber string `json:"serial-number,omitempty"`
+
+	// Expiry of the certificate
+	ExpirationTime *metav1.Time `json:"expiration-time,omitempty"`
+	// Time the certificate will be renewed at, with the Renew expiration policy
+	RenewalTime *metav1.Time `json:"renewal-time,omitempty"`
+
+	// Conditions of the KubeconfigRequest
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Control Plane",type=string,JSONPath=`.spec.control-plane`
+//+kubebuilder:printcolumn:name="Username",type=string,JSONPath=`.spec.username`
+//+kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.status.secret-name`
+//+kubebuilder:printcolumn:name="Expires",type=string,JSONPath=`.status.expiration-time`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// KubeconfigRequest issues a client certificate from the CA of a ControlPlane and writes a kubeconfig using it
+type KubeconfigRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KubeconfigRequestSpec   `json:"spec,omitempty"`
+	Status KubeconfigRequestStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// KubeconfigRequestList contains a list of KubeconfigRequest
+type KubeconfigRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KubeconfigRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KubeconfigRequest{}, &KubeconfigRequestList{})
+}
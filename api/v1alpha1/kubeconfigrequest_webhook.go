@@ -0,0 +1,97 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// Bounds of the TTL of a KubeconfigRequest, the certificates cannot be revoked so they must expire
+const (
+	MinKubeconfigTTL = 10 * time.Minute
+	MaxKubeconfigTTL = 365 * 24 * time.Hour
+)
+
+// log is for logging in this package.
+var kubeconfigrequestlog = logf.Log.WithName("kubeconfigrequest-resource")
+
+func (r *KubeconfigRequest) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-kubeconfigrequest,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=kubeconfigrequests,verbs=create;update,versions=v1alpha1,name=vkubeconfigrequest.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &KubeconfigRequest{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeconfigRequest) ValidateCreate() error {
+	kubeconfigrequestlog.Info("validate create", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeconfigRequest) ValidateUpdate(old runtime.Object) error {
+	kubeconfigrequestlog.Info("validate update", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeconfigRequest) ValidateDelete() error {
+	return nil
+}
+
+func (r *KubeconfigRequest) validate() error {
+	errs := r.Spec.validate(field.NewPath("spec"))
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("KubeconfigRequest").GroupKind(), r.Name, errs)
+}
+
+func (s *KubeconfigRequestSpec) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateResourceName(path.Child("control-plane"), s.ControlPlane, true)...)
+	errs = append(errs, validateResourceName(path.Child("secret-name"), s.SecretName, false)...)
+
+	errs = append(errs, validateRequired(path.Child("username"), s.Username)...)
+	if strings.HasPrefix(s.Username, ReservedIdentityPrefix) {
+		errs = append(errs, field.Forbidden(path.Child("username"), "users prefixed with "+ReservedIdentityPrefix+" are reserved to the Kubernetes components"))
+	}
+	for i, g := range s.Groups {
+		if g == "" {
+			errs = append(errs, field.Required(path.Child("groups").Index(i), ""))
+		}
+		if strings.HasPrefix(g, ReservedIdentityPrefix) {
+			errs = append(errs, field.Forbidden(path.Child("groups").Index(i), "groups prefixed with "+ReservedIdentityPrefix+" are reserved to the Kubernetes components, grant permissions with RBAC instead"))
+		}
+	}
+
+	if s.TTL.Duration < MinKubeconfigTTL || s.TTL.Duration > MaxKubeconfigTTL {
+		errs = append(errs, field.Invalid(path.Child("ttl"), s.TTL.Duration.String(), "must be between "+MinKubeconfigTTL.String()+" and "+MaxKubeconfigTTL.String()))
+	}
+	return errs
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func validKubeconfigRequest(name string) *KubeconfigRequest {
+	return &KubeconfigRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: KubeconfigRequestSpec{
+			ControlPlane: "demo",
+			Username:     "jane@example.com",
+			Groups:       []string{"developers"},
+			TTL:          metav1.Duration{Duration: 8 * time.Hour},
+		},
+	}
+}
+
+var _ = Describe("KubeconfigRequest webhook", func() {
+
+	It("Accepts a valid KubeconfigRequest", func() {
+		Expect(k8sClient.Create(ctx, validKubeconfigRequest("valid"))).Should(Succeed())
+	})
+
+	It("Rejects the identities of the Kubernetes components", func() {
+		kr := validKubeconfigRequest("reserved")
+		kr.Spec.Username = "system:kube-controller-manager"
+		kr.Spec.Groups = []string{"developers", "system:masters"}
+
+		err := k8sClient.Create(ctx, kr)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.username"))
+		Expect(err.Error()).Should(ContainSubstring("spec.groups[1]"))
+	})
+
+	It("Rejects a TTL out of bounds", func() {
+		kr := validKubeconfigRequest("ttl")
+		kr.Spec.TTL = metav1.Duration{Duration: 2 * 365 * 24 * time.Hour}
+
+		err := k8sClient.Create(ctx, kr)
+		Expect(apierrors.IsInvalid(err)).Should(BeTrue())
+		Expect(err.Error()).Should(ContainSubstring("spec.ttl"))
+	})
+})
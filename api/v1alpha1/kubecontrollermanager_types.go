@@ -29,6 +29,18 @@ type KubeControllerManagerTLS struct {
 	ServiceAccountsTLS    string `json:"service-accounts-tls,omitempty"`
 }
 
+// KubeControllerManagerOptions are the networks kube-controller-manager allocates from
+type KubeControllerManagerOptions struct {
+	// Ranges of the pod IPs, comma separated with dual-stack. 10.200.0.0/16 when empty.
+	ClusterCIDR string `json:"cluster-cidr,omitempty"`
+	// Ranges of the ClusterIP Services, they must match the ones of kube-apiserver. 10.32.0.0/24 when empty.
+	ServiceClusterIpRange string `json:"service-cluster-ip-range,omitempty"`
+	// Prefix length of the IPv4 pod range of each node, 24 when empty
+	NodeCIDRMaskSize int32 `json:"node-cidr-mask-size,omitempty"`
+	// Prefix length of the IPv6 pod range of each node, 64 when empty
+	NodeCIDRMaskSizeIPv6 int32 `json:"node-cidr-mask-size-ipv6,omitempty"`
+}
+
 // KubeControllerManagerSpec defines the desired state of KubeControllerManager
 type KubeControllerManagerSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -45,12 +57,40 @@ type KubeControllerManagerSpec struct {
 
 	// KubeAPIServer Service infos
 	KubeAPIServerService Service `json:"kube-apiserver-service,omitempty"`
+
+	// Networks of the cluster
+	Options KubeControllerManagerOptions `json:"options,omitempty"`
+
+	// Flags of kube-controller-manager without the leading --, merged over the defaults of the operator.
+	// The flags set from the spec cannot be overridden, the flags missing from the version of the
+	// component are rejected.
+	ExtraArgs map[string]string `json:"extra-args,omitempty"`
+
+	// Feature gates of kube-controller-manager, the gates missing from its version are rejected
+	FeatureGates map[string]bool `json:"feature-gates,omitempty"`
 }
 
 // KubeControllerManagerStatus defines the observed state of KubeControllerManager
 type KubeControllerManagerStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Generation observed by the controller
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Number of replicas of the Deployment
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Number of available replicas of the Deployment
+	AvailableReplicas int32 `json:"available-replicas,omitempty"`
+
+	// Number of replicas running the latest Deployment template
+	UpdatedReplicas int32 `json:"updated-replicas,omitempty"`
+
+	// Version running on every replica, updated once a rollout is complete
+	Version string `json:"version,omitempty"`
+
+	// Conditions of the component
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
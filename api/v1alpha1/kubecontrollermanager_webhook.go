@@ -0,0 +1,91 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var kubecontrollermanagerlog = logf.Log.WithName("kubecontrollermanager-resource")
+
+func (r *KubeControllerManager) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-kubecontrollermanager,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=kubecontrollermanagers,verbs=create;update,versions=v1alpha1,name=vkubecontrollermanager.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &KubeControllerManager{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeControllerManager) ValidateCreate() error {
+	kubecontrollermanagerlog.Info("validate create", "name", r.Name)
+	return r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeControllerManager) ValidateUpdate(old runtime.Object) error {
+	kubecontrollermanagerlog.Info("validate update", "name", r.Name)
+	return r.validate(old.(*KubeControllerManager))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeControllerManager) ValidateDelete() error {
+	return nil
+}
+
+func (r *KubeControllerManager) validate(old *KubeControllerManager) error {
+	spec := field.NewPath("spec")
+	errs := r.Spec.validate(spec, true)
+	errs = append(errs, r.Spec.validateArgs(spec, r.Spec.Version)...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("KubeControllerManager").GroupKind(), r.Name, errs)
+}
+
+// validate checks the spec, the version may be inherited from the ControlPlane
+func (s *KubeControllerManagerSpec) validate(path *field.Path, versionRequired bool) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateVersion(path.Child("version"), s.Version, versionRequired)...)
+	// The Deployment is named kube-controller-manager when empty
+	errs = append(errs, s.Deployment.validate(path.Child("deployment"), false)...)
+	errs = append(errs, s.KubeAPIServerService.validate(path.Child("kube-apiserver-service"))...)
+
+	tls := path.Child("tls")
+	errs = append(errs, validateResourceName(tls.Child("ca"), s.TLS.CA, true)...)
+	errs = append(errs, validateResourceName(tls.Child("kube-controller-manager-tls"), s.TLS.KubeControllerManager, true)...)
+	errs = append(errs, validateResourceName(tls.Child("service-accounts-tls"), s.TLS.ServiceAccountsTLS, true)...)
+
+	options := path.Child("options")
+	errs = append(errs, validateDualStackCIDRList(options.Child("cluster-cidr"), s.Options.ClusterCIDR, false)...)
+	errs = append(errs, validateDualStackCIDRList(options.Child("service-cluster-ip-range"), s.Options.ServiceClusterIpRange, false)...)
+	if s.Options.NodeCIDRMaskSize < 0 || s.Options.NodeCIDRMaskSize > 32 {
+		errs = append(errs, field.Invalid(options.Child("node-cidr-mask-size"), s.Options.NodeCIDRMaskSize, "must be between 0 and 32"))
+	}
+	if s.Options.NodeCIDRMaskSizeIPv6 < 0 || s.Options.NodeCIDRMaskSizeIPv6 > 128 {
+		errs = append(errs, field.Invalid(options.Child("node-cidr-mask-size-ipv6"), s.Options.NodeCIDRMaskSizeIPv6, "must be between 0 and 128"))
+	}
+	return errs
+}
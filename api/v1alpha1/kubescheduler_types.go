@@ -38,12 +38,37 @@ type KubeSchedulerSpec struct {
 
 	// Deployment spec for kube-scheduler
 	Deployment Deployment `json:"deployment,omitempty"`
+
+	// Flags of kube-scheduler without the leading --, merged over the defaults of the operator.
+	// The flags set from the spec cannot be overridden, the flags missing from the version of the
+	// component are rejected.
+	ExtraArgs map[string]string `json:"extra-args,omitempty"`
+
+	// Feature gates of kube-scheduler, the gates missing from its version are rejected
+	FeatureGates map[string]bool `json:"feature-gates,omitempty"`
 }
 
 // KubeSchedulerStatus defines the observed state of KubeScheduler
 type KubeSchedulerStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Generation observed by the controller
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Number of replicas of the Deployment
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Number of available replicas of the Deployment
+	AvailableReplicas int32 `json:"available-replicas,omitempty"`
+
+	// Number of replicas running the latest Deployment template
+	UpdatedReplicas int32 `json:"updated-replicas,omitempty"`
+
+	// Version running on every replica, updated once a rollout is complete
+	Version string `json:"version,omitempty"`
+
+	// Conditions of the component
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
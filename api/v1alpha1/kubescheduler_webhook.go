@@ -0,0 +1,77 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var kubeschedulerlog = logf.Log.WithName("kubescheduler-resource")
+
+func (r *KubeScheduler) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-kubescheduler,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=kubeschedulers,verbs=create;update,versions=v1alpha1,name=vkubescheduler.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &KubeScheduler{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeScheduler) ValidateCreate() error {
+	kubeschedulerlog.Info("validate create", "name", r.Name)
+	return r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeScheduler) ValidateUpdate(old runtime.Object) error {
+	kubeschedulerlog.Info("validate update", "name", r.Name)
+	return r.validate(old.(*KubeScheduler))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *KubeScheduler) ValidateDelete() error {
+	return nil
+}
+
+func (r *KubeScheduler) validate(old *KubeScheduler) error {
+	spec := field.NewPath("spec")
+	errs := r.Spec.validate(spec, true)
+	errs = append(errs, r.Spec.validateArgs(spec, r.Spec.Version)...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("KubeScheduler").GroupKind(), r.Name, errs)
+}
+
+// validate checks the spec, the version may be inherited from the ControlPlane
+func (s *KubeSchedulerSpec) validate(path *field.Path, versionRequired bool) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateVersion(path.Child("version"), s.Version, versionRequired)...)
+	// The Deployment is named kube-scheduler when empty
+	errs = append(errs, s.Deployment.validate(path.Child("deployment"), false)...)
+	errs = append(errs, s.KubeAPIServerService.validate(path.Child("kube-apiserver-service"))...)
+	errs = append(errs, validateResourceName(path.Child("kube-scheduler-tls"), s.KubeSchedulerTls, true)...)
+	return errs
+}
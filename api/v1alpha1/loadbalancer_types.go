@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,17 +29,27 @@ type LoadbalancerSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Foo is an example field of Loadbalancer. Edit loadbalancer_types.go to remove/update
+	// Name of the LoadBalancer Service created for kube-apiserver
 	Name      string            `json:"name,omitempty"`
 	Port      int32             `json:"port,omitempty"`
 	Selectors map[string]string `json:"selectors,omitempty"`
+
+	// IP families of the Service, the default of the management cluster when empty. RequireDualStack or PreferDualStack
+	// give an IP of each family to kube-apiserver.
+	IPFamilyPolicy *corev1.IPFamilyPolicy `json:"ip-family-policy,omitempty"`
+	// IP families of the Service, the first one is the family of its primary IP
+	IPFamilies []corev1.IPFamily `json:"ip-families,omitempty"`
 }
 
 // LoadbalancerStatus defines the observed state of Loadbalancer
 type LoadbalancerStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Primary ingress IP of the Service
 	IP string `json:"ip,omitempty"`
+	// Every ingress IP of the Service, one per family with a dual-stack Service
+	IPs []string `json:"ips,omitempty"`
 }
 
 //+kubebuilder:object:root=true
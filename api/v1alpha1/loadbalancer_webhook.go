@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var loadbalancerlog = logf.Log.WithName("loadbalancer-resource")
+
+func (r *Loadbalancer) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-loadbalancer,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=loadbalancers,verbs=create;update,versions=v1alpha1,name=vloadbalancer.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Loadbalancer{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Loadbalancer) ValidateCreate() error {
+	loadbalancerlog.Info("validate create", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Loadbalancer) ValidateUpdate(old runtime.Object) error {
+	loadbalancerlog.Info("validate update", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Loadbalancer) ValidateDelete() error {
+	return nil
+}
+
+func (r *Loadbalancer) validate() error {
+	errs := r.Spec.validate(field.NewPath("spec"))
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Loadbalancer").GroupKind(), r.Name, errs)
+}
+
+func (s *LoadbalancerSpec) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateResourceName(path.Child("name"), s.Name, true)...)
+	errs = append(errs, validatePort(path.Child("port"), int64(s.Port))...)
+	errs = append(errs, metav1validation.ValidateLabels(s.Selectors, path.Child("selectors"))...)
+
+	for i, family := range s.IPFamilies {
+		if family != corev1.IPv4Protocol && family != corev1.IPv6Protocol {
+			errs = append(errs, field.NotSupported(path.Child("ip-families").Index(i), family, []string{string(corev1.IPv4Protocol), string(corev1.IPv6Protocol)}))
+		}
+	}
+	if len(s.IPFamilies) > 2 {
+		errs = append(errs, field.TooMany(path.Child("ip-families"), len(s.IPFamilies), 2))
+	}
+	if len(s.IPFamilies) == 2 && s.IPFamilies[0] == s.IPFamilies[1] {
+		errs = append(errs, field.Duplicate(path.Child("ip-families").Index(1), s.IPFamilies[1]))
+	}
+	if len(s.IPFamilies) > 1 && s.IPFamilyPolicy != nil && *s.IPFamilyPolicy == corev1.IPFamilyPolicySingleStack {
+		errs = append(errs, field.Invalid(path.Child("ip-families"), s.IPFamilies, "must hold one family with the SingleStack policy"))
+	}
+	return errs
+}
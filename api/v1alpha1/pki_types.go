@@ -23,40 +23,186 @@ import (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// PKIIssuerRef references a cert-manager issuer
+type PKIIssuerRef struct {
+	Name string `json:"name"`
+	// Issuer or ClusterIssuer, Issuer when empty
+	Kind string `json:"kind,omitempty"`
+	// API group of the issuer, cert-manager.io when empty
+	Group string `json:"group,omitempty"`
+}
+
+// PKICA is the cluster CA. It is a self-signed CA issued by the PKI unless it is imported from a secret or signed by an external issuer,
+// the CA secret then only holds the CA certificate and key so the components do not trust the rest of the chain.
 type PKICA struct {
 	Name string `json:"name,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
+
+	// Secret holding the tls.crt and tls.key of an existing CA to sign the certificates with
+	ImportSecretName string `json:"import-secret-name,omitempty"`
+
+	// cert-manager issuer signing the CA, such as a Vault issuer chaining the CA to an intermediate. Requires the cert-manager backend.
+	IssuerRef *PKIIssuerRef `json:"issuer-ref,omitempty"`
+	// Common name of the CA requested from the issuer, ca when empty. Issuers such as Vault usually restrict the allowed names.
+	CommonName string `json:"common-name,omitempty"`
 }
 
 type PKIServiceAccounts struct {
 	Name string `json:"name,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
 }
 
 type PKIAdmin struct {
 	Name string `json:"name,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
 }
 
 type PKIKubeAPIServer struct {
 	Name        string   `json:"name,omitempty"`
 	IPAddresses []string `json:"IPAddresses,omitempty"`
 	DNSNames    []string `json:"DNSNames,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
 }
 
 type PKIKubeControllerManager struct {
 	Name string `json:"name,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
 }
 
 type PKIKubeScheduler struct {
 	Name string `json:"name,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
 }
 
 type PKIKonnectivity struct {
 	Name string `json:"name,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
+}
+
+// PKIFrontProxyCA is the CA the aggregated API servers trust the requests proxied by kube-apiserver with
+type PKIFrontProxyCA struct {
+	// Secret name of the front-proxy CA, no front-proxy certificate is issued when empty
+	Name string `json:"name,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
+}
+
+// PKIFrontProxyClient is the certificate kube-apiserver proxies requests to the aggregated API servers with
+type PKIFrontProxyClient struct {
+	// Secret name of the front-proxy-client certificate, signed by the front-proxy CA
+	Name string `json:"name,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
+}
+
+type PKIEtcd struct {
+	// Secret name of the CA signing the etcd certificates, required with the etcd server certificate.
+	// A dedicated CA keeps the client certificates signed with the cluster CA, such as the kubelet ones, out of etcd.
+	CA string `json:"ca,omitempty"`
+	// Secret name of the etcd server certificate, no etcd certificate is issued when empty
+	Server string `json:"server,omitempty"`
+	// Secret name of the etcd peer certificate
+	Peer string `json:"peer,omitempty"`
+	// Secret name of the client certificate used by kube-apiserver to reach etcd
+	Client   string   `json:"client,omitempty"`
+	DNSNames []string `json:"DNSNames,omitempty"`
+
+	// Override the PKI profile for the CA, server, peer and client certificates
+	CAProfile     *CertificateProfile `json:"ca-profile,omitempty"`
+	ServerProfile *CertificateProfile `json:"server-profile,omitempty"`
+	PeerProfile   *CertificateProfile `json:"peer-profile,omitempty"`
+	ClientProfile *CertificateProfile `json:"client-profile,omitempty"`
+}
+
+// Private key algorithms of the certificates
+const (
+	KeyAlgorithmRSA     = "RSA"
+	KeyAlgorithmECDSA   = "ECDSA"
+	KeyAlgorithmEd25519 = "Ed25519"
+)
+
+// CertificateProfile configures the private key, validity and usages of certificates.
+// Fields left empty in a certificate profile are taken from the PKI profile.
+type CertificateProfile struct {
+	// Private key algorithm, RSA when empty
+	//+kubebuilder:validation:Enum=RSA;ECDSA;Ed25519
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// Private key size: 2048, 3072 or 4096 for RSA (2048 when empty), 256, 384 or 521 for ECDSA (256 when empty), unused for Ed25519
+	Size int `json:"size,omitempty"`
+
+	// Validity of the certificate, 10 years for CAs and 1 year for other certificates when empty (cert-manager: 90 days)
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// Time before expiry the certificate is renewed, a third of its validity when empty
+	RenewBefore *metav1.Duration `json:"renew-before,omitempty"`
+
+	// Key usages, named as in cert-manager ("digital signature", "server auth", "client auth", ...).
+	// CAs default to "cert sign", "crl sign" and "digital signature",
+	// other certificates to "digital signature", "key encipherment", "server auth" and "client auth".
+	Usages []string `json:"usages,omitempty"`
 }
 
+// Merge returns the profile with the fields set in override replacing its own
+func (p CertificateProfile) Merge(override *CertificateProfile) CertificateProfile {
+	if override == nil {
+		return p
+	}
+	if override.Algorithm != "" {
+		p.Algorithm = override.Algorithm
+		// A size is specific to its algorithm
+		p.Size = 0
+	}
+	if override.Size != 0 {
+		p.Size = override.Size
+	}
+	if override.Duration != nil {
+		p.Duration = override.Duration
+	}
+	if override.RenewBefore != nil {
+		p.RenewBefore = override.RenewBefore
+	}
+	if len(override.Usages) > 0 {
+		p.Usages = override.Usages
+	}
+	return p
+}
+
+// CAProfile returns the profile of a CA, which only inherits the key algorithm and size of the PKI profile
+func (s *PkiSpec) CAProfile(override *CertificateProfile) CertificateProfile {
+	return CertificateProfile{Algorithm: s.Profile.Algorithm, Size: s.Profile.Size}.Merge(override)
+}
+
+// PKI backends issuing the certificates
+const (
+	// PkiBackendCertManager issues the certificates with cert-manager Issuers and Certificates
+	PkiBackendCertManager = "cert-manager"
+	// PkiBackendNative issues the certificates in the operator and renews them itself
+	PkiBackendNative = "native"
+)
+
 // PkiSpec defines the desired state of Pki
 type PkiSpec struct {
+	// Backend issuing the certificates, the operator --pki-backend flag is used when empty
+	//+kubebuilder:validation:Enum=cert-manager;native
+	Backend string `json:"backend,omitempty"`
+
+	// Default profile of the certificates issued by the PKI, the CA only inherits its algorithm and size
+	Profile CertificateProfile `json:"profile,omitempty"`
+
+	// IP of the Loadbalancer the admin kubeconfig connects to
+	ControlPlaneIP string `json:"controlplane-ips,omitempty"`
+	// Every IP of the Loadbalancer, added to the kube-apiserver certificate with ControlPlaneIP
+	ControlPlaneIPs []string `json:"controlplane-ip-addresses,omitempty"`
+
 	Name                  string                   `json:"name,omitempty"`
-	ControlPlaneIP        string                   `json:"controlplane-ips,omitempty"`
 	CA                    PKICA                    `json:"ca,omitempty"`
 	ServiceAccounts       PKIServiceAccounts       `json:"service-accounts,omitempty"`
 	Admin                 PKIAdmin                 `json:"admin,omitempty"`
@@ -64,11 +210,28 @@ type PkiSpec struct {
 	KubeControllerManager PKIKubeControllerManager `json:"kube-controller-manager,omitempty"`
 	KubeScheduler         PKIKubeScheduler         `json:"kube-scheduler,omitempty"`
 	Konnectivity          PKIKonnectivity          `json:"konnectivity,omitempty"`
+	ETCD                  PKIEtcd                  `json:"etcd,omitempty"`
+	FrontProxyCA          PKIFrontProxyCA          `json:"front-proxy-ca,omitempty"`
+	FrontProxyClient      PKIFrontProxyClient      `json:"front-proxy-client,omitempty"`
 }
 
+// ConditionCAValid is reported by Pki resources, it is false when the imported or externally issued CA cannot sign certificates
+const ConditionCAValid = "CAValid"
+
 // PkiStatus defines the observed state of Pki
 type PkiStatus struct {
 	Ready bool `json:"ready,omitempty"`
+
+	// Conditions of the PKI
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Backend the certificates are issued with
+	Backend string `json:"backend,omitempty"`
+
+	// Name of the secret holding the admin kubeconfig
+	AdminKubeconfig string `json:"admin-kubeconfig,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,224 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var pkilog = logf.Log.WithName("pki-resource")
+
+func (r *Pki) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-cluster-kubeception-ulfo-fr-v1alpha1-pki,mutating=false,failurePolicy=fail,sideEffects=None,groups=cluster.kubeception.ulfo.fr,resources=pkis,verbs=create;update,versions=v1alpha1,name=vpki.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Pki{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Pki) ValidateCreate() error {
+	pkilog.Info("validate create", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Pki) ValidateUpdate(old runtime.Object) error {
+	pkilog.Info("validate update", "name", r.Name)
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Pki) ValidateDelete() error {
+	return nil
+}
+
+func (r *Pki) validate() error {
+	errs := r.Spec.validate(field.NewPath("spec"))
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Pki").GroupKind(), r.Name, errs)
+}
+
+// secretNames returns the secret name of every certificate issued by the PKI
+func (s *PkiSpec) secretNames(path *field.Path) map[*field.Path]string {
+	names := map[*field.Path]string{
+		path.Child("ca", "name"):                      s.CA.Name,
+		path.Child("service-accounts", "name"):        s.ServiceAccounts.Name,
+		path.Child("admin", "name"):                   s.Admin.Name,
+		path.Child("kube-apiserver", "name"):          s.KubeAPIServer.Name,
+		path.Child("kube-controller-manager", "name"): s.KubeControllerManager.Name,
+		path.Child("kube-scheduler", "name"):          s.KubeScheduler.Name,
+		path.Child("konnectivity", "name"):            s.Konnectivity.Name,
+	}
+	if s.ETCD.Server != "" {
+		names[path.Child("etcd", "server")] = s.ETCD.Server
+		names[path.Child("etcd", "peer")] = s.ETCD.Peer
+		names[path.Child("etcd", "client")] = s.ETCD.Client
+	}
+	if s.ETCD.CA != "" {
+		names[path.Child("etcd", "ca")] = s.ETCD.CA
+	}
+	if s.FrontProxyCA.Name != "" {
+		names[path.Child("front-proxy-ca", "name")] = s.FrontProxyCA.Name
+		names[path.Child("front-proxy-client", "name")] = s.FrontProxyClient.Name
+	}
+	return names
+}
+
+// KeyUsages are the key usages certificate profiles accept
+var KeyUsages = []string{
+	"signing", "digital signature", "content commitment", "key encipherment", "key agreement", "data encipherment",
+	"cert sign", "crl sign", "encipher only", "decipher only",
+	"any", "server auth", "client auth", "code signing", "email protection",
+	"ipsec end system", "ipsec tunnel", "ipsec user", "timestamping", "ocsp signing",
+}
+
+// validateProfile checks a merged profile, the CA profile must allow signing certificates
+func validateProfile(path *field.Path, p CertificateProfile, isCA bool) field.ErrorList {
+	errs := field.ErrorList{}
+
+	switch p.Algorithm {
+	case "", KeyAlgorithmRSA:
+		if p.Size != 0 && !sets.NewInt(2048, 3072, 4096).Has(p.Size) {
+			errs = append(errs, field.NotSupported(path.Child("size"), p.Size, []string{"2048", "3072", "4096"}))
+		}
+	case KeyAlgorithmECDSA:
+		if p.Size != 0 && !sets.NewInt(256, 384, 521).Has(p.Size) {
+			errs = append(errs, field.NotSupported(path.Child("size"), p.Size, []string{"256", "384", "521"}))
+		}
+	case KeyAlgorithmEd25519:
+		if p.Size != 0 {
+			errs = append(errs, field.Invalid(path.Child("size"), p.Size, "must be empty for Ed25519 keys"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(path.Child("algorithm"), p.Algorithm, []string{KeyAlgorithmRSA, KeyAlgorithmECDSA, KeyAlgorithmEd25519}))
+	}
+
+	if p.Duration != nil && p.Duration.Duration < time.Hour {
+		errs = append(errs, field.Invalid(path.Child("duration"), p.Duration.Duration.String(), "must be at least 1h"))
+	}
+	if p.RenewBefore != nil {
+		if p.RenewBefore.Duration < 5*time.Minute {
+			errs = append(errs, field.Invalid(path.Child("renew-before"), p.RenewBefore.Duration.String(), "must be at least 5m"))
+		} else if p.Duration != nil && p.RenewBefore.Duration >= p.Duration.Duration {
+			errs = append(errs, field.Invalid(path.Child("renew-before"), p.RenewBefore.Duration.String(), "must be shorter than the duration"))
+		}
+	}
+
+	for i, u := range p.Usages {
+		if !sets.NewString(KeyUsages...).Has(u) {
+			errs = append(errs, field.NotSupported(path.Child("usages").Index(i), u, KeyUsages))
+		}
+	}
+	if isCA && len(p.Usages) > 0 && !sets.NewString(p.Usages...).Has("cert sign") {
+		errs = append(errs, field.Invalid(path.Child("usages"), p.Usages, "must contain cert sign for a CA"))
+	}
+	return errs
+}
+
+func (s *PkiSpec) validate(path *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	errs = append(errs, validateResourceName(path.Child("name"), s.Name, true)...)
+	errs = append(errs, validateIP(path.Child("controlplane-ips"), s.ControlPlaneIP)...)
+	errs = append(errs, validateIPs(path.Child("controlplane-ip-addresses"), s.ControlPlaneIPs)...)
+
+	names := s.secretNames(path)
+	for _, p := range sortedPaths(names) {
+		errs = append(errs, validateResourceName(p, names[p], true)...)
+	}
+	errs = append(errs, validateUniqueNames(names)...)
+
+	errs = append(errs, validateIPs(path.Child("kube-apiserver", "IPAddresses"), s.KubeAPIServer.IPAddresses)...)
+	errs = append(errs, validateDNSNames(path.Child("kube-apiserver", "DNSNames"), s.KubeAPIServer.DNSNames)...)
+	errs = append(errs, validateDNSNames(path.Child("etcd", "DNSNames"), s.ETCD.DNSNames)...)
+	// etcd trusts every client certificate of its CA, the cluster CA also signs the kubelet and user certificates
+	if s.ETCD.Server != "" && s.ETCD.CA == "" {
+		errs = append(errs, field.Required(path.Child("etcd", "ca"), "the etcd certificates require a dedicated CA"))
+	}
+	if s.CA.ImportSecretName != "" && s.CA.IssuerRef != nil {
+		errs = append(errs, field.Forbidden(path.Child("ca", "issuer-ref"), "cannot be set with import-secret-name"))
+	}
+	errs = append(errs, validateResourceName(path.Child("ca", "import-secret-name"), s.CA.ImportSecretName, false)...)
+	if s.CA.ImportSecretName != "" && s.CA.ImportSecretName == s.CA.Name {
+		errs = append(errs, field.Invalid(path.Child("ca", "import-secret-name"), s.CA.ImportSecretName, "must differ from the CA secret name, the CA is copied to it"))
+	}
+	if ref := s.CA.IssuerRef; ref != nil {
+		issuer := path.Child("ca", "issuer-ref")
+		errs = append(errs, validateRequired(issuer.Child("name"), ref.Name)...)
+		// External issuers of other groups define their own kinds
+		if (ref.Group == "" || ref.Group == "cert-manager.io") && ref.Kind != "" && ref.Kind != "Issuer" && ref.Kind != "ClusterIssuer" {
+			errs = append(errs, field.NotSupported(issuer.Child("kind"), ref.Kind, []string{"Issuer", "ClusterIssuer"}))
+		}
+		if s.Backend == PkiBackendNative {
+			errs = append(errs, field.Forbidden(issuer, "external issuers require the cert-manager backend"))
+		}
+	} else if s.CA.CommonName != "" {
+		errs = append(errs, field.Forbidden(path.Child("ca", "common-name"), "only applies to a CA signed by an issuer-ref"))
+	}
+	if s.FrontProxyCA.Name == "" && s.FrontProxyClient.Name != "" {
+		errs = append(errs, field.Required(path.Child("front-proxy-ca", "name"), "the front-proxy-client certificate is signed by the front-proxy CA"))
+	}
+
+	// Errors are reported on the profile setting the field
+	errs = append(errs, validateProfile(path.Child("profile"), s.Profile, false)...)
+	if s.CA.Profile != nil {
+		errs = append(errs, validateProfile(path.Child("ca", "profile"), s.CAProfile(s.CA.Profile), true)...)
+	}
+	if s.ETCD.CAProfile != nil {
+		errs = append(errs, validateProfile(path.Child("etcd", "ca-profile"), s.CAProfile(s.ETCD.CAProfile), true)...)
+	}
+	if s.FrontProxyCA.Profile != nil {
+		errs = append(errs, validateProfile(path.Child("front-proxy-ca", "profile"), s.CAProfile(s.FrontProxyCA.Profile), true)...)
+	}
+	profiles := []struct {
+		path    *field.Path
+		profile *CertificateProfile
+	}{
+		{path.Child("service-accounts", "profile"), s.ServiceAccounts.Profile},
+		{path.Child("admin", "profile"), s.Admin.Profile},
+		{path.Child("kube-apiserver", "profile"), s.KubeAPIServer.Profile},
+		{path.Child("kube-controller-manager", "profile"), s.KubeControllerManager.Profile},
+		{path.Child("kube-scheduler", "profile"), s.KubeScheduler.Profile},
+		{path.Child("konnectivity", "profile"), s.Konnectivity.Profile},
+		{path.Child("etcd", "server-profile"), s.ETCD.ServerProfile},
+		{path.Child("etcd", "peer-profile"), s.ETCD.PeerProfile},
+		{path.Child("etcd", "client-profile"), s.ETCD.ClientProfile},
+		{path.Child("front-proxy-client", "profile"), s.FrontProxyClient.Profile},
+	}
+	for _, p := range profiles {
+		if p.profile != nil {
+			errs = append(errs, validateProfile(p.path, s.Profile.Merge(p.profile), false)...)
+		}
+	}
+	if s.Profile.Merge(s.ServiceAccounts.Profile).Algorithm == KeyAlgorithmEd25519 {
+		errs = append(errs, field.Invalid(path.Child("service-accounts", "profile", "algorithm"), KeyAlgorithmEd25519, "service account tokens cannot be signed with Ed25519 keys"))
+	}
+	return errs
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// Validation helpers shared by the webhooks of every kind
+
+func validateRequired(path *field.Path, value string) field.ErrorList {
+	if value == "" {
+		return field.ErrorList{field.Required(path, "")}
+	}
+	return nil
+}
+
+// validateVersion accepts Kubernetes release versions such as v1.27.5
+func validateVersion(path *field.Path, value string, required bool) field.ErrorList {
+	if value == "" {
+		if required {
+			return field.ErrorList{field.Required(path, "")}
+		}
+		return nil
+	}
+
+	if !strings.HasPrefix(value, "v") {
+		return field.ErrorList{field.Invalid(path, value, "must start with v, e.g. v1.27.5")}
+	}
+	if _, err := version.ParseSemantic(value); err != nil {
+		return field.ErrorList{field.Invalid(path, value, err.Error())}
+	}
+	return nil
+}
+
+// ValidateUpgrade rejects downgrades and upgrades skipping a minor version
+func ValidateUpgrade(from, to string) error {
+	current, err := version.ParseSemantic(from)
+	if err != nil {
+		return fmt.Errorf("invalid current version %s: %w", from, err)
+	}
+
+	target, err := version.ParseSemantic(to)
+	if err != nil {
+		return fmt.Errorf("invalid version %s: %w", to, err)
+	}
+
+	if target.LessThan(current) {
+		return fmt.Errorf("downgrade from %s to %s is not supported", from, to)
+	}
+
+	if target.Major() != current.Major() || target.Minor() > current.Minor()+1 {
+		return fmt.Errorf("upgrade from %s to %s skips a minor version, upgrade to v%d.%d first", from, to, current.Major(), current.Minor()+1)
+	}
+
+	return nil
+}
+
+func validateCIDR(path *field.Path, value string, required bool) field.ErrorList {
+	if value == "" {
+		if required {
+			return field.ErrorList{field.Required(path, "")}
+		}
+		return nil
+	}
+
+	ip, ipnet, err := net.ParseCIDR(value)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, value, "must be a valid CIDR, e.g. 10.32.0.0/24")}
+	}
+	if !ip.Equal(ipnet.IP) {
+		return field.ErrorList{field.Invalid(path, value, "must be the network address of the CIDR, e.g. "+ipnet.String())}
+	}
+	return nil
+}
+
+// hasIPv4CIDR reports whether one of the CIDRs is an IPv4 range
+func hasIPv4CIDR(cidrs []string) bool {
+	for _, cidr := range cidrs {
+		if ip, _, err := net.ParseCIDR(cidr); err == nil && ip.To4() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasIPv6CIDR reports whether one of the CIDRs is an IPv6 range
+func hasIPv6CIDR(cidrs []string) bool {
+	for _, cidr := range cidrs {
+		if ip, _, err := net.ParseCIDR(cidr); err == nil && ip.To4() == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDualStackCIDRs accepts one CIDR, or an IPv4 and an IPv6 one
+func validateDualStackCIDRs(path *field.Path, values []string) field.ErrorList {
+	errs := field.ErrorList{}
+	if len(values) > 2 {
+		return field.ErrorList{field.TooMany(path, len(values), 2)}
+	}
+	for i, value := range values {
+		errs = append(errs, validateCIDR(path.Index(i), value, true)...)
+	}
+	if len(errs) == 0 && len(values) == 2 {
+		first, _, _ := net.ParseCIDR(values[0])
+		second, _, _ := net.ParseCIDR(values[1])
+		if (first.To4() == nil) == (second.To4() == nil) {
+			errs = append(errs, field.Invalid(path.Index(1), values[1], "must be of another IP family than "+values[0]+" for dual-stack"))
+		}
+	}
+	return errs
+}
+
+// validateDualStackCIDRList is validateDualStackCIDRs for a comma separated list
+func validateDualStackCIDRList(path *field.Path, value string, required bool) field.ErrorList {
+	if value == "" {
+		if required {
+			return field.ErrorList{field.Required(path, "")}
+		}
+		return nil
+	}
+	if errs := validateDualStackCIDRs(path, strings.Split(value, ",")); len(errs) > 0 {
+		return field.ErrorList{field.Invalid(path, value, "must be a CIDR, or an IPv4 and an IPv6 CIDR separated by a comma, e.g. 10.32.0.0/24,fd00:32::/112")}
+	}
+	return nil
+}
+
+// validateNoOverlap checks the ranges share no address with each other nor with the reserved ones
+func validateNoOverlap(ranges map[*field.Path]*net.IPNet, reserved []*net.IPNet) field.ErrorList {
+	errs := field.ErrorList{}
+	paths := make([]*field.Path, 0, len(ranges))
+	for p := range ranges {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].String() < paths[j].String() })
+
+	overlap := func(a, b *net.IPNet) bool { return a.Contains(b.IP) || b.Contains(a.IP) }
+	for i, p := range paths {
+		for _, other := range paths[i+1:] {
+			if overlap(ranges[p], ranges[other]) {
+				errs = append(errs, field.Invalid(other, ranges[other].String(), "overlaps "+p.String()))
+			}
+		}
+		for _, r := range reserved {
+			if overlap(ranges[p], r) {
+				errs = append(errs, field.Invalid(p, ranges[p].String(), "overlaps the management cluster network "+r.String()))
+			}
+		}
+	}
+	return errs
+}
+
+func validateIP(path *field.Path, value string) field.ErrorList {
+	if value != "" && net.ParseIP(value) == nil {
+		return field.ErrorList{field.Invalid(path, value, "must be a valid IP address")}
+	}
+	return nil
+}
+
+func validateIPs(path *field.Path, values []string) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, v := range values {
+		errs = append(errs, validateIP(path.Index(i), v)...)
+	}
+	return errs
+}
+
+func validateDNSNames(path *field.Path, values []string) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, v := range values {
+		// Wildcards are allowed in certificates
+		for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(v, "*.")) {
+			errs = append(errs, field.Invalid(path.Index(i), v, msg))
+		}
+	}
+	return errs
+}
+
+// validateResourceName checks names of Secrets, Services and Deployments
+func validateResourceName(path *field.Path, value string, required bool) field.ErrorList {
+	if value == "" {
+		if required {
+			return field.ErrorList{field.Required(path, "")}
+		}
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	for _, msg := range validation.IsDNS1123Label(value) {
+		errs = append(errs, field.Invalid(path, value, msg))
+	}
+	return errs
+}
+
+func validatePort(path *field.Path, value int64) field.ErrorList {
+	if value < 1 || value > 65535 {
+		return field.ErrorList{field.Invalid(path, value, "must be between 1 and 65535")}
+	}
+	return nil
+}
+
+func validateReplicas(path *field.Path, value int32) field.ErrorList {
+	if value < 0 {
+		return field.ErrorList{field.Invalid(path, value, "must be greater than or equal to 0")}
+	}
+	return nil
+}
+
+// validateURLs checks a comma separated list of http(s) URLs
+func validateURLs(path *field.Path, value string, required bool) field.ErrorList {
+	if value == "" {
+		if required {
+			return field.ErrorList{field.Required(path, "")}
+		}
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	for _, v := range strings.Split(value, ",") {
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, field.Invalid(path, v, "must be a comma separated list of http(s) URLs"))
+		}
+	}
+	return errs
+}
+
+// validateUniqueNames rejects two fields holding the same name
+func validateUniqueNames(names map[*field.Path]string) field.ErrorList {
+	errs := field.ErrorList{}
+	seen := map[string]*field.Path{}
+
+	for _, p := range sortedPaths(names) {
+		name := names[p]
+		if name == "" {
+			continue
+		}
+		if other, ok := seen[name]; ok {
+			errs = append(errs, field.Invalid(p, name, "already used by "+other.String()))
+			continue
+		}
+		seen[name] = p
+	}
+	return errs
+}
+
+// sortedPaths returns the paths of a name map sorted, so errors are stable
+func sortedPaths(names map[*field.Path]string) []*field.Path {
+	paths := []*field.Path{}
+	for p := range names {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].String() < paths[j].String() })
+	return paths
+}
+
+func validateImmutable(path *field.Path, value, old string) field.ErrorList {
+	if old != "" && value != old {
+		return field.ErrorList{field.Forbidden(path, "field is immutable")}
+	}
+	return nil
+}
@@ -22,16 +22,199 @@ limitations under the License.
 package v1alpha1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogBackend) DeepCopyInto(out *AuditLogBackend) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogBackend.
+func (in *AuditLogBackend) DeepCopy() *AuditLogBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditWebhookBackend) DeepCopyInto(out *AuditWebhookBackend) {
+	*out = *in
+	if in.BatchMaxWait != nil {
+		in, out := &in.BatchMaxWait, &out.BatchMaxWait
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.InitialBackoff != nil {
+		in, out := &in.InitialBackoff, &out.InitialBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditWebhookBackend.
+func (in *AuditWebhookBackend) DeepCopy() *AuditWebhookBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditWebhookBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapToken) DeepCopyInto(out *BootstrapToken) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapToken.
+func (in *BootstrapToken) DeepCopy() *BootstrapToken {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BootstrapToken) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapTokenList) DeepCopyInto(out *BootstrapTokenList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BootstrapToken, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapTokenList.
+func (in *BootstrapTokenList) DeepCopy() *BootstrapTokenList {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapTokenList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BootstrapTokenList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapTokenSpec) DeepCopyInto(out *BootstrapTokenSpec) {
+	*out = *in
+	out.TTL = in.TTL
+	if in.Usages != nil {
+		in, out := &in.Usages, &out.Usages
+		*out = make([]BootstrapTokenUsage, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapTokenSpec.
+func (in *BootstrapTokenSpec) DeepCopy() *BootstrapTokenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapTokenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapTokenStatus) DeepCopyInto(out *BootstrapTokenStatus) {
+	*out = *in
+	if in.ExpirationTime != nil {
+		in, out := &in.ExpirationTime, &out.ExpirationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapTokenStatus.
+func (in *BootstrapTokenStatus) DeepCopy() *BootstrapTokenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapTokenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateProfile) DeepCopyInto(out *CertificateProfile) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Usages != nil {
+		in, out := &in.Usages, &out.Usages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateProfile.
+func (in *CertificateProfile) DeepCopy() *CertificateProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlane) DeepCopyInto(out *ControlPlane) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlane.
@@ -52,6 +235,39 @@ func (in *ControlPlane) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneAddon) DeepCopyInto(out *ControlPlaneAddon) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneAddon.
+func (in *ControlPlaneAddon) DeepCopy() *ControlPlaneAddon {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneAddon)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneAddons) DeepCopyInto(out *ControlPlaneAddons) {
+	*out = *in
+	out.CoreDNS = in.CoreDNS
+	out.KonnectivityAgent = in.KonnectivityAgent
+	out.KubeProxy = in.KubeProxy
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneAddons.
+func (in *ControlPlaneAddons) DeepCopy() *ControlPlaneAddons {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneAddons)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlaneList) DeepCopyInto(out *ControlPlaneList) {
 	*out = *in
@@ -84,14 +300,46 @@ func (in *ControlPlaneList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneNetwork) DeepCopyInto(out *ControlPlaneNetwork) {
+	*out = *in
+	if in.PodCIDRs != nil {
+		in, out := &in.PodCIDRs, &out.PodCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceCIDRs != nil {
+		in, out := &in.ServiceCIDRs, &out.ServiceCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneNetwork.
+func (in *ControlPlaneNetwork) DeepCopy() *ControlPlaneNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlaneSpec) DeepCopyInto(out *ControlPlaneSpec) {
 	*out = *in
+	in.Network.DeepCopyInto(&out.Network)
 	in.Loadbalancer.DeepCopyInto(&out.Loadbalancer)
 	in.PKI.DeepCopyInto(&out.PKI)
 	in.KubeApiServer.DeepCopyInto(&out.KubeApiServer)
 	in.KubeControllerManager.DeepCopyInto(&out.KubeControllerManager)
 	in.KubeScheduler.DeepCopyInto(&out.KubeScheduler)
+	if in.Etcd != nil {
+		in, out := &in.Etcd, &out.Etcd
+		*out = new(EtcdSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Addons = in.Addons
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneSpec.
@@ -107,6 +355,30 @@ func (in *ControlPlaneSpec) DeepCopy() *ControlPlaneSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControlPlaneStatus) DeepCopyInto(out *ControlPlaneStatus) {
 	*out = *in
+	if in.KubeconfigSecretRef != nil {
+		in, out := &in.KubeconfigSecretRef, &out.KubeconfigSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(ControlPlaneUpgrade)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeHistory != nil {
+		in, out := &in.UpgradeHistory, &out.UpgradeHistory
+		*out = make([]ControlPlaneUpgradeHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneStatus.
@@ -114,60 +386,809 @@ func (in *ControlPlaneStatus) DeepCopy() *ControlPlaneStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ControlPlaneStatus)
+	out := new(ControlPlaneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneUpgrade) DeepCopyInto(out *ControlPlaneUpgrade) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneUpgrade.
+func (in *ControlPlaneUpgrade) DeepCopy() *ControlPlaneUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneUpgradeHistory) DeepCopyInto(out *ControlPlaneUpgradeHistory) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneUpgradeHistory.
+func (in *ControlPlaneUpgradeHistory) DeepCopy() *ControlPlaneUpgradeHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneUpgradeHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Deployment) DeepCopyInto(out *Deployment) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Deployment.
+func (in *Deployment) DeepCopy() *Deployment {
+	if in == nil {
+		return nil
+	}
+	out := new(Deployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionKey) DeepCopyInto(out *EncryptionKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionKey.
+func (in *EncryptionKey) DeepCopy() *EncryptionKey {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Etcd) DeepCopyInto(out *Etcd) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Etcd.
+func (in *Etcd) DeepCopy() *Etcd {
+	if in == nil {
+		return nil
+	}
+	out := new(Etcd)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Etcd) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackup) DeepCopyInto(out *EtcdBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackup.
+func (in *EtcdBackup) DeepCopy() *EtcdBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackupList) DeepCopyInto(out *EtcdBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackupList.
+func (in *EtcdBackupList) DeepCopy() *EtcdBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackupSpec) DeepCopyInto(out *EtcdBackupSpec) {
+	*out = *in
+	in.Storage.DeepCopyInto(&out.Storage)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackupSpec.
+func (in *EtcdBackupSpec) DeepCopy() *EtcdBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackupStatus) DeepCopyInto(out *EtcdBackupStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackupStatus.
+func (in *EtcdBackupStatus) DeepCopy() *EtcdBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdList) DeepCopyInto(out *EtcdList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Etcd, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdList.
+func (in *EtcdList) DeepCopy() *EtcdList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRestore) DeepCopyInto(out *EtcdRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestore.
+func (in *EtcdRestore) DeepCopy() *EtcdRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRestoreList) DeepCopyInto(out *EtcdRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EtcdRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestoreList.
+func (in *EtcdRestoreList) DeepCopy() *EtcdRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EtcdRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRestoreSpec) DeepCopyInto(out *EtcdRestoreSpec) {
+	*out = *in
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(EtcdSnapshotSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestoreSpec.
+func (in *EtcdRestoreSpec) DeepCopy() *EtcdRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRestoreStatus) DeepCopyInto(out *EtcdRestoreStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestoreStatus.
+func (in *EtcdRestoreStatus) DeepCopy() *EtcdRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSnapshotPVC) DeepCopyInto(out *EtcdSnapshotPVC) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSnapshotPVC.
+func (in *EtcdSnapshotPVC) DeepCopy() *EtcdSnapshotPVC {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSnapshotPVC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSnapshotS3) DeepCopyInto(out *EtcdSnapshotS3) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSnapshotS3.
+func (in *EtcdSnapshotS3) DeepCopy() *EtcdSnapshotS3 {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSnapshotS3)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSnapshotSource) DeepCopyInto(out *EtcdSnapshotSource) {
+	*out = *in
+	in.EtcdSnapshotStorage.DeepCopyInto(&out.EtcdSnapshotStorage)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSnapshotSource.
+func (in *EtcdSnapshotSource) DeepCopy() *EtcdSnapshotSource {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSnapshotSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSnapshotStorage) DeepCopyInto(out *EtcdSnapshotStorage) {
+	*out = *in
+	if in.PVC != nil {
+		in, out := &in.PVC, &out.PVC
+		*out = new(EtcdSnapshotPVC)
+		**out = **in
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(EtcdSnapshotS3)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSnapshotStorage.
+func (in *EtcdSnapshotStorage) DeepCopy() *EtcdSnapshotStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSnapshotStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSpec) DeepCopyInto(out *EtcdSpec) {
+	*out = *in
+	in.Storage.DeepCopyInto(&out.Storage)
+	out.TLS = in.TLS
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(EtcdSnapshotSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSpec.
+func (in *EtcdSpec) DeepCopy() *EtcdSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdStatus) DeepCopyInto(out *EtcdStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdStatus.
+func (in *EtcdStatus) DeepCopy() *EtcdStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdStorage) DeepCopyInto(out *EtcdStorage) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdStorage.
+func (in *EtcdStorage) DeepCopy() *EtcdStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdTLS) DeepCopyInto(out *EtcdTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdTLS.
+func (in *EtcdTLS) DeepCopy() *EtcdTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestResource) DeepCopyInto(out *GuestResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestResource.
+func (in *GuestResource) DeepCopy() *GuestResource {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestResourceSet) DeepCopyInto(out *GuestResourceSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestResourceSet.
+func (in *GuestResourceSet) DeepCopy() *GuestResourceSet {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestResourceSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GuestResourceSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestResourceSetControlPlane) DeepCopyInto(out *GuestResourceSetControlPlane) {
+	*out = *in
+	if in.LastAppliedTime != nil {
+		in, out := &in.LastAppliedTime, &out.LastAppliedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestResourceSetControlPlane.
+func (in *GuestResourceSetControlPlane) DeepCopy() *GuestResourceSetControlPlane {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestResourceSetControlPlane)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestResourceSetList) DeepCopyInto(out *GuestResourceSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GuestResourceSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestResourceSetList.
+func (in *GuestResourceSetList) DeepCopy() *GuestResourceSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestResourceSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GuestResourceSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestResourceSetSpec) DeepCopyInto(out *GuestResourceSetSpec) {
+	*out = *in
+	in.ControlPlaneSelector.DeepCopyInto(&out.ControlPlaneSelector)
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]GuestResource, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestResourceSetSpec.
+func (in *GuestResourceSetSpec) DeepCopy() *GuestResourceSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestResourceSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GuestResourceSetStatus) DeepCopyInto(out *GuestResourceSetStatus) {
+	*out = *in
+	if in.ControlPlanes != nil {
+		in, out := &in.ControlPlanes, &out.ControlPlanes
+		*out = make([]GuestResourceSetControlPlane, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GuestResourceSetStatus.
+func (in *GuestResourceSetStatus) DeepCopy() *GuestResourceSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GuestResourceSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KMSPlugin) DeepCopyInto(out *KMSPlugin) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KMSPlugin.
+func (in *KMSPlugin) DeepCopy() *KMSPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(KMSPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeAPIServer) DeepCopyInto(out *KubeAPIServer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServer.
+func (in *KubeAPIServer) DeepCopy() *KubeAPIServer {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeAPIServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeAPIServer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeAPIServerAdmissionPlugins) DeepCopyInto(out *KubeAPIServerAdmissionPlugins) {
+	*out = *in
+	if in.Enable != nil {
+		in, out := &in.Enable, &out.Enable
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Disable != nil {
+		in, out := &in.Disable, &out.Disable
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerAdmissionPlugins.
+func (in *KubeAPIServerAdmissionPlugins) DeepCopy() *KubeAPIServerAdmissionPlugins {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeAPIServerAdmissionPlugins)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeAPIServerAudit) DeepCopyInto(out *KubeAPIServerAudit) {
+	*out = *in
+	if in.Log != nil {
+		in, out := &in.Log, &out.Log
+		*out = new(AuditLogBackend)
+		**out = **in
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(AuditWebhookBackend)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerAudit.
+func (in *KubeAPIServerAudit) DeepCopy() *KubeAPIServerAudit {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeAPIServerAudit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeAPIServerAuthentication) DeepCopyInto(out *KubeAPIServerAuthentication) {
+	*out = *in
+	if in.OIDC != nil {
+		in, out := &in.OIDC, &out.OIDC
+		*out = make([]OIDCIssuer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerAuthentication.
+func (in *KubeAPIServerAuthentication) DeepCopy() *KubeAPIServerAuthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeAPIServerAuthentication)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Deployment) DeepCopyInto(out *Deployment) {
+func (in *KubeAPIServerEncryption) DeepCopyInto(out *KubeAPIServerEncryption) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSPlugin)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Deployment.
-func (in *Deployment) DeepCopy() *Deployment {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerEncryption.
+func (in *KubeAPIServerEncryption) DeepCopy() *KubeAPIServerEncryption {
 	if in == nil {
 		return nil
 	}
-	out := new(Deployment)
+	out := new(KubeAPIServerEncryption)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KubeAPIServer) DeepCopyInto(out *KubeAPIServer) {
+func (in *KubeAPIServerEncryptionStatus) DeepCopyInto(out *KubeAPIServerEncryptionStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]EncryptionKey, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServer.
-func (in *KubeAPIServer) DeepCopy() *KubeAPIServer {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerEncryptionStatus.
+func (in *KubeAPIServerEncryptionStatus) DeepCopy() *KubeAPIServerEncryptionStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KubeAPIServer)
+	out := new(KubeAPIServerEncryptionStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KubeAPIServer) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeAPIServerList) DeepCopyInto(out *KubeAPIServerList) {
 	*out = *in
@@ -221,6 +1242,32 @@ func (in *KubeAPIServerSpec) DeepCopyInto(out *KubeAPIServerSpec) {
 	in.Deployment.DeepCopyInto(&out.Deployment)
 	out.TLS = in.TLS
 	out.Options = in.Options
+	in.Authentication.DeepCopyInto(&out.Authentication)
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(KubeAPIServerAudit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(KubeAPIServerEncryption)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.AdmissionPlugins.DeepCopyInto(&out.AdmissionPlugins)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerSpec.
@@ -236,6 +1283,18 @@ func (in *KubeAPIServerSpec) DeepCopy() *KubeAPIServerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeAPIServerStatus) DeepCopyInto(out *KubeAPIServerStatus) {
 	*out = *in
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(KubeAPIServerEncryptionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerStatus.
@@ -269,7 +1328,7 @@ func (in *KubeControllerManager) DeepCopyInto(out *KubeControllerManager) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeControllerManager.
@@ -322,12 +1381,42 @@ func (in *KubeControllerManagerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeControllerManagerOptions) DeepCopyInto(out *KubeControllerManagerOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeControllerManagerOptions.
+func (in *KubeControllerManagerOptions) DeepCopy() *KubeControllerManagerOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeControllerManagerOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeControllerManagerSpec) DeepCopyInto(out *KubeControllerManagerSpec) {
 	*out = *in
 	out.TLS = in.TLS
 	in.Deployment.DeepCopyInto(&out.Deployment)
 	out.KubeAPIServerService = in.KubeAPIServerService
+	out.Options = in.Options
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeControllerManagerSpec.
@@ -343,6 +1432,13 @@ func (in *KubeControllerManagerSpec) DeepCopy() *KubeControllerManagerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeControllerManagerStatus) DeepCopyInto(out *KubeControllerManagerStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeControllerManagerStatus.
@@ -376,7 +1472,7 @@ func (in *KubeScheduler) DeepCopyInto(out *KubeScheduler) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeScheduler.
@@ -434,6 +1530,20 @@ func (in *KubeSchedulerSpec) DeepCopyInto(out *KubeSchedulerSpec) {
 	*out = *in
 	out.KubeAPIServerService = in.KubeAPIServerService
 	in.Deployment.DeepCopyInto(&out.Deployment)
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeSchedulerSpec.
@@ -449,6 +1559,13 @@ func (in *KubeSchedulerSpec) DeepCopy() *KubeSchedulerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeSchedulerStatus) DeepCopyInto(out *KubeSchedulerStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeSchedulerStatus.
@@ -461,13 +1578,123 @@ func (in *KubeSchedulerStatus) DeepCopy() *KubeSchedulerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeconfigRequest) DeepCopyInto(out *KubeconfigRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeconfigRequest.
+func (in *KubeconfigRequest) DeepCopy() *KubeconfigRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeconfigRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeconfigRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeconfigRequestList) DeepCopyInto(out *KubeconfigRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KubeconfigRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeconfigRequestList.
+func (in *KubeconfigRequestList) DeepCopy() *KubeconfigRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeconfigRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KubeconfigRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeconfigRequestSpec) DeepCopyInto(out *KubeconfigRequestSpec) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.TTL = in.TTL
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeconfigRequestSpec.
+func (in *KubeconfigRequestSpec) DeepCopy() *KubeconfigRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeconfigRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeconfigRequestStatus) DeepCopyInto(out *KubeconfigRequestStatus) {
+	*out = *in
+	if in.ExpirationTime != nil {
+		in, out := &in.ExpirationTime, &out.ExpirationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RenewalTime != nil {
+		in, out := &in.RenewalTime, &out.RenewalTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeconfigRequestStatus.
+func (in *KubeconfigRequestStatus) DeepCopy() *KubeconfigRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeconfigRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Loadbalancer) DeepCopyInto(out *Loadbalancer) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Loadbalancer.
@@ -530,6 +1757,16 @@ func (in *LoadbalancerSpec) DeepCopyInto(out *LoadbalancerSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.IPFamilyPolicy != nil {
+		in, out := &in.IPFamilyPolicy, &out.IPFamilyPolicy
+		*out = new(corev1.IPFamilyPolicy)
+		**out = **in
+	}
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]corev1.IPFamily, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadbalancerSpec.
@@ -545,6 +1782,11 @@ func (in *LoadbalancerSpec) DeepCopy() *LoadbalancerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoadbalancerStatus) DeepCopyInto(out *LoadbalancerStatus) {
 	*out = *in
+	if in.IPs != nil {
+		in, out := &in.IPs, &out.IPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadbalancerStatus.
@@ -557,9 +1799,36 @@ func (in *LoadbalancerStatus) DeepCopy() *LoadbalancerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCIssuer) DeepCopyInto(out *OIDCIssuer) {
+	*out = *in
+	if in.RequiredClaims != nil {
+		in, out := &in.RequiredClaims, &out.RequiredClaims
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCIssuer.
+func (in *OIDCIssuer) DeepCopy() *OIDCIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PKIAdmin) DeepCopyInto(out *PKIAdmin) {
 	*out = *in
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIAdmin.
@@ -575,6 +1844,16 @@ func (in *PKIAdmin) DeepCopy() *PKIAdmin {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PKICA) DeepCopyInto(out *PKICA) {
 	*out = *in
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(PKIIssuerRef)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKICA.
@@ -587,9 +1866,109 @@ func (in *PKICA) DeepCopy() *PKICA {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKIEtcd) DeepCopyInto(out *PKIEtcd) {
+	*out = *in
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CAProfile != nil {
+		in, out := &in.CAProfile, &out.CAProfile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServerProfile != nil {
+		in, out := &in.ServerProfile, &out.ServerProfile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PeerProfile != nil {
+		in, out := &in.PeerProfile, &out.PeerProfile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientProfile != nil {
+		in, out := &in.ClientProfile, &out.ClientProfile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIEtcd.
+func (in *PKIEtcd) DeepCopy() *PKIEtcd {
+	if in == nil {
+		return nil
+	}
+	out := new(PKIEtcd)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKIFrontProxyCA) DeepCopyInto(out *PKIFrontProxyCA) {
+	*out = *in
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIFrontProxyCA.
+func (in *PKIFrontProxyCA) DeepCopy() *PKIFrontProxyCA {
+	if in == nil {
+		return nil
+	}
+	out := new(PKIFrontProxyCA)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKIFrontProxyClient) DeepCopyInto(out *PKIFrontProxyClient) {
+	*out = *in
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIFrontProxyClient.
+func (in *PKIFrontProxyClient) DeepCopy() *PKIFrontProxyClient {
+	if in == nil {
+		return nil
+	}
+	out := new(PKIFrontProxyClient)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKIIssuerRef) DeepCopyInto(out *PKIIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIIssuerRef.
+func (in *PKIIssuerRef) DeepCopy() *PKIIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(PKIIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PKIKonnectivity) DeepCopyInto(out *PKIKonnectivity) {
 	*out = *in
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIKonnectivity.
@@ -615,6 +1994,11 @@ func (in *PKIKubeAPIServer) DeepCopyInto(out *PKIKubeAPIServer) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIKubeAPIServer.
@@ -630,6 +2014,11 @@ func (in *PKIKubeAPIServer) DeepCopy() *PKIKubeAPIServer {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PKIKubeControllerManager) DeepCopyInto(out *PKIKubeControllerManager) {
 	*out = *in
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIKubeControllerManager.
@@ -645,6 +2034,11 @@ func (in *PKIKubeControllerManager) DeepCopy() *PKIKubeControllerManager {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PKIKubeScheduler) DeepCopyInto(out *PKIKubeScheduler) {
 	*out = *in
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIKubeScheduler.
@@ -660,6 +2054,11 @@ func (in *PKIKubeScheduler) DeepCopy() *PKIKubeScheduler {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PKIServiceAccounts) DeepCopyInto(out *PKIServiceAccounts) {
 	*out = *in
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKIServiceAccounts.
@@ -678,7 +2077,7 @@ func (in *Pki) DeepCopyInto(out *Pki) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pki.
@@ -734,13 +2133,22 @@ func (in *PkiList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PkiSpec) DeepCopyInto(out *PkiSpec) {
 	*out = *in
-	out.CA = in.CA
-	out.ServiceAccounts = in.ServiceAccounts
-	out.Admin = in.Admin
+	in.Profile.DeepCopyInto(&out.Profile)
+	if in.ControlPlaneIPs != nil {
+		in, out := &in.ControlPlaneIPs, &out.ControlPlaneIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.CA.DeepCopyInto(&out.CA)
+	in.ServiceAccounts.DeepCopyInto(&out.ServiceAccounts)
+	in.Admin.DeepCopyInto(&out.Admin)
 	in.KubeAPIServer.DeepCopyInto(&out.KubeAPIServer)
-	out.KubeControllerManager = in.KubeControllerManager
-	out.KubeScheduler = in.KubeScheduler
-	out.Konnectivity = in.Konnectivity
+	in.KubeControllerManager.DeepCopyInto(&out.KubeControllerManager)
+	in.KubeScheduler.DeepCopyInto(&out.KubeScheduler)
+	in.Konnectivity.DeepCopyInto(&out.Konnectivity)
+	in.ETCD.DeepCopyInto(&out.ETCD)
+	in.FrontProxyCA.DeepCopyInto(&out.FrontProxyCA)
+	in.FrontProxyClient.DeepCopyInto(&out.FrontProxyClient)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PkiSpec.
@@ -756,6 +2164,13 @@ func (in *PkiSpec) DeepCopy() *PkiSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PkiStatus) DeepCopyInto(out *PkiStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PkiStatus.
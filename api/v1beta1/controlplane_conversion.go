@@ -0,0 +1,584 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// v1alpha1 is the hub, fields it computes from the observed state (pki.controlplane-ips,
+// kube-apiserver.options.advertise-address) have no v1beta1 equivalent and are dropped on conversion.
+
+var _ conversion.Convertible = &ControlPlane{}
+
+// ConvertTo converts this ControlPlane to the hub version (v1alpha1)
+func (src *ControlPlane) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1alpha1.ControlPlane)
+	if !ok {
+		return fmt.Errorf("unsupported hub type %T", dstRaw)
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+
+	s := src.Spec
+	dst.Spec = v1alpha1.ControlPlaneSpec{
+		Version: s.Version,
+		Network: v1alpha1.ControlPlaneNetwork(s.Network),
+		Addons: v1alpha1.ControlPlaneAddons{
+			CoreDNS:           v1alpha1.ControlPlaneAddon(s.Addons.CoreDNS),
+			KonnectivityAgent: v1alpha1.ControlPlaneAddon(s.Addons.KonnectivityAgent),
+			KubeProxy:         v1alpha1.ControlPlaneAddon(s.Addons.KubeProxy),
+		},
+		Loadbalancer: v1alpha1.LoadbalancerSpec{
+			Name:      s.Loadbalancer.ServiceName,
+			Port:      s.Loadbalancer.Port,
+			Selectors: s.Loadbalancer.Selector,
+
+			IPFamilyPolicy: s.Loadbalancer.IPFamilyPolicy,
+			IPFamilies:     s.Loadbalancer.IPFamilies,
+		},
+		PKI: v1alpha1.PkiSpec{
+			Backend:         s.PKI.Backend,
+			Profile:         v1alpha1.CertificateProfile(s.PKI.Profile),
+			Name:            s.PKI.IssuerName,
+			CA:              v1alpha1.PKICA{Name: s.PKI.CA.SecretRef.Name, Profile: convertProfileTo(s.PKI.CA.Profile), CommonName: s.PKI.CA.CommonName},
+			ServiceAccounts: v1alpha1.PKIServiceAccounts{Name: s.PKI.ServiceAccounts.SecretRef.Name, Profile: convertProfileTo(s.PKI.ServiceAccounts.Profile)},
+			Admin:           v1alpha1.PKIAdmin{Name: s.PKI.Admin.SecretRef.Name, Profile: convertProfileTo(s.PKI.Admin.Profile)},
+			KubeAPIServer: v1alpha1.PKIKubeAPIServer{
+				Name:        s.PKI.KubeAPIServer.SecretRef.Name,
+				IPAddresses: s.PKI.KubeAPIServer.IPAddresses,
+				DNSNames:    s.PKI.KubeAPIServer.DNSNames,
+				Profile:     convertProfileTo(s.PKI.KubeAPIServer.Profile),
+			},
+			KubeControllerManager: v1alpha1.PKIKubeControllerManager{Name: s.PKI.KubeControllerManager.SecretRef.Name, Profile: convertProfileTo(s.PKI.KubeControllerManager.Profile)},
+			KubeScheduler:         v1alpha1.PKIKubeScheduler{Name: s.PKI.KubeScheduler.SecretRef.Name, Profile: convertProfileTo(s.PKI.KubeScheduler.Profile)},
+			Konnectivity:          v1alpha1.PKIKonnectivity{Name: s.PKI.Konnectivity.SecretRef.Name, Profile: convertProfileTo(s.PKI.Konnectivity.Profile)},
+			ETCD: v1alpha1.PKIEtcd{
+				CA:            s.PKI.Etcd.CASecretRef.Name,
+				Server:        s.PKI.Etcd.ServerSecretRef.Name,
+				Peer:          s.PKI.Etcd.PeerSecretRef.Name,
+				Client:        s.PKI.Etcd.ClientSecretRef.Name,
+				DNSNames:      s.PKI.Etcd.DNSNames,
+				CAProfile:     convertProfileTo(s.PKI.Etcd.CAProfile),
+				ServerProfile: convertProfileTo(s.PKI.Etcd.ServerProfile),
+				PeerProfile:   convertProfileTo(s.PKI.Etcd.PeerProfile),
+				ClientProfile: convertProfileTo(s.PKI.Etcd.ClientProfile),
+			},
+			FrontProxyCA:     v1alpha1.PKIFrontProxyCA{Name: s.PKI.FrontProxyCA.SecretRef.Name, Profile: convertProfileTo(s.PKI.FrontProxyCA.Profile)},
+			FrontProxyClient: v1alpha1.PKIFrontProxyClient{Name: s.PKI.FrontProxyClient.SecretRef.Name, Profile: convertProfileTo(s.PKI.FrontProxyClient.Profile)},
+		},
+		KubeApiServer: v1alpha1.KubeAPIServerSpec{
+			Version:     s.KubeAPIServer.Version,
+			ETCDservers: strings.Join(s.KubeAPIServer.EtcdServers, ","),
+			Deployment:  convertDeploymentTo(s.KubeAPIServer.Deployment),
+			TLS: v1alpha1.KubeAPIServerTLS{
+				CASecretName:              s.KubeAPIServer.TLS.CASecretRef.Name,
+				KubeApiServerSecretName:   s.KubeAPIServer.TLS.ServingSecretRef.Name,
+				ServiceAccountsSecretName: s.KubeAPIServer.TLS.ServiceAccountsSecretRef.Name,
+				KonnectivitySecretName:    s.KubeAPIServer.TLS.KonnectivitySecretRef.Name,
+			},
+			Options: v1alpha1.KubeAPIServerOptions{
+				ServiceClusterIpRange: s.KubeAPIServer.ServiceClusterIPRange,
+			},
+			Authentication: v1alpha1.KubeAPIServerAuthentication{OIDC: convertOIDCTo(s.KubeAPIServer.Authentication.OIDC)},
+			Audit:          convertAuditTo(s.KubeAPIServer.Audit),
+			Encryption:     convertEncryptionTo(s.KubeAPIServer.Encryption),
+			ExtraArgs:      s.KubeAPIServer.ExtraArgs,
+			FeatureGates:   s.KubeAPIServer.FeatureGates,
+			AdmissionPlugins: v1alpha1.KubeAPIServerAdmissionPlugins{
+				Enable:  s.KubeAPIServer.AdmissionPlugins.Enable,
+				Disable: s.KubeAPIServer.AdmissionPlugins.Disable,
+			},
+		},
+		KubeControllerManager: v1alpha1.KubeControllerManagerSpec{
+			Version: s.KubeControllerManager.Version,
+			TLS: v1alpha1.KubeControllerManagerTLS{
+				CA:                    s.KubeControllerManager.TLS.CASecretRef.Name,
+				KubeControllerManager: s.KubeControllerManager.TLS.ClientSecretRef.Name,
+				ServiceAccountsTLS:    s.KubeControllerManager.TLS.ServiceAccountsSecretRef.Name,
+			},
+			Deployment:           convertDeploymentTo(s.KubeControllerManager.Deployment),
+			KubeAPIServerService: convertEndpointTo(s.KubeControllerManager.KubeAPIServerEndpoint),
+			// The networks of kube-controller-manager are the ones of the ControlPlane
+			Options: v1alpha1.KubeControllerManagerOptions{
+				ClusterCIDR:           strings.Join(s.Network.PodCIDRs, ","),
+				ServiceClusterIpRange: strings.Join(s.Network.ServiceCIDRs, ","),
+				NodeCIDRMaskSize:      s.Network.NodeCIDRMaskSize,
+				NodeCIDRMaskSizeIPv6:  s.Network.NodeCIDRMaskSizeIPv6,
+			},
+			ExtraArgs:    s.KubeControllerManager.ExtraArgs,
+			FeatureGates: s.KubeControllerManager.FeatureGates,
+		},
+		KubeScheduler: v1alpha1.KubeSchedulerSpec{
+			Version:              s.KubeScheduler.Version,
+			KubeAPIServerService: convertEndpointTo(s.KubeScheduler.KubeAPIServerEndpoint),
+			KubeSchedulerTls:     s.KubeScheduler.TLS.ClientSecretRef.Name,
+			Deployment:           convertDeploymentTo(s.KubeScheduler.Deployment),
+			ExtraArgs:            s.KubeScheduler.ExtraArgs,
+			FeatureGates:         s.KubeScheduler.FeatureGates,
+		},
+	}
+	if etcdClient := s.KubeAPIServer.TLS.EtcdClientSecretRef; etcdClient != nil {
+		dst.Spec.KubeApiServer.TLS.ETCDClientSecretName = etcdClient.Name
+	}
+	if frontProxyClient := s.KubeAPIServer.TLS.FrontProxyClientSecretRef; frontProxyClient != nil {
+		dst.Spec.KubeApiServer.TLS.FrontProxyClientSecretName = frontProxyClient.Name
+	}
+	if imported := s.PKI.CA.ImportSecretRef; imported != nil {
+		dst.Spec.PKI.CA.ImportSecretName = imported.Name
+	}
+	if issuer := s.PKI.CA.IssuerRef; issuer != nil {
+		ref := v1alpha1.PKIIssuerRef(*issuer)
+		dst.Spec.PKI.CA.IssuerRef = &ref
+	}
+	if s.Etcd != nil {
+		dst.Spec.Etcd = &v1alpha1.EtcdSpec{
+			Version:  s.Etcd.Version,
+			Replicas: s.Etcd.Replicas,
+			Storage: v1alpha1.EtcdStorage{
+				Size:             s.Etcd.Storage.Size,
+				StorageClassName: s.Etcd.Storage.StorageClassName,
+			},
+			Snapshot: convertSnapshotTo(s.Etcd.Snapshot),
+		}
+		if server := s.Etcd.TLS.ServerSecretRef; server != nil {
+			dst.Spec.Etcd.TLS.ServerSecretName = server.Name
+		}
+		if peer := s.Etcd.TLS.PeerSecretRef; peer != nil {
+			dst.Spec.Etcd.TLS.PeerSecretName = peer.Name
+		}
+	}
+
+	st := src.Status
+	dst.Status = v1alpha1.ControlPlaneStatus{
+		ObservedGeneration:  st.ObservedGeneration,
+		KubeconfigSecretRef: st.KubeconfigSecretRef,
+		Version:             st.Version,
+		Conditions:          st.Conditions,
+	}
+	if st.Endpoint != nil {
+		dst.Status.Endpoint = formatEndpoint(*st.Endpoint)
+	}
+	if st.Upgrade != nil {
+		dst.Status.Upgrade = &v1alpha1.ControlPlaneUpgrade{
+			From:      st.Upgrade.From,
+			To:        st.Upgrade.To,
+			Component: st.Upgrade.Component,
+			StartTime: st.Upgrade.StartTime,
+		}
+	}
+	for _, h := range st.UpgradeHistory {
+		dst.Status.UpgradeHistory = append(dst.Status.UpgradeHistory, v1alpha1.ControlPlaneUpgradeHistory(h))
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1alpha1) to this version
+func (dst *ControlPlane) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1alpha1.ControlPlane)
+	if !ok {
+		return fmt.Errorf("unsupported hub type %T", srcRaw)
+	}
+
+	dst.ObjectMeta = src.ObjectMeta
+
+	s := src.Spec
+	dst.Spec = ControlPlaneSpec{
+		Version: s.Version,
+		Network: NetworkSpec(s.Network),
+		Addons: AddonsSpec{
+			CoreDNS:           AddonSpec(s.Addons.CoreDNS),
+			KonnectivityAgent: AddonSpec(s.Addons.KonnectivityAgent),
+			KubeProxy:         AddonSpec(s.Addons.KubeProxy),
+		},
+		Loadbalancer: LoadbalancerSpec{
+			ServiceName: s.Loadbalancer.Name,
+			Port:        s.Loadbalancer.Port,
+			Selector:    s.Loadbalancer.Selectors,
+
+			IPFamilyPolicy: s.Loadbalancer.IPFamilyPolicy,
+			IPFamilies:     s.Loadbalancer.IPFamilies,
+		},
+		PKI: PKISpec{
+			Backend:         s.PKI.Backend,
+			Profile:         CertificateProfile(s.PKI.Profile),
+			IssuerName:      s.PKI.Name,
+			CA:              CASpec{SecretRef: ref(s.PKI.CA.Name), Profile: convertProfileFrom(s.PKI.CA.Profile), CommonName: s.PKI.CA.CommonName},
+			ServiceAccounts: CertificateSpec{SecretRef: ref(s.PKI.ServiceAccounts.Name), Profile: convertProfileFrom(s.PKI.ServiceAccounts.Profile)},
+			Admin:           CertificateSpec{SecretRef: ref(s.PKI.Admin.Name), Profile: convertProfileFrom(s.PKI.Admin.Profile)},
+			KubeAPIServer: ServingCertificateSpec{
+				SecretRef:   ref(s.PKI.KubeAPIServer.Name),
+				IPAddresses: s.PKI.KubeAPIServer.IPAddresses,
+				DNSNames:    s.PKI.KubeAPIServer.DNSNames,
+				Profile:     convertProfileFrom(s.PKI.KubeAPIServer.Profile),
+			},
+			KubeControllerManager: CertificateSpec{SecretRef: ref(s.PKI.KubeControllerManager.Name), Profile: convertProfileFrom(s.PKI.KubeControllerManager.Profile)},
+			KubeScheduler:         CertificateSpec{SecretRef: ref(s.PKI.KubeScheduler.Name), Profile: convertProfileFrom(s.PKI.KubeScheduler.Profile)},
+			Konnectivity:          CertificateSpec{SecretRef: ref(s.PKI.Konnectivity.Name), Profile: convertProfileFrom(s.PKI.Konnectivity.Profile)},
+			Etcd: EtcdCertificatesSpec{
+				CASecretRef:     ref(s.PKI.ETCD.CA),
+				ServerSecretRef: ref(s.PKI.ETCD.Server),
+				PeerSecretRef:   ref(s.PKI.ETCD.Peer),
+				ClientSecretRef: ref(s.PKI.ETCD.Client),
+				DNSNames:        s.PKI.ETCD.DNSNames,
+				CAProfile:       convertProfileFrom(s.PKI.ETCD.CAProfile),
+				ServerProfile:   convertProfileFrom(s.PKI.ETCD.ServerProfile),
+				PeerProfile:     convertProfileFrom(s.PKI.ETCD.PeerProfile),
+				ClientProfile:   convertProfileFrom(s.PKI.ETCD.ClientProfile),
+			},
+			FrontProxyCA:     CertificateSpec{SecretRef: ref(s.PKI.FrontProxyCA.Name), Profile: convertProfileFrom(s.PKI.FrontProxyCA.Profile)},
+			FrontProxyClient: CertificateSpec{SecretRef: ref(s.PKI.FrontProxyClient.Name), Profile: convertProfileFrom(s.PKI.FrontProxyClient.Profile)},
+		},
+		KubeAPIServer: KubeAPIServerSpec{
+			Version:               s.KubeApiServer.Version,
+			ServiceClusterIPRange: s.KubeApiServer.Options.ServiceClusterIpRange,
+			Deployment:            convertDeploymentFrom(s.KubeApiServer.Deployment),
+			TLS: KubeAPIServerTLS{
+				CASecretRef:              ref(s.KubeApiServer.TLS.CASecretName),
+				ServingSecretRef:         ref(s.KubeApiServer.TLS.KubeApiServerSecretName),
+				ServiceAccountsSecretRef: ref(s.KubeApiServer.TLS.ServiceAccountsSecretName),
+				KonnectivitySecretRef:    ref(s.KubeApiServer.TLS.KonnectivitySecretName),
+			},
+			Authentication: AuthenticationSpec{OIDC: convertOIDCFrom(s.KubeApiServer.Authentication.OIDC)},
+			Audit:          convertAuditFrom(s.KubeApiServer.Audit),
+			Encryption:     convertEncryptionFrom(s.KubeApiServer.Encryption),
+			ExtraArgs:      s.KubeApiServer.ExtraArgs,
+			FeatureGates:   s.KubeApiServer.FeatureGates,
+			AdmissionPlugins: AdmissionPluginsSpec{
+				Enable:  s.KubeApiServer.AdmissionPlugins.Enable,
+				Disable: s.KubeApiServer.AdmissionPlugins.Disable,
+			},
+		},
+		KubeControllerManager: KubeControllerManagerSpec{
+			Version:               s.KubeControllerManager.Version,
+			KubeAPIServerEndpoint: convertEndpointFrom(s.KubeControllerManager.KubeAPIServerService),
+			Deployment:            convertDeploymentFrom(s.KubeControllerManager.Deployment),
+			TLS: KubeControllerManagerTLS{
+				CASecretRef:              ref(s.KubeControllerManager.TLS.CA),
+				ClientSecretRef:          ref(s.KubeControllerManager.TLS.KubeControllerManager),
+				ServiceAccountsSecretRef: ref(s.KubeControllerManager.TLS.ServiceAccountsTLS),
+			},
+			ExtraArgs:    s.KubeControllerManager.ExtraArgs,
+			FeatureGates: s.KubeControllerManager.FeatureGates,
+		},
+		KubeScheduler: KubeSchedulerSpec{
+			Version:               s.KubeScheduler.Version,
+			KubeAPIServerEndpoint: convertEndpointFrom(s.KubeScheduler.KubeAPIServerService),
+			Deployment:            convertDeploymentFrom(s.KubeScheduler.Deployment),
+			TLS:                   KubeSchedulerTLS{ClientSecretRef: ref(s.KubeScheduler.KubeSchedulerTls)},
+			ExtraArgs:             s.KubeScheduler.ExtraArgs,
+			FeatureGates:          s.KubeScheduler.FeatureGates,
+		},
+	}
+	if s.KubeApiServer.ETCDservers != "" {
+		dst.Spec.KubeAPIServer.EtcdServers = strings.Split(s.KubeApiServer.ETCDservers, ",")
+	}
+	if s.KubeApiServer.TLS.ETCDClientSecretName != "" {
+		dst.Spec.KubeAPIServer.TLS.EtcdClientSecretRef = &corev1.LocalObjectReference{Name: s.KubeApiServer.TLS.ETCDClientSecretName}
+	}
+	if s.KubeApiServer.TLS.FrontProxyClientSecretName != "" {
+		dst.Spec.KubeAPIServer.TLS.FrontProxyClientSecretRef = &corev1.LocalObjectReference{Name: s.KubeApiServer.TLS.FrontProxyClientSecretName}
+	}
+	if s.PKI.CA.ImportSecretName != "" {
+		dst.Spec.PKI.CA.ImportSecretRef = &corev1.LocalObjectReference{Name: s.PKI.CA.ImportSecretName}
+	}
+	if issuer := s.PKI.CA.IssuerRef; issuer != nil {
+		ref := IssuerReference(*issuer)
+		dst.Spec.PKI.CA.IssuerRef = &ref
+	}
+	if s.Etcd != nil {
+		dst.Spec.Etcd = &EtcdSpec{
+			Version:  s.Etcd.Version,
+			Replicas: s.Etcd.Replicas,
+			Storage: EtcdStorage{
+				Size:             s.Etcd.Storage.Size,
+				StorageClassName: s.Etcd.Storage.StorageClassName,
+			},
+			Snapshot: convertSnapshotFrom(s.Etcd.Snapshot),
+		}
+		if s.Etcd.TLS.ServerSecretName != "" {
+			dst.Spec.Etcd.TLS.ServerSecretRef = &corev1.LocalObjectReference{Name: s.Etcd.TLS.ServerSecretName}
+		}
+		if s.Etcd.TLS.PeerSecretName != "" {
+			dst.Spec.Etcd.TLS.PeerSecretRef = &corev1.LocalObjectReference{Name: s.Etcd.TLS.PeerSecretName}
+		}
+	}
+
+	st := src.Status
+	dst.Status = ControlPlaneStatus{
+		ObservedGeneration:  st.ObservedGeneration,
+		KubeconfigSecretRef: st.KubeconfigSecretRef,
+		Version:             st.Version,
+		Conditions:          st.Conditions,
+	}
+	if st.Endpoint != "" {
+		endpoint, err := parseEndpoint(st.Endpoint)
+		if err != nil {
+			return err
+		}
+		dst.Status.Endpoint = endpoint
+	}
+	if st.Upgrade != nil {
+		dst.Status.Upgrade = &ControlPlaneUpgrade{
+			From:      st.Upgrade.From,
+			To:        st.Upgrade.To,
+			Component: st.Upgrade.Component,
+			StartTime: st.Upgrade.StartTime,
+		}
+	}
+	for _, h := range st.UpgradeHistory {
+		dst.Status.UpgradeHistory = append(dst.Status.UpgradeHistory, ControlPlaneUpgradeHistory(h))
+	}
+
+	return nil
+}
+
+func ref(name string) corev1.LocalObjectReference {
+	return corev1.LocalObjectReference{Name: name}
+}
+
+func convertProfileTo(p *CertificateProfile) *v1alpha1.CertificateProfile {
+	if p == nil {
+		return nil
+	}
+	out := v1alpha1.CertificateProfile(*p)
+	return &out
+}
+
+func convertProfileFrom(p *v1alpha1.CertificateProfile) *CertificateProfile {
+	if p == nil {
+		return nil
+	}
+	out := CertificateProfile(*p)
+	return &out
+}
+
+func convertOIDCTo(issuers []OIDCIssuer) []v1alpha1.OIDCIssuer {
+	if issuers == nil {
+		return nil
+	}
+	out := make([]v1alpha1.OIDCIssuer, 0, len(issuers))
+	for _, o := range issuers {
+		out = append(out, v1alpha1.OIDCIssuer(o))
+	}
+	return out
+}
+
+func convertOIDCFrom(issuers []v1alpha1.OIDCIssuer) []OIDCIssuer {
+	if issuers == nil {
+		return nil
+	}
+	out := make([]OIDCIssuer, 0, len(issuers))
+	for _, o := range issuers {
+		out = append(out, OIDCIssuer(o))
+	}
+	return out
+}
+
+func convertAuditTo(a *AuditSpec) *v1alpha1.KubeAPIServerAudit {
+	if a == nil {
+		return nil
+	}
+
+	dst := &v1alpha1.KubeAPIServerAudit{Policy: a.Policy}
+	if a.PolicyConfigMapRef != nil {
+		dst.PolicyConfigMapName = a.PolicyConfigMapRef.Name
+	}
+	if a.Log != nil {
+		dst.Log = &v1alpha1.AuditLogBackend{
+			ClaimName:  a.Log.ClaimRef.Name,
+			Format:     a.Log.Format,
+			MaxAge:     a.Log.MaxAge,
+			MaxBackups: a.Log.MaxBackups,
+			MaxSize:    a.Log.MaxSize,
+		}
+	}
+	if w := a.Webhook; w != nil {
+		dst.Webhook = &v1alpha1.AuditWebhookBackend{
+			Server:         w.Server,
+			CABundle:       w.CABundle,
+			Mode:           w.Mode,
+			BatchMaxSize:   w.BatchMaxSize,
+			BatchMaxWait:   w.BatchMaxWait,
+			InitialBackoff: w.InitialBackoff,
+		}
+		if w.ClientSecretRef != nil {
+			dst.Webhook.ClientSecretName = w.ClientSecretRef.Name
+		}
+	}
+	return dst
+}
+
+func convertAuditFrom(a *v1alpha1.KubeAPIServerAudit) *AuditSpec {
+	if a == nil {
+		return nil
+	}
+
+	dst := &AuditSpec{Policy: a.Policy}
+	if a.PolicyConfigMapName != "" {
+		dst.PolicyConfigMapRef = &corev1.LocalObjectReference{Name: a.PolicyConfigMapName}
+	}
+	if a.Log != nil {
+		dst.Log = &AuditLogBackend{
+			ClaimRef:   ref(a.Log.ClaimName),
+			Format:     a.Log.Format,
+			MaxAge:     a.Log.MaxAge,
+			MaxBackups: a.Log.MaxBackups,
+			MaxSize:    a.Log.MaxSize,
+		}
+	}
+	if w := a.Webhook; w != nil {
+		dst.Webhook = &AuditWebhookBackend{
+			Server:         w.Server,
+			CABundle:       w.CABundle,
+			Mode:           w.Mode,
+			BatchMaxSize:   w.BatchMaxSize,
+			BatchMaxWait:   w.BatchMaxWait,
+			InitialBackoff: w.InitialBackoff,
+		}
+		if w.ClientSecretName != "" {
+			dst.Webhook.ClientSecretRef = &corev1.LocalObjectReference{Name: w.ClientSecretName}
+		}
+	}
+	return dst
+}
+
+func convertEncryptionTo(e *EncryptionSpec) *v1alpha1.KubeAPIServerEncryption {
+	if e == nil {
+		return nil
+	}
+
+	dst := &v1alpha1.KubeAPIServerEncryption{Provider: e.Provider, RotateKeys: e.RotateKeys}
+	if e.KMS != nil {
+		kms := v1alpha1.KMSPlugin(*e.KMS)
+		dst.KMS = &kms
+	}
+	if e.AdminKubeconfigSecretRef != nil {
+		dst.AdminKubeconfigSecretName = e.AdminKubeconfigSecretRef.Name
+	}
+	return dst
+}
+
+func convertEncryptionFrom(e *v1alpha1.KubeAPIServerEncryption) *EncryptionSpec {
+	if e == nil {
+		return nil
+	}
+
+	dst := &EncryptionSpec{Provider: e.Provider, RotateKeys: e.RotateKeys}
+	if e.KMS != nil {
+		kms := KMSPlugin(*e.KMS)
+		dst.KMS = &kms
+	}
+	if e.AdminKubeconfigSecretName != "" {
+		dst.AdminKubeconfigSecretRef = &corev1.LocalObjectReference{Name: e.AdminKubeconfigSecretName}
+	}
+	return dst
+}
+
+func convertDeploymentTo(d DeploymentSpec) v1alpha1.Deployment {
+	return v1alpha1.Deployment{Name: d.Name, Replicas: d.Replicas, Labels: d.Labels}
+}
+
+func convertDeploymentFrom(d v1alpha1.Deployment) DeploymentSpec {
+	return DeploymentSpec{Name: d.Name, Replicas: d.Replicas, Labels: d.Labels}
+}
+
+func convertEndpointTo(e APIEndpoint) v1alpha1.Service {
+	return v1alpha1.Service{Name: e.Host, Port: uint(e.Port)}
+}
+
+func convertEndpointFrom(s v1alpha1.Service) APIEndpoint {
+	return APIEndpoint{Host: s.Name, Port: int32(s.Port)}
+}
+
+// formatEndpoint formats the https://<host>:<port> v1alpha1 endpoint
+func formatEndpoint(e APIEndpoint) string {
+	if e.Port == 0 {
+		return fmt.Sprintf("https://%s", e.Host)
+	}
+	return fmt.Sprintf("https://%s", net.JoinHostPort(e.Host, strconv.Itoa(int(e.Port))))
+}
+
+// parseEndpoint parses the https://<host>:<port> v1alpha1 endpoint
+func parseEndpoint(endpoint string) (*APIEndpoint, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endpoint %q: %w", endpoint, err)
+	}
+
+	e := &APIEndpoint{Host: u.Hostname()}
+	if p := u.Port(); p != "" {
+		port, err := strconv.ParseInt(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse endpoint %q port: %w", endpoint, err)
+		}
+		e.Port = int32(port)
+	}
+	return e, nil
+}
+
+func convertSnapshotTo(s *EtcdSnapshotSource) *v1alpha1.EtcdSnapshotSource {
+	if s == nil {
+		return nil
+	}
+
+	dst := &v1alpha1.EtcdSnapshotSource{Snapshot: s.Snapshot}
+	if s.PVC != nil {
+		dst.PVC = &v1alpha1.EtcdSnapshotPVC{ClaimName: s.PVC.ClaimRef.Name}
+	}
+	if s.S3 != nil {
+		dst.S3 = &v1alpha1.EtcdSnapshotS3{
+			Endpoint:              s.S3.Endpoint,
+			Bucket:                s.S3.Bucket,
+			Prefix:                s.S3.Prefix,
+			CredentialsSecretName: s.S3.CredentialsSecretRef.Name,
+			Image:                 s.S3.Image,
+		}
+	}
+	return dst
+}
+
+func convertSnapshotFrom(s *v1alpha1.EtcdSnapshotSource) *EtcdSnapshotSource {
+	if s == nil {
+		return nil
+	}
+
+	dst := &EtcdSnapshotSource{Snapshot: s.Snapshot}
+	if s.PVC != nil {
+		dst.PVC = &EtcdSnapshotPVC{ClaimRef: ref(s.PVC.ClaimName)}
+	}
+	if s.S3 != nil {
+		dst.S3 = &EtcdSnapshotS3{
+			Endpoint:             s.S3.Endpoint,
+			Bucket:               s.S3.Bucket,
+			Prefix:               s.S3.Prefix,
+			CredentialsSecretRef: ref(s.S3.CredentialsSecretName),
+			Image:                s.S3.Image,
+		}
+	}
+	return dst
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+func hubControlPlane() *v1alpha1.ControlPlane {
+	dualStack := corev1.IPFamilyPolicyRequireDualStack
+	return &v1alpha1.ControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Spec: v1alpha1.ControlPlaneSpec{
+			Version:      "v1.27.5",
+			Network:      v1alpha1.ControlPlaneNetwork{PodCIDRs: []string{"10.200.0.0/16", "fd00:200::/56"}, ServiceCIDRs: []string{"10.32.0.0/24", "fd00:32::/112"}, NodeCIDRMaskSize: 24, NodeCIDRMaskSizeIPv6: 64, DNSDomain: "demo.local"},
+			Loadbalancer: v1alpha1.LoadbalancerSpec{Name: "demo-kube-apiserver", Port: 6443, Selectors: map[string]string{"app.kubernetes.io/instance": "demo"}, IPFamilyPolicy: &dualStack, IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}},
+			PKI: v1alpha1.PkiSpec{
+				Backend:               v1alpha1.PkiBackendNative,
+				Profile:               v1alpha1.CertificateProfile{Duration: &metav1.Duration{Duration: 24 * time.Hour}, Usages: []string{"client auth"}},
+				Name:                  "demo-pki",
+				CA:                    v1alpha1.PKICA{Name: "demo-ca", Profile: &v1alpha1.CertificateProfile{Algorithm: v1alpha1.KeyAlgorithmECDSA, Size: 384}, IssuerRef: &v1alpha1.PKIIssuerRef{Name: "vault", Kind: "ClusterIssuer"}, CommonName: "demo.example.com"},
+				Admin:                 v1alpha1.PKIAdmin{Name: "demo-admin"},
+				ServiceAccounts:       v1alpha1.PKIServiceAccounts{Name: "demo-service-accounts"},
+				Konnectivity:          v1alpha1.PKIKonnectivity{Name: "demo-konnectivity"},
+				KubeAPIServer:         v1alpha1.PKIKubeAPIServer{Name: "demo-kube-apiserver", IPAddresses: []string{"10.32.0.1", "fd00:32::1"}, DNSNames: []string{"kubernetes"}},
+				KubeControllerManager: v1alpha1.PKIKubeControllerManager{Name: "demo-kube-controller-manager"},
+				KubeScheduler:         v1alpha1.PKIKubeScheduler{Name: "demo-kube-scheduler"},
+				ETCD:                  v1alpha1.PKIEtcd{CA: "demo-etcd-ca", Server: "demo-etcd-server", Peer: "demo-etcd-peer", Client: "demo-etcd-client", ServerProfile: &v1alpha1.CertificateProfile{Usages: []string{"server auth"}}},
+				FrontProxyCA:          v1alpha1.PKIFrontProxyCA{Name: "demo-front-proxy-ca"},
+				FrontProxyClient:      v1alpha1.PKIFrontProxyClient{Name: "demo-front-proxy-client"},
+			},
+			KubeApiServer: v1alpha1.KubeAPIServerSpec{
+				ETCDservers: "https://etcd-0:2379,https://etcd-1:2379",
+				Deployment:  v1alpha1.Deployment{Name: "demo-kube-apiserver", Replicas: 3, Labels: map[string]string{"foo": "bar"}},
+				TLS: v1alpha1.KubeAPIServerTLS{
+					CASecretName:               "demo-ca",
+					KubeApiServerSecretName:    "demo-kube-apiserver",
+					ServiceAccountsSecretName:  "demo-service-accounts",
+					KonnectivitySecretName:     "demo-konnectivity",
+					ETCDClientSecretName:       "demo-etcd-client",
+					FrontProxyClientSecretName: "demo-front-proxy-client",
+				},
+				Options: v1alpha1.KubeAPIServerOptions{ServiceClusterIpRange: "10.32.0.0/24,fd00:32::/112"},
+				Authentication: v1alpha1.KubeAPIServerAuthentication{OIDC: []v1alpha1.OIDCIssuer{
+					{IssuerURL: "https://sso.example.com", ClientID: "kubernetes", GroupsClaim: "groups", GroupsPrefix: "sso:", RequiredClaims: map[string]string{"hd": "example.com"}},
+				}},
+				Audit: &v1alpha1.KubeAPIServerAudit{
+					PolicyConfigMapName: "demo-audit-policy",
+					Log:                 &v1alpha1.AuditLogBackend{ClaimName: "demo-audit", Format: "json", MaxAge: 7, MaxSize: 100},
+					Webhook:             &v1alpha1.AuditWebhookBackend{Server: "https://siem.example.com/audit", ClientSecretName: "demo-siem", Mode: "batch", BatchMaxWait: &metav1.Duration{Duration: 5 * time.Second}},
+				},
+				Encryption: &v1alpha1.KubeAPIServerEncryption{
+					Provider:                  "kms",
+					KMS:                       &v1alpha1.KMSPlugin{Name: "vault", Image: "example.com/vault-kms-plugin:v1", Args: []string{"--listen=$(KMS_SOCKET)"}, Timeout: &metav1.Duration{Duration: 5 * time.Second}},
+					RotateKeys:                "2023-10",
+					AdminKubeconfigSecretName: "demo-admin-kubeconfig",
+				},
+				ExtraArgs:        map[string]string{"authorization-mode": "RBAC,Node,Webhook", "profiling": "false"},
+				FeatureGates:     map[string]bool{"InPlacePodVerticalScaling": true},
+				AdmissionPlugins: v1alpha1.KubeAPIServerAdmissionPlugins{Enable: []string{"PodNodeSelector"}, Disable: []string{"DefaultStorageClass"}},
+			},
+			KubeControllerManager: v1alpha1.KubeControllerManagerSpec{
+				Deployment:           v1alpha1.Deployment{Name: "demo-kube-controller-manager", Replicas: 3},
+				TLS:                  v1alpha1.KubeControllerManagerTLS{CA: "demo-ca", KubeControllerManager: "demo-kube-controller-manager", ServiceAccountsTLS: "demo-service-accounts"},
+				KubeAPIServerService: v1alpha1.Service{Name: "demo-kube-apiserver", Port: 6443},
+				Options:              v1alpha1.KubeControllerManagerOptions{ClusterCIDR: "10.200.0.0/16,fd00:200::/56", ServiceClusterIpRange: "10.32.0.0/24,fd00:32::/112", NodeCIDRMaskSize: 24, NodeCIDRMaskSizeIPv6: 64},
+				ExtraArgs:            map[string]string{"controllers": "*,-ttl"},
+				FeatureGates:         map[string]bool{"CronJobTimeZone": true},
+			},
+			KubeScheduler: v1alpha1.KubeSchedulerSpec{
+				Deployment:           v1alpha1.Deployment{Name: "demo-kube-scheduler", Replicas: 3},
+				KubeSchedulerTls:     "demo-kube-scheduler",
+				KubeAPIServerService: v1alpha1.Service{Name: "demo-kube-apiserver", Port: 6443},
+				ExtraArgs:            map[string]string{"kube-api-qps": "100"},
+			},
+			Etcd: &v1alpha1.EtcdSpec{
+				Version:  "3.5.9-0",
+				Replicas: 3,
+				Storage:  v1alpha1.EtcdStorage{Size: resource.MustParse("1Gi")},
+				TLS:      v1alpha1.EtcdTLS{ServerSecretName: "demo-etcd-server", PeerSecretName: "demo-etcd-peer"},
+				Snapshot: &v1alpha1.EtcdSnapshotSource{
+					EtcdSnapshotStorage: v1alpha1.EtcdSnapshotStorage{
+						S3: &v1alpha1.EtcdSnapshotS3{Endpoint: "http://minio:9000", Bucket: "etcd", CredentialsSecretName: "minio"},
+					},
+					Snapshot: "snapshot.db",
+				},
+			},
+			Addons: v1alpha1.ControlPlaneAddons{
+				CoreDNS:           v1alpha1.ControlPlaneAddon{Enabled: true, Version: "v1.10.1"},
+				KonnectivityAgent: v1alpha1.ControlPlaneAddon{Enabled: true},
+				KubeProxy:         v1alpha1.ControlPlaneAddon{Version: "v1.27.5"},
+			},
+		},
+		Status: v1alpha1.ControlPlaneStatus{
+			ObservedGeneration:  2,
+			Endpoint:            "https://10.0.0.10:6443",
+			KubeconfigSecretRef: &corev1.LocalObjectReference{Name: "demo-admin-kubeconfig"},
+			Version:             "v1.27.5",
+			Conditions:          []metav1.Condition{{Type: v1alpha1.ConditionReady, Status: metav1.ConditionTrue, Reason: "Ready"}},
+		},
+	}
+}
+
+var _ = Describe("ControlPlane conversion", func() {
+
+	It("Converts v1alpha1 to v1beta1 and back", func() {
+		hub := hubControlPlane()
+
+		cp := &ControlPlane{}
+		Expect(cp.ConvertFrom(hub)).Should(Succeed())
+		Expect(cp.Spec.PKI.CA.SecretRef.Name).Should(Equal("demo-ca"))
+		Expect(cp.Spec.PKI.CA.Profile).Should(Equal(&CertificateProfile{Algorithm: v1alpha1.KeyAlgorithmECDSA, Size: 384}))
+		Expect(cp.Spec.PKI.CA.IssuerRef).Should(Equal(&IssuerReference{Name: "vault", Kind: "ClusterIssuer"}))
+		Expect(cp.Spec.KubeAPIServer.EtcdServers).Should(Equal([]string{"https://etcd-0:2379", "https://etcd-1:2379"}))
+		Expect(cp.Spec.KubeAPIServer.TLS.EtcdClientSecretRef).Should(Equal(&corev1.LocalObjectReference{Name: "demo-etcd-client"}))
+		Expect(cp.Spec.KubeScheduler.KubeAPIServerEndpoint).Should(Equal(APIEndpoint{Host: "demo-kube-apiserver", Port: 6443}))
+		Expect(cp.Spec.Etcd.Snapshot.S3.CredentialsSecretRef.Name).Should(Equal("minio"))
+		Expect(cp.Spec.Etcd.TLS.ServerSecretRef).Should(Equal(&corev1.LocalObjectReference{Name: "demo-etcd-server"}))
+		Expect(cp.Status.Endpoint).Should(Equal(&APIEndpoint{Host: "10.0.0.10", Port: 6443}))
+
+		restored := &v1alpha1.ControlPlane{}
+		Expect(cp.ConvertTo(restored)).Should(Succeed())
+		Expect(restored).Should(Equal(hub))
+	})
+
+	It("Drops the fields computed by the operator", func() {
+		hub := hubControlPlane()
+		hub.Spec.PKI.ControlPlaneIP = "10.0.0.10"
+		hub.Spec.PKI.ControlPlaneIPs = []string{"10.0.0.10", "fd00::10"}
+		hub.Spec.KubeApiServer.Options.AdvertiseAddress = "10.0.0.10"
+
+		cp := &ControlPlane{}
+		Expect(cp.ConvertFrom(hub)).Should(Succeed())
+
+		restored := &v1alpha1.ControlPlane{}
+		Expect(cp.ConvertTo(restored)).Should(Succeed())
+		Expect(restored.Spec.PKI.ControlPlaneIP).Should(BeEmpty())
+		Expect(restored.Spec.PKI.ControlPlaneIPs).Should(BeEmpty())
+		Expect(restored.Spec.KubeApiServer.Options.AdvertiseAddress).Should(BeEmpty())
+	})
+
+	It("Converts an IPv6 endpoint", func() {
+		hub := hubControlPlane()
+		hub.Status.Endpoint = "https://[fd00::10]:6443"
+
+		cp := &ControlPlane{}
+		Expect(cp.ConvertFrom(hub)).Should(Succeed())
+		Expect(cp.Status.Endpoint).Should(Equal(&APIEndpoint{Host: "fd00::10", Port: 6443}))
+
+		restored := &v1alpha1.ControlPlane{}
+		Expect(cp.ConvertTo(restored)).Should(Succeed())
+		Expect(restored.Status.Endpoint).Should(Equal("https://[fd00::10]:6443"))
+	})
+})
@@ -0,0 +1,588 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// APIEndpoint is a host and a port kube-apiserver is reachable on
+type APIEndpoint struct {
+	// IP or DNS name
+	Host string `json:"host"`
+	// Port, 6443 when empty
+	Port int32 `json:"port,omitempty"`
+}
+
+// LoadbalancerSpec is the Service exposing kube-apiserver
+type LoadbalancerSpec struct {
+	// Name of the LoadBalancer Service created for kube-apiserver
+	ServiceName string `json:"service-name,omitempty"`
+	// Port kube-apiserver is exposed on
+	Port int32 `json:"port,omitempty"`
+	// Labels of the kube-apiserver pods selected by the Service
+	Selector map[string]string `json:"selector,omitempty"`
+	// IP family policy of the Service, the default of the management cluster when empty
+	IPFamilyPolicy *corev1.IPFamilyPolicy `json:"ip-family-policy,omitempty"`
+	// IP families of the Service, the first one is the family of its primary IP
+	IPFamilies []corev1.IPFamily `json:"ip-families,omitempty"`
+}
+
+// CertificateProfile configures the private key, validity and usages of certificates.
+// Fields left empty in a certificate profile are taken from the PKI profile.
+type CertificateProfile struct {
+	// Private key algorithm, RSA when empty
+	//+kubebuilder:validation:Enum=RSA;ECDSA;Ed25519
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// Private key size: 2048, 3072 or 4096 for RSA (2048 when empty), 256, 384 or 521 for ECDSA (256 when empty), unused for Ed25519
+	Size int `json:"size,omitempty"`
+
+	// Validity of the certificate, 10 years for CAs and 1 year for other certificates when empty (cert-manager: 90 days)
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// Time before expiry the certificate is renewed, a third of its validity when empty
+	RenewBefore *metav1.Duration `json:"renew-before,omitempty"`
+
+	// Key usages, named as in cert-manager ("digital signature", "server auth", "client auth", ...)
+	Usages []string `json:"usages,omitempty"`
+}
+
+// CertificateSpec is a certificate issued by the PKI
+type CertificateSpec struct {
+	// Secret the certificate, its key and the CA are written to
+	SecretRef corev1.LocalObjectReference `json:"secret-ref,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
+}
+
+// IssuerReference references a cert-manager issuer
+type IssuerReference struct {
+	Name string `json:"name"`
+	// Issuer or ClusterIssuer, Issuer when empty
+	Kind string `json:"kind,omitempty"`
+	// API group of the issuer, cert-manager.io when empty
+	Group string `json:"group,omitempty"`
+}
+
+// CASpec is the cluster CA, self-signed unless it is imported from a secret or signed by an external issuer
+type CASpec struct {
+	// Secret the CA certificate and key are written to
+	SecretRef corev1.LocalObjectReference `json:"secret-ref,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
+
+	// Secret holding the tls.crt and tls.key of an existing CA to sign the certificates with
+	ImportSecretRef *corev1.LocalObjectReference `json:"import-secret-ref,omitempty"`
+	// cert-manager issuer signing the CA, such as a Vault issuer chaining the CA to an intermediate. Requires the cert-manager backend.
+	IssuerRef *IssuerReference `json:"issuer-ref,omitempty"`
+	// Common name of the CA requested from the issuer, ca when empty
+	CommonName string `json:"common-name,omitempty"`
+}
+
+// ServingCertificateSpec is a certificate issued by the PKI for a server
+type ServingCertificateSpec struct {
+	// Secret the certificate, its key and the CA are written to
+	SecretRef corev1.LocalObjectReference `json:"secret-ref,omitempty"`
+	// IP addresses of the certificate, the Loadbalancer IP is added by the operator
+	IPAddresses []string `json:"ip-addresses,omitempty"`
+	// DNS names of the certificate
+	DNSNames []string `json:"dns-names,omitempty"`
+	// Overrides the PKI profile for this certificate
+	Profile *CertificateProfile `json:"profile,omitempty"`
+}
+
+// EtcdCertificatesSpec are the certificates of the managed etcd
+type EtcdCertificatesSpec struct {
+	// Secret of the CA signing the etcd certificates, required with the server certificate
+	CASecretRef corev1.LocalObjectReference `json:"ca-secret-ref,omitempty"`
+	// Secret of the etcd server certificate, no etcd certificate is issued when empty
+	ServerSecretRef corev1.LocalObjectReference `json:"server-secret-ref,omitempty"`
+	// Secret of the etcd peer certificate
+	PeerSecretRef corev1.LocalObjectReference `json:"peer-secret-ref,omitempty"`
+	// Secret of the client certificate used by kube-apiserver to reach etcd
+	ClientSecretRef corev1.LocalObjectReference `json:"client-secret-ref,omitempty"`
+	// DNS names of the server and peer certificates, the names of the managed etcd are added by the operator
+	DNSNames []string `json:"dns-names,omitempty"`
+
+	// Override the PKI profile for the CA, server, peer and client certificates
+	CAProfile     *CertificateProfile `json:"ca-profile,omitempty"`
+	ServerProfile *CertificateProfile `json:"server-profile,omitempty"`
+	PeerProfile   *CertificateProfile `json:"peer-profile,omitempty"`
+	ClientProfile *CertificateProfile `json:"client-profile,omitempty"`
+}
+
+// PKISpec are the certificates issued for the Control Plane
+type PKISpec struct {
+	// Backend issuing the certificates, cert-manager or native, the operator --pki-backend flag is used when empty
+	//+kubebuilder:validation:Enum=cert-manager;native
+	Backend string `json:"backend,omitempty"`
+
+	// Default profile of the certificates issued by the PKI, the CA only inherits its algorithm and size
+	Profile CertificateProfile `json:"profile,omitempty"`
+
+	// Name of the cert-manager Issuer signing the certificates with the CA
+	IssuerName string `json:"issuer-name,omitempty"`
+
+	CA                    CASpec                 `json:"ca,omitempty"`
+	ServiceAccounts       CertificateSpec        `json:"service-accounts,omitempty"`
+	Admin                 CertificateSpec        `json:"admin,omitempty"`
+	KubeAPIServer         ServingCertificateSpec `json:"kube-apiserver,omitempty"`
+	KubeControllerManager CertificateSpec        `json:"kube-controller-manager,omitempty"`
+	KubeScheduler         CertificateSpec        `json:"kube-scheduler,omitempty"`
+	Konnectivity          CertificateSpec        `json:"konnectivity,omitempty"`
+	Etcd                  EtcdCertificatesSpec   `json:"etcd,omitempty"`
+
+	// CA trusted by the aggregated API servers, no front-proxy certificate is issued when empty
+	FrontProxyCA CertificateSpec `json:"front-proxy-ca,omitempty"`
+	// Client certificate kube-apiserver proxies requests to the aggregated API servers with
+	FrontProxyClient CertificateSpec `json:"front-proxy-client,omitempty"`
+}
+
+// DeploymentSpec configures the Deployment of a component
+type DeploymentSpec struct {
+	// Name of the Deployment
+	Name string `json:"name,omitempty"`
+	// Number of replicas
+	Replicas int32 `json:"replicas,omitempty"`
+	// Labels added to the Deployment and its pods
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// KubeAPIServerTLS are the secrets mounted in kube-apiserver pods
+type KubeAPIServerTLS struct {
+	CASecretRef              corev1.LocalObjectReference `json:"ca-secret-ref,omitempty"`
+	ServingSecretRef         corev1.LocalObjectReference `json:"serving-secret-ref,omitempty"`
+	ServiceAccountsSecretRef corev1.LocalObjectReference `json:"service-accounts-secret-ref,omitempty"`
+	KonnectivitySecretRef    corev1.LocalObjectReference `json:"konnectivity-secret-ref,omitempty"`
+	// Client certificate used to reach etcd, etcd is reached without TLS when empty
+	EtcdClientSecretRef *corev1.LocalObjectReference `json:"etcd-client-secret-ref,omitempty"`
+	// Client certificate used to proxy requests to aggregated API servers, the kube-apiserver certificate when empty
+	FrontProxyClientSecretRef *corev1.LocalObjectReference `json:"front-proxy-client-secret-ref,omitempty"`
+}
+
+// OIDCIssuer is an OpenID Connect provider whose ID tokens authenticate users
+type OIDCIssuer struct {
+	// URL of the issuer, it must use https and match the iss claim of the tokens
+	IssuerURL string `json:"issuer-url"`
+	// Client ID the tokens must be issued for, matched against their aud claim
+	ClientID string `json:"client-id"`
+	// PEM encoded CA bundle verifying the issuer, the CAs of the kube-apiserver image are used when empty
+	CABundle string `json:"ca-bundle,omitempty"`
+
+	// Claim used as the user name, sub when empty
+	UsernameClaim string `json:"username-claim,omitempty"`
+	// Prefix of the user names, <issuer-url># when empty unless the username claim is email. - disables it.
+	UsernamePrefix string `json:"username-prefix,omitempty"`
+	// Claim holding the groups of the user, the user has no groups when empty
+	GroupsClaim string `json:"groups-claim,omitempty"`
+	// Prefix of the groups
+	GroupsPrefix string `json:"groups-prefix,omitempty"`
+
+	// Claims the tokens must hold with the given values
+	RequiredClaims map[string]string `json:"required-claims,omitempty"`
+}
+
+// AuthenticationSpec configures the authenticators of kube-apiserver, on top of client certificates and service account tokens
+type AuthenticationSpec struct {
+	// OIDC issuers, rendered to an AuthenticationConfiguration file from v1.30 and to the --oidc-* flags before.
+	// Versions before v1.30 only support one issuer.
+	OIDC []OIDCIssuer `json:"oidc,omitempty"`
+}
+
+// AuditLogBackend writes the audit events to files on a PersistentVolumeClaim, one file per kube-apiserver pod
+type AuditLogBackend struct {
+	// PersistentVolumeClaim the events are written to, it must be ReadWriteMany with more than one replica
+	ClaimRef corev1.LocalObjectReference `json:"claim-ref"`
+
+	// Format of the events
+	//+kubebuilder:validation:Enum=json;legacy
+	//+kubebuilder:default=json
+	Format string `json:"format,omitempty"`
+
+	// Days the rotated files are kept, forever when empty
+	//+kubebuilder:validation:Minimum=0
+	MaxAge int32 `json:"max-age,omitempty"`
+	// Number of rotated files kept, all when empty
+	//+kubebuilder:validation:Minimum=0
+	MaxBackups int32 `json:"max-backups,omitempty"`
+	// Size in megabytes a file is rotated at
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:default=100
+	MaxSize int32 `json:"max-size,omitempty"`
+}
+
+// AuditWebhookBackend sends the audit events to an HTTP server
+type AuditWebhookBackend struct {
+	// URL the events are posted to
+	Server string `json:"server"`
+	// PEM encoded CA bundle verifying the server, the CAs of the kube-apiserver image are used when empty
+	CABundle string `json:"ca-bundle,omitempty"`
+	// Secret holding the tls.crt and tls.key of a client certificate authenticating to the server
+	ClientSecretRef *corev1.LocalObjectReference `json:"client-secret-ref,omitempty"`
+
+	// Sending strategy: batch buffers events and sends them asynchronously, blocking sends each event before answering
+	// the request and blocking-strict also fails the request when sending fails
+	//+kubebuilder:validation:Enum=batch;blocking;blocking-strict
+	//+kubebuilder:default=batch
+	Mode string `json:"mode,omitempty"`
+	// Maximum number of events in a batch
+	//+kubebuilder:validation:Minimum=0
+	BatchMaxSize int32 `json:"batch-max-size,omitempty"`
+	// Time a batch waits for more events before being sent
+	BatchMaxWait *metav1.Duration `json:"batch-max-wait,omitempty"`
+	// Time waited before retrying a failed request
+	InitialBackoff *metav1.Duration `json:"initial-backoff,omitempty"`
+}
+
+// AuditSpec configures the audit events recorded by kube-apiserver
+type AuditSpec struct {
+	// audit.k8s.io/v1 Policy in YAML
+	Policy string `json:"policy,omitempty"`
+	// ConfigMap holding the audit.k8s.io/v1 Policy under the policy.yaml key, exclusive with policy
+	PolicyConfigMapRef *corev1.LocalObjectReference `json:"policy-config-map-ref,omitempty"`
+
+	Log     *AuditLogBackend     `json:"log,omitempty"`
+	Webhook *AuditWebhookBackend `json:"webhook,omitempty"`
+}
+
+// KMSPlugin is a KMS v2 plugin run as a sidecar of kube-apiserver, the keys stay in the external KMS it reaches
+type KMSPlugin struct {
+	// Name of the provider, stored with the encrypted Secrets so it cannot be changed
+	Name string `json:"name"`
+
+	// Image of the plugin, it must serve the KMS v2 API on the unix socket of the KMS_SOCKET environment variable
+	Image string `json:"image"`
+	// Arguments of the plugin, $(KMS_SOCKET) is replaced by the socket
+	Args []string `json:"args,omitempty"`
+	// Environment of the plugin, such as the credentials of the KMS
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Timeout of the calls of kube-apiserver to the plugin, 3s when empty
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// EncryptionSpec encrypts the Secrets stored in etcd with keys generated by the operator
+type EncryptionSpec struct {
+	// Provider of the keys, changing it rotates the keys. kms keeps the keys in an external KMS.
+	//+kubebuilder:validation:Enum=aescbc;secretbox;kms
+	//+kubebuilder:default=aescbc
+	Provider string `json:"provider,omitempty"`
+
+	// KMS v2 plugin, required by the kms provider. Remove it once the rotation to another provider is complete.
+	KMS *KMSPlugin `json:"kms,omitempty"`
+
+	// Any new value starts a key rotation
+	RotateKeys string `json:"rotate-keys,omitempty"`
+
+	// Secret holding the kubeconfig.yml of an administrator of the cluster, used to rewrite the Secrets with the new key
+	// during a rotation. The admin kubeconfig of the ControlPlane when empty.
+	AdminKubeconfigSecretRef *corev1.LocalObjectReference `json:"admin-kubeconfig-secret-ref,omitempty"`
+}
+
+// AdmissionPluginsSpec changes the admission plugins enabled by the operator
+type AdmissionPluginsSpec struct {
+	// Plugins enabled on top of the defaults
+	Enable []string `json:"enable,omitempty"`
+	// Plugins disabled, including the ones kube-apiserver enables by default
+	Disable []string `json:"disable,omitempty"`
+}
+
+// KubeAPIServerSpec configures kube-apiserver
+type KubeAPIServerSpec struct {
+	// Version of kube-apiserver, the ControlPlane version when empty
+	Version string `json:"version,omitempty"`
+
+	// Client URLs of an external etcd, only used when the ControlPlane etcd is not managed
+	EtcdServers []string `json:"etcd-servers,omitempty"`
+
+	// Range of the ClusterIP Services, immutable
+	ServiceClusterIPRange string `json:"service-cluster-ip-range,omitempty"`
+
+	Deployment DeploymentSpec   `json:"deployment,omitempty"`
+	TLS        KubeAPIServerTLS `json:"tls,omitempty"`
+
+	// Authentication of users with external identity providers
+	Authentication AuthenticationSpec `json:"authentication,omitempty"`
+
+	// Audit events, kube-apiserver records none when empty
+	Audit *AuditSpec `json:"audit,omitempty"`
+
+	// Encryption of the Secrets at rest, they are stored in plaintext when empty. It cannot be removed once set.
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+
+	// Flags of kube-apiserver without the leading --, merged over the defaults of the operator.
+	// The flags set from the spec cannot be overridden, the flags missing from the version of the
+	// component are rejected.
+	ExtraArgs map[string]string `json:"extra-args,omitempty"`
+	// Feature gates of kube-apiserver, the gates missing from its version are rejected
+	FeatureGates map[string]bool `json:"feature-gates,omitempty"`
+	// Admission plugins enabled or disabled on top of the defaults of the operator
+	AdmissionPlugins AdmissionPluginsSpec `json:"admission-plugins,omitempty"`
+}
+
+// KubeControllerManagerTLS are the secrets mounted in kube-controller-manager pods
+type KubeControllerManagerTLS struct {
+	CASecretRef              corev1.LocalObjectReference `json:"ca-secret-ref,omitempty"`
+	ClientSecretRef          corev1.LocalObjectReference `json:"client-secret-ref,omitempty"`
+	ServiceAccountsSecretRef corev1.LocalObjectReference `json:"service-accounts-secret-ref,omitempty"`
+}
+
+// KubeControllerManagerSpec configures kube-controller-manager
+type KubeControllerManagerSpec struct {
+	// Version of kube-controller-manager, the ControlPlane version when empty
+	Version string `json:"version,omitempty"`
+
+	// Endpoint kube-controller-manager reaches kube-apiserver on
+	KubeAPIServerEndpoint APIEndpoint `json:"kube-apiserver-endpoint,omitempty"`
+
+	Deployment DeploymentSpec           `json:"deployment,omitempty"`
+	TLS        KubeControllerManagerTLS `json:"tls,omitempty"`
+
+	// Flags of kube-controller-manager without the leading --, merged over the defaults of the operator.
+	// The flags set from the spec cannot be overridden, the flags missing from the version of the
+	// component are rejected.
+	ExtraArgs map[string]string `json:"extra-args,omitempty"`
+	// Feature gates of kube-controller-manager, the gates missing from its version are rejected
+	FeatureGates map[string]bool `json:"feature-gates,omitempty"`
+}
+
+// KubeSchedulerTLS are the secrets mounted in kube-scheduler pods
+type KubeSchedulerTLS struct {
+	ClientSecretRef corev1.LocalObjectReference `json:"client-secret-ref,omitempty"`
+}
+
+// KubeSchedulerSpec configures kube-scheduler
+type KubeSchedulerSpec struct {
+	// Version of kube-scheduler, the ControlPlane version when empty
+	Version string `json:"version,omitempty"`
+
+	// Endpoint kube-scheduler reaches kube-apiserver on
+	KubeAPIServerEndpoint APIEndpoint `json:"kube-apiserver-endpoint,omitempty"`
+
+	Deployment DeploymentSpec   `json:"deployment,omitempty"`
+	TLS        KubeSchedulerTLS `json:"tls,omitempty"`
+
+	// Flags of kube-scheduler without the leading --, merged over the defaults of the operator.
+	// The flags set from the spec cannot be overridden, the flags missing from the version of the
+	// component are rejected.
+	ExtraArgs map[string]string `json:"extra-args,omitempty"`
+	// Feature gates of kube-scheduler, the gates missing from its version are rejected
+	FeatureGates map[string]bool `json:"feature-gates,omitempty"`
+}
+
+type EtcdSnapshotPVC struct {
+	// Persistent volume claim holding the snapshot
+	ClaimRef corev1.LocalObjectReference `json:"claim-ref"`
+}
+
+type EtcdSnapshotS3 struct {
+	// URL of the S3 compatible endpoint
+	Endpoint string `json:"endpoint"`
+	// Bucket holding the snapshot
+	Bucket string `json:"bucket"`
+	// Key prefix of the snapshot inside the bucket
+	Prefix string `json:"prefix,omitempty"`
+	// Secret holding the access-key-id and secret-access-key keys
+	CredentialsSecretRef corev1.LocalObjectReference `json:"credentials-secret-ref"`
+	// MinIO client image used to download the snapshot
+	Image string `json:"image,omitempty"`
+}
+
+// EtcdSnapshotSource references a snapshot file, exactly one of PVC or S3 must be set
+type EtcdSnapshotSource struct {
+	PVC *EtcdSnapshotPVC `json:"pvc,omitempty"`
+	S3  *EtcdSnapshotS3  `json:"s3,omitempty"`
+
+	// Snapshot file name, relative to the PVC root or the S3 prefix
+	Snapshot string `json:"snapshot"`
+}
+
+// EtcdStorage is the persistent storage of the etcd members
+type EtcdStorage struct {
+	// Size of the persistent volume claim of each member
+	Size resource.Quantity `json:"size,omitempty"`
+	// Storage class of the persistent volume claims, the cluster default is used when empty
+	StorageClassName *string `json:"storage-class-name,omitempty"`
+}
+
+// EtcdTLS overrides the certificates of the etcd members
+type EtcdTLS struct {
+	// Secret of the etcd server certificate, the PKI etcd server certificate is used when empty
+	ServerSecretRef *corev1.LocalObjectReference `json:"server-secret-ref,omitempty"`
+	// Secret of the etcd peer certificate, the PKI etcd peer certificate is used when empty
+	PeerSecretRef *corev1.LocalObjectReference `json:"peer-secret-ref,omitempty"`
+}
+
+// EtcdSpec configures the managed etcd, certificates are taken from the PKI unless overridden
+type EtcdSpec struct {
+	// Etcd version, used as registry.k8s.io/etcd image tag
+	Version string `json:"version,omitempty"`
+
+	// Number of etcd members
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Persistent storage of the members
+	Storage EtcdStorage `json:"storage,omitempty"`
+
+	// Server and peer certificates of the members
+	TLS EtcdTLS `json:"tls,omitempty"`
+
+	// Snapshot restored into the data directory of members that have no data yet
+	Snapshot *EtcdSnapshotSource `json:"snapshot,omitempty"`
+}
+
+// NetworkSpec are the networks of the guest cluster, they are immutable.
+// A dual-stack cluster has an IPv4 and an IPv6 range, the first one is the primary family.
+type NetworkSpec struct {
+	// Ranges of the pod IPs, split by kube-controller-manager into one range per node
+	PodCIDRs []string `json:"pod-cidrs,omitempty"`
+	// Ranges of the ClusterIP Services
+	ServiceCIDRs []string `json:"service-cidrs,omitempty"`
+	// Prefix length of the IPv4 pod range of each node
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=32
+	NodeCIDRMaskSize int32 `json:"node-cidr-mask-size,omitempty"`
+	// Prefix length of the IPv6 pod range of each node
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=128
+	NodeCIDRMaskSizeIPv6 int32 `json:"node-cidr-mask-size-ipv6,omitempty"`
+	// DNS domain of the Services
+	DNSDomain string `json:"dns-domain,omitempty"`
+}
+
+// AddonSpec is an addon applied to the guest cluster with the admin kubeconfig of the ControlPlane
+type AddonSpec struct {
+	// Applies the addon to the guest cluster, it is removed from the guest cluster once disabled
+	Enabled bool `json:"enabled,omitempty"`
+	// Version of the addon image, changing it upgrades the addon
+	Version string `json:"version,omitempty"`
+}
+
+// AddonsSpec are the addons managed in the guest cluster, none is enabled by default
+type AddonsSpec struct {
+	// CoreDNS, the cluster DNS answering on the tenth IP of the Service ranges. v1.10.1 when no version is set.
+	CoreDNS AddonSpec `json:"coredns,omitempty"`
+	// konnectivity-agent, connecting the nodes to the konnectivity server next to kube-apiserver.
+	// The version of the konnectivity server, v0.0.37, when no version is set.
+	KonnectivityAgent AddonSpec `json:"konnectivity-agent,omitempty"`
+	// kube-proxy, it follows the version of the ControlPlane when no version is set
+	KubeProxy AddonSpec `json:"kube-proxy,omitempty"`
+}
+
+// ControlPlaneSpec defines the desired state of ControlPlane
+type ControlPlaneSpec struct {
+	// Control Plane version
+	Version string `json:"version,omitempty"`
+
+	// Networks of the guest cluster, given to kube-apiserver, kube-controller-manager and the certificates
+	Network NetworkSpec `json:"network,omitempty"`
+
+	Loadbalancer          LoadbalancerSpec          `json:"loadbalancer,omitempty"`
+	PKI                   PKISpec                   `json:"pki,omitempty"`
+	KubeAPIServer         KubeAPIServerSpec         `json:"kube-apiserver,omitempty"`
+	KubeControllerManager KubeControllerManagerSpec `json:"kube-controller-manager,omitempty"`
+	KubeScheduler         KubeSchedulerSpec         `json:"kube-scheduler,omitempty"`
+
+	// Managed etcd cluster, kube-apiserver etcd-servers is used when empty
+	Etcd *EtcdSpec `json:"etcd,omitempty"`
+
+	// Addons applied to the guest cluster once kube-apiserver is reachable
+	Addons AddonsSpec `json:"addons,omitempty"`
+}
+
+// ControlPlaneUpgrade is the upgrade in progress
+type ControlPlaneUpgrade struct {
+	// Version upgraded from
+	From string `json:"from"`
+	// Version upgraded to
+	To string `json:"to"`
+	// Component being upgraded: KubeAPIServer, KubeControllerManager or KubeScheduler
+	Component string `json:"component,omitempty"`
+	// Time the upgrade started
+	StartTime metav1.Time `json:"start-time"`
+}
+
+// ControlPlaneUpgradeHistory is a completed upgrade
+type ControlPlaneUpgradeHistory struct {
+	// Version upgraded from
+	From string `json:"from"`
+	// Version upgraded to
+	To string `json:"to"`
+	// Time the upgrade started
+	StartTime metav1.Time `json:"start-time"`
+	// Time the last component was upgraded
+	CompletionTime metav1.Time `json:"completion-time"`
+}
+
+// ControlPlaneStatus defines the observed state of ControlPlane
+type ControlPlaneStatus struct {
+	// Generation of the ControlPlane observed by the controller
+	ObservedGeneration int64 `json:"observed-generation,omitempty"`
+
+	// Endpoint of the kube-apiserver exposed by the Loadbalancer
+	Endpoint *APIEndpoint `json:"endpoint,omitempty"`
+
+	// Secret holding the admin kubeconfig of the Control Plane
+	KubeconfigSecretRef *corev1.LocalObjectReference `json:"kubeconfig-secret-ref,omitempty"`
+
+	// Version every component has been rolled out to
+	Version string `json:"version,omitempty"`
+
+	// Upgrade in progress
+	Upgrade *ControlPlaneUpgrade `json:"upgrade,omitempty"`
+
+	// Last completed upgrades, most recent last
+	UpgradeHistory []ControlPlaneUpgradeHistory `json:"upgrade-history,omitempty"`
+
+	// Conditions of the Control Plane and its components
+	//+listType=map
+	//+listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=cp
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`
+//+kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.endpoint.host`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ControlPlane is the Schema for the controlplanes API
+type ControlPlane struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ControlPlaneSpec   `json:"spec,omitempty"`
+	Status ControlPlaneStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ControlPlaneList contains a list of ControlPlane
+type ControlPlaneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ControlPlane `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ControlPlane{}, &ControlPlaneList{})
+}
@@ -0,0 +1,1018 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIEndpoint) DeepCopyInto(out *APIEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIEndpoint.
+func (in *APIEndpoint) DeepCopy() *APIEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(APIEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonSpec) DeepCopyInto(out *AddonSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonSpec.
+func (in *AddonSpec) DeepCopy() *AddonSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonsSpec) DeepCopyInto(out *AddonsSpec) {
+	*out = *in
+	out.CoreDNS = in.CoreDNS
+	out.KonnectivityAgent = in.KonnectivityAgent
+	out.KubeProxy = in.KubeProxy
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonsSpec.
+func (in *AddonsSpec) DeepCopy() *AddonsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionPluginsSpec) DeepCopyInto(out *AdmissionPluginsSpec) {
+	*out = *in
+	if in.Enable != nil {
+		in, out := &in.Enable, &out.Enable
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Disable != nil {
+		in, out := &in.Disable, &out.Disable
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionPluginsSpec.
+func (in *AdmissionPluginsSpec) DeepCopy() *AdmissionPluginsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionPluginsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogBackend) DeepCopyInto(out *AuditLogBackend) {
+	*out = *in
+	out.ClaimRef = in.ClaimRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogBackend.
+func (in *AuditLogBackend) DeepCopy() *AuditLogBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditSpec) DeepCopyInto(out *AuditSpec) {
+	*out = *in
+	if in.PolicyConfigMapRef != nil {
+		in, out := &in.PolicyConfigMapRef, &out.PolicyConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Log != nil {
+		in, out := &in.Log, &out.Log
+		*out = new(AuditLogBackend)
+		**out = **in
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(AuditWebhookBackend)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditSpec.
+func (in *AuditSpec) DeepCopy() *AuditSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditWebhookBackend) DeepCopyInto(out *AuditWebhookBackend) {
+	*out = *in
+	if in.ClientSecretRef != nil {
+		in, out := &in.ClientSecretRef, &out.ClientSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.BatchMaxWait != nil {
+		in, out := &in.BatchMaxWait, &out.BatchMaxWait
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.InitialBackoff != nil {
+		in, out := &in.InitialBackoff, &out.InitialBackoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditWebhookBackend.
+func (in *AuditWebhookBackend) DeepCopy() *AuditWebhookBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditWebhookBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthenticationSpec) DeepCopyInto(out *AuthenticationSpec) {
+	*out = *in
+	if in.OIDC != nil {
+		in, out := &in.OIDC, &out.OIDC
+		*out = make([]OIDCIssuer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthenticationSpec.
+func (in *AuthenticationSpec) DeepCopy() *AuthenticationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthenticationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CASpec) DeepCopyInto(out *CASpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImportSecretRef != nil {
+		in, out := &in.ImportSecretRef, &out.ImportSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(IssuerReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CASpec.
+func (in *CASpec) DeepCopy() *CASpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CASpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateProfile) DeepCopyInto(out *CertificateProfile) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Usages != nil {
+		in, out := &in.Usages, &out.Usages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateProfile.
+func (in *CertificateProfile) DeepCopy() *CertificateProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateSpec) DeepCopyInto(out *CertificateSpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateSpec.
+func (in *CertificateSpec) DeepCopy() *CertificateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlane) DeepCopyInto(out *ControlPlane) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlane.
+func (in *ControlPlane) DeepCopy() *ControlPlane {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlane)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ControlPlane) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneList) DeepCopyInto(out *ControlPlaneList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ControlPlane, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneList.
+func (in *ControlPlaneList) DeepCopy() *ControlPlaneList {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ControlPlaneList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneSpec) DeepCopyInto(out *ControlPlaneSpec) {
+	*out = *in
+	in.Network.DeepCopyInto(&out.Network)
+	in.Loadbalancer.DeepCopyInto(&out.Loadbalancer)
+	in.PKI.DeepCopyInto(&out.PKI)
+	in.KubeAPIServer.DeepCopyInto(&out.KubeAPIServer)
+	in.KubeControllerManager.DeepCopyInto(&out.KubeControllerManager)
+	in.KubeScheduler.DeepCopyInto(&out.KubeScheduler)
+	if in.Etcd != nil {
+		in, out := &in.Etcd, &out.Etcd
+		*out = new(EtcdSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Addons = in.Addons
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneSpec.
+func (in *ControlPlaneSpec) DeepCopy() *ControlPlaneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneStatus) DeepCopyInto(out *ControlPlaneStatus) {
+	*out = *in
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(APIEndpoint)
+		**out = **in
+	}
+	if in.KubeconfigSecretRef != nil {
+		in, out := &in.KubeconfigSecretRef, &out.KubeconfigSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(ControlPlaneUpgrade)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeHistory != nil {
+		in, out := &in.UpgradeHistory, &out.UpgradeHistory
+		*out = make([]ControlPlaneUpgradeHistory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneStatus.
+func (in *ControlPlaneStatus) DeepCopy() *ControlPlaneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneUpgrade) DeepCopyInto(out *ControlPlaneUpgrade) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneUpgrade.
+func (in *ControlPlaneUpgrade) DeepCopy() *ControlPlaneUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneUpgradeHistory) DeepCopyInto(out *ControlPlaneUpgradeHistory) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneUpgradeHistory.
+func (in *ControlPlaneUpgradeHistory) DeepCopy() *ControlPlaneUpgradeHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneUpgradeHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeploymentSpec) DeepCopyInto(out *DeploymentSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentSpec.
+func (in *DeploymentSpec) DeepCopy() *DeploymentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionSpec) DeepCopyInto(out *EncryptionSpec) {
+	*out = *in
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSPlugin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdminKubeconfigSecretRef != nil {
+		in, out := &in.AdminKubeconfigSecretRef, &out.AdminKubeconfigSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionSpec.
+func (in *EncryptionSpec) DeepCopy() *EncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdCertificatesSpec) DeepCopyInto(out *EtcdCertificatesSpec) {
+	*out = *in
+	out.CASecretRef = in.CASecretRef
+	out.ServerSecretRef = in.ServerSecretRef
+	out.PeerSecretRef = in.PeerSecretRef
+	out.ClientSecretRef = in.ClientSecretRef
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CAProfile != nil {
+		in, out := &in.CAProfile, &out.CAProfile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServerProfile != nil {
+		in, out := &in.ServerProfile, &out.ServerProfile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PeerProfile != nil {
+		in, out := &in.PeerProfile, &out.PeerProfile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientProfile != nil {
+		in, out := &in.ClientProfile, &out.ClientProfile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdCertificatesSpec.
+func (in *EtcdCertificatesSpec) DeepCopy() *EtcdCertificatesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdCertificatesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSnapshotPVC) DeepCopyInto(out *EtcdSnapshotPVC) {
+	*out = *in
+	out.ClaimRef = in.ClaimRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSnapshotPVC.
+func (in *EtcdSnapshotPVC) DeepCopy() *EtcdSnapshotPVC {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSnapshotPVC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSnapshotS3) DeepCopyInto(out *EtcdSnapshotS3) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSnapshotS3.
+func (in *EtcdSnapshotS3) DeepCopy() *EtcdSnapshotS3 {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSnapshotS3)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSnapshotSource) DeepCopyInto(out *EtcdSnapshotSource) {
+	*out = *in
+	if in.PVC != nil {
+		in, out := &in.PVC, &out.PVC
+		*out = new(EtcdSnapshotPVC)
+		**out = **in
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(EtcdSnapshotS3)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSnapshotSource.
+func (in *EtcdSnapshotSource) DeepCopy() *EtcdSnapshotSource {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSnapshotSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSpec) DeepCopyInto(out *EtcdSpec) {
+	*out = *in
+	in.Storage.DeepCopyInto(&out.Storage)
+	in.TLS.DeepCopyInto(&out.TLS)
+	if in.Snapshot != nil {
+		in, out := &in.Snapshot, &out.Snapshot
+		*out = new(EtcdSnapshotSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSpec.
+func (in *EtcdSpec) DeepCopy() *EtcdSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdStorage) DeepCopyInto(out *EtcdStorage) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdStorage.
+func (in *EtcdStorage) DeepCopy() *EtcdStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdTLS) DeepCopyInto(out *EtcdTLS) {
+	*out = *in
+	if in.ServerSecretRef != nil {
+		in, out := &in.ServerSecretRef, &out.ServerSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.PeerSecretRef != nil {
+		in, out := &in.PeerSecretRef, &out.PeerSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdTLS.
+func (in *EtcdTLS) DeepCopy() *EtcdTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuerReference) DeepCopyInto(out *IssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuerReference.
+func (in *IssuerReference) DeepCopy() *IssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KMSPlugin) DeepCopyInto(out *KMSPlugin) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KMSPlugin.
+func (in *KMSPlugin) DeepCopy() *KMSPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(KMSPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeAPIServerSpec) DeepCopyInto(out *KubeAPIServerSpec) {
+	*out = *in
+	if in.EtcdServers != nil {
+		in, out := &in.EtcdServers, &out.EtcdServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Deployment.DeepCopyInto(&out.Deployment)
+	in.TLS.DeepCopyInto(&out.TLS)
+	in.Authentication.DeepCopyInto(&out.Authentication)
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(AuditSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(EncryptionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.AdmissionPlugins.DeepCopyInto(&out.AdmissionPlugins)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerSpec.
+func (in *KubeAPIServerSpec) DeepCopy() *KubeAPIServerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeAPIServerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeAPIServerTLS) DeepCopyInto(out *KubeAPIServerTLS) {
+	*out = *in
+	out.CASecretRef = in.CASecretRef
+	out.ServingSecretRef = in.ServingSecretRef
+	out.ServiceAccountsSecretRef = in.ServiceAccountsSecretRef
+	out.KonnectivitySecretRef = in.KonnectivitySecretRef
+	if in.EtcdClientSecretRef != nil {
+		in, out := &in.EtcdClientSecretRef, &out.EtcdClientSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.FrontProxyClientSecretRef != nil {
+		in, out := &in.FrontProxyClientSecretRef, &out.FrontProxyClientSecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeAPIServerTLS.
+func (in *KubeAPIServerTLS) DeepCopy() *KubeAPIServerTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeAPIServerTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeControllerManagerSpec) DeepCopyInto(out *KubeControllerManagerSpec) {
+	*out = *in
+	out.KubeAPIServerEndpoint = in.KubeAPIServerEndpoint
+	in.Deployment.DeepCopyInto(&out.Deployment)
+	out.TLS = in.TLS
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeControllerManagerSpec.
+func (in *KubeControllerManagerSpec) DeepCopy() *KubeControllerManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeControllerManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeControllerManagerTLS) DeepCopyInto(out *KubeControllerManagerTLS) {
+	*out = *in
+	out.CASecretRef = in.CASecretRef
+	out.ClientSecretRef = in.ClientSecretRef
+	out.ServiceAccountsSecretRef = in.ServiceAccountsSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeControllerManagerTLS.
+func (in *KubeControllerManagerTLS) DeepCopy() *KubeControllerManagerTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeControllerManagerTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeSchedulerSpec) DeepCopyInto(out *KubeSchedulerSpec) {
+	*out = *in
+	out.KubeAPIServerEndpoint = in.KubeAPIServerEndpoint
+	in.Deployment.DeepCopyInto(&out.Deployment)
+	out.TLS = in.TLS
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeSchedulerSpec.
+func (in *KubeSchedulerSpec) DeepCopy() *KubeSchedulerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeSchedulerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeSchedulerTLS) DeepCopyInto(out *KubeSchedulerTLS) {
+	*out = *in
+	out.ClientSecretRef = in.ClientSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeSchedulerTLS.
+func (in *KubeSchedulerTLS) DeepCopy() *KubeSchedulerTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeSchedulerTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadbalancerSpec) DeepCopyInto(out *LoadbalancerSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IPFamilyPolicy != nil {
+		in, out := &in.IPFamilyPolicy, &out.IPFamilyPolicy
+		*out = new(v1.IPFamilyPolicy)
+		**out = **in
+	}
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]v1.IPFamily, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadbalancerSpec.
+func (in *LoadbalancerSpec) DeepCopy() *LoadbalancerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadbalancerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
+	*out = *in
+	if in.PodCIDRs != nil {
+		in, out := &in.PodCIDRs, &out.PodCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceCIDRs != nil {
+		in, out := &in.ServiceCIDRs, &out.ServiceCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkSpec.
+func (in *NetworkSpec) DeepCopy() *NetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCIssuer) DeepCopyInto(out *OIDCIssuer) {
+	*out = *in
+	if in.RequiredClaims != nil {
+		in, out := &in.RequiredClaims, &out.RequiredClaims
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCIssuer.
+func (in *OIDCIssuer) DeepCopy() *OIDCIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKISpec) DeepCopyInto(out *PKISpec) {
+	*out = *in
+	in.Profile.DeepCopyInto(&out.Profile)
+	in.CA.DeepCopyInto(&out.CA)
+	in.ServiceAccounts.DeepCopyInto(&out.ServiceAccounts)
+	in.Admin.DeepCopyInto(&out.Admin)
+	in.KubeAPIServer.DeepCopyInto(&out.KubeAPIServer)
+	in.KubeControllerManager.DeepCopyInto(&out.KubeControllerManager)
+	in.KubeScheduler.DeepCopyInto(&out.KubeScheduler)
+	in.Konnectivity.DeepCopyInto(&out.Konnectivity)
+	in.Etcd.DeepCopyInto(&out.Etcd)
+	in.FrontProxyCA.DeepCopyInto(&out.FrontProxyCA)
+	in.FrontProxyClient.DeepCopyInto(&out.FrontProxyClient)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKISpec.
+func (in *PKISpec) DeepCopy() *PKISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PKISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServingCertificateSpec) DeepCopyInto(out *ServingCertificateSpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	if in.IPAddresses != nil {
+		in, out := &in.IPAddresses, &out.IPAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Profile != nil {
+		in, out := &in.Profile, &out.Profile
+		*out = new(CertificateProfile)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServingCertificateSpec.
+func (in *ServingCertificateSpec) DeepCopy() *ServingCertificateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServingCertificateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
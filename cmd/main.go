@@ -18,7 +18,9 @@ package main
 
 import (
 	"flag"
+	"net"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -33,6 +35,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+	clusterv1beta1 "github.com/elssuy/kubeception-operator/api/v1beta1"
 	"github.com/elssuy/kubeception-operator/internal/controller"
 
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
@@ -48,6 +51,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(clusterv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(clusterv1beta1.AddToScheme(scheme))
 	utilruntime.Must(certmanagerv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
@@ -56,11 +60,18 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var pkiBackend string
+	var managementClusterCIDRs string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&pkiBackend, "pki-backend", clusterv1alpha1.PkiBackendCertManager,
+		"Backend issuing the certificates of the Pki that do not select one, either cert-manager or native.")
+	flag.StringVar(&managementClusterCIDRs, "management-cluster-cidrs", "",
+		"Comma separated networks of the management cluster, such as its node, pod and service ranges. "+
+			"The networks of the ControlPlanes must not overlap them.")
 	opts := zap.Options{
 		Development: true,
 		// Encoder:     zapcore.NewJSONEncoder(zapcore.EncoderConfig{}),
@@ -70,6 +81,18 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	for _, cidr := range strings.Split(managementClusterCIDRs, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			setupLog.Error(err, "invalid --management-cluster-cidrs")
+			os.Exit(1)
+		}
+		clusterv1alpha1.ManagementClusterCIDRs = append(clusterv1alpha1.ManagementClusterCIDRs, ipnet)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -98,7 +121,7 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "ControlPlane")
 		os.Exit(1)
 	}
-	if err = controller.NewPkiReconciler(mgr).SetupWithManager(mgr); err != nil {
+	if err = controller.NewPkiReconciler(mgr, pkiBackend).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Pki")
 		os.Exit(1)
 	}
@@ -119,6 +142,81 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = controller.NewEtcdReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Etcd")
+		os.Exit(1)
+	}
+	if err = controller.NewEtcdBackupReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EtcdBackup")
+		os.Exit(1)
+	}
+	if err = controller.NewEtcdRestoreReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EtcdRestore")
+		os.Exit(1)
+	}
+	if err = controller.NewKubeconfigRequestReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KubeconfigRequest")
+		os.Exit(1)
+	}
+	if err = controller.NewBootstrapTokenReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BootstrapToken")
+		os.Exit(1)
+	}
+	if err = controller.NewAddonsReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Addons")
+		os.Exit(1)
+	}
+	if err = controller.NewGuestResourceSetReconciler(mgr).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GuestResourceSet")
+		os.Exit(1)
+	}
+	// Webhooks need serving certificates, disable them with ENABLE_WEBHOOKS=false when running locally
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&clusterv1alpha1.ControlPlane{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ControlPlane")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.Pki{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Pki")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.KubeAPIServer{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "KubeAPIServer")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.KubeControllerManager{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "KubeControllerManager")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.KubeScheduler{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "KubeScheduler")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.Loadbalancer{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Loadbalancer")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.KubeconfigRequest{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "KubeconfigRequest")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.Etcd{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Etcd")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.BootstrapToken{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "BootstrapToken")
+			os.Exit(1)
+		}
+		if err = (&clusterv1alpha1.GuestResourceSet{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "GuestResourceSet")
+			os.Exit(1)
+		}
+		if err = (&clusterv1beta1.ControlPlane{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ControlPlane")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -9,100 +9,7 @@ if [[ -z $CONTROL_PLANE_IP ]]; then
   exit 1
 fi
 
-echo "==== Deploying Konnectivity Agent ===="
-kubectl apply -f - <<EOF
-apiVersion: apps/v1
-# Alternatively, you can deploy the agents as Deployments. It is not necessary
-# to have an agent on each node.
-kind: DaemonSet
-metadata:
-  labels:
-    addonmanager.kubernetes.io/mode: Reconcile
-    k8s-app: konnectivity-agent
-  namespace: kube-system
-  name: konnectivity-agent
-spec:
-  selector:
-    matchLabels:
-      k8s-app: konnectivity-agent
-  template:
-    metadata:
-      labels:
-        k8s-app: konnectivity-agent
-    spec:
-      hostNetwork: true
-      priorityClassName: system-cluster-critical
-      tolerations:
-        - key: "CriticalAddonsOnly"
-          operator: "Exists"
-      containers:
-        - image: registry.k8s.io/kas-network-proxy/proxy-agent:v0.0.37
-          name: konnectivity-agent
-          command: ["/proxy-agent"]
-          args: [
-                  "--logtostderr=true",
-                  "--ca-cert=/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
-                  "--proxy-server-host=$CONTROL_PLANE_IP",
-                  "--proxy-server-port=8091",
-                  "--agent-identifiers=host=\$(NODE_NAME)&ipv4=\$(HOST_IP)",
-                  "--agent-id=\$(NODE_NAME)",
-                  "--service-account-token-path=/var/run/secrets/tokens/konnectivity-agent-token"
-                  ]
-          env:
-            - name: NODE_NAME
-              valueFrom:
-                fieldRef:
-                  apiVersion: v1
-                  fieldPath: spec.nodeName
-            - name: HOST_IP
-              valueFrom:
-                fieldRef:
-                  apiVersion: v1
-                  fieldPath: status.hostIP
-          volumeMounts:
-            - mountPath: /var/run/secrets/tokens
-              name: konnectivity-agent-token
-          livenessProbe:
-            httpGet:
-              port: 8093
-              path: /healthz
-            initialDelaySeconds: 15
-            timeoutSeconds: 15
-      serviceAccountName: konnectivity-agent
-      volumes:
-        - name: konnectivity-agent-token
-          projected:
-            sources:
-              - serviceAccountToken:
-                  path: konnectivity-agent-token
-                  audience: system:konnectivity-server
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: system:konnectivity-server
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: system:auth-delegator
-subjects:
-  - apiGroup: rbac.authorization.k8s.io
-    kind: User
-    name: system:konnectivity-server
----
-apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: konnectivity-agent
-  namespace: kube-system
-  labels:
-    kubernetes.io/cluster-service: "true"
-    addonmanager.kubernetes.io/mode: Reconcile
-EOF
-
+# CoreDNS, konnectivity-agent and kube-proxy are addons of the ControlPlane, applied by the operator
 
 echo "==== Deploy CNI ===="
 helm install cilium cilium/cilium --version=1.14.1 \
@@ -131,211 +38,6 @@ subjects:
 EOF
 
 
-echo "==== Deploy CoreDNS ===="
-kubectl apply -f - <<EOF
-apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: coredns
-  namespace: kube-system
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRole
-metadata:
-  labels:
-    kubernetes.io/bootstrapping: rbac-defaults
-  name: system:coredns
-rules:
-  - apiGroups:
-    - ""
-    resources:
-    - endpoints
-    - services
-    - pods
-    - namespaces
-    verbs:
-    - list
-    - watch
-  - apiGroups:
-    - discovery.k8s.io
-    resources:
-    - endpointslices
-    verbs:
-    - list
-    - watch
----
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  annotations:
-    rbac.authorization.kubernetes.io/autoupdate: "true"
-  labels:
-    kubernetes.io/bootstrapping: rbac-defaults
-  name: system:coredns
-roleRef:
-  apiGroup: rbac.authorization.k8s.io
-  kind: ClusterRole
-  name: system:coredns
-subjects:
-- kind: ServiceAccount
-  name: coredns
-  namespace: kube-system
----
-apiVersion: v1
-kind: ConfigMap
-metadata:
-  name: coredns
-  namespace: kube-system
-data:
-  Corefile: |
-    .:53 {
-        errors
-        health {
-            lameduck 5s
-        }
-        ready
-        kubernetes cluster.local in-addr.arpa ip6.arpa {
-            pods insecure
-            fallthrough in-addr.arpa ip6.arpa
-            ttl 30
-        }
-        prometheus :9153
-        forward . 1.1.1.1
-        cache 30
-        loop
-        reload
-        loadbalance
-    }
----
-apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: coredns
-  namespace: kube-system
-  labels:
-    k8s-app: kube-dns
-    kubernetes.io/name: "CoreDNS"
-    app.kubernetes.io/name: coredns
-spec:
-  # replicas: not specified here:
-  # 1. Default is 1.
-  # 2. Will be tuned in real time if DNS horizontal auto-scaling is turned on.
-  strategy:
-    type: RollingUpdate
-    rollingUpdate:
-      maxUnavailable: 1
-  selector:
-    matchLabels:
-      k8s-app: kube-dns
-      app.kubernetes.io/name: coredns
-  template:
-    metadata:
-      labels:
-        k8s-app: kube-dns
-        app.kubernetes.io/name: coredns
-    spec:
-      priorityClassName: system-cluster-critical
-      serviceAccountName: coredns
-      tolerations:
-        - key: "CriticalAddonsOnly"
-          operator: "Exists"
-      nodeSelector:
-        kubernetes.io/os: linux
-      affinity:
-         podAntiAffinity:
-           requiredDuringSchedulingIgnoredDuringExecution:
-           - labelSelector:
-               matchExpressions:
-               - key: k8s-app
-                 operator: In
-                 values: ["kube-dns"]
-             topologyKey: kubernetes.io/hostname
-      containers:
-      - name: coredns
-        image: coredns/coredns:1.9.4
-        imagePullPolicy: IfNotPresent
-        resources:
-          limits:
-            memory: 170Mi
-          requests:
-            cpu: 100m
-            memory: 70Mi
-        args: [ "-conf", "/etc/coredns/Corefile" ]
-        volumeMounts:
-        - name: config-volume
-          mountPath: /etc/coredns
-          readOnly: true
-        ports:
-        - containerPort: 53
-          name: dns
-          protocol: UDP
-        - containerPort: 53
-          name: dns-tcp
-          protocol: TCP
-        - containerPort: 9153
-          name: metrics
-          protocol: TCP
-        securityContext:
-          allowPrivilegeEscalation: false
-          capabilities:
-            add:
-            - NET_BIND_SERVICE
-            drop:
-            - all
-          readOnlyRootFilesystem: true
-        livenessProbe:
-          httpGet:
-            path: /health
-            port: 8080
-            scheme: HTTP
-          initialDelaySeconds: 60
-          timeoutSeconds: 5
-          successThreshold: 1
-          failureThreshold: 5
-        readinessProbe:
-          httpGet:
-            path: /ready
-            port: 8181
-            scheme: HTTP
-      dnsPolicy: Default
-      volumes:
-        - name: config-volume
-          configMap:
-            name: coredns
-            items:
-            - key: Corefile
-              path: Corefile
----
-apiVersion: v1
-kind: Service
-metadata:
-  name: kube-dns
-  namespace: kube-system
-  annotations:
-    prometheus.io/port: "9153"
-    prometheus.io/scrape: "true"
-  labels:
-    k8s-app: kube-dns
-    kubernetes.io/cluster-service: "true"
-    kubernetes.io/name: "CoreDNS"
-    app.kubernetes.io/name: coredns
-spec:
-  selector:
-    k8s-app: kube-dns
-    app.kubernetes.io/name: coredns
-  clusterIP: 10.32.0.2
-  ports:
-  - name: dns
-    port: 53
-    protocol: UDP
-  - name: dns-tcp
-    port: 53
-    protocol: TCP
-  - name: metrics
-    port: 9153
-    protocol: TCP
-EOF
-
 echo "==== Deploy Kube metrics ===="
 kubectl apply -f - <<EOF
 apiVersion: v1
@@ -1,74 +0,0 @@
-#!/bin/bash
-export LC_ALL=C
-ID="$(</dev/urandom tr -dc a-z0-9 | head -c "${1:-6}";echo;)"
-SECRET="$(< /dev/urandom tr -dc a-z0-9 | head -c "${1:-16}";echo;)"
-TOKEN="$ID.$SECRET"
-
-echo "TOKEN is : $TOKEN"
-
-kubectl apply -f - <<EOF
-apiVersion: v1
-kind: Secret
-metadata:
-  name: bootstrap-token-$ID
-  namespace: kube-system
-
-type: bootstrap.kubernetes.io/token
-stringData:
-  description: "Bootstrap token"
-
-  token-id: $ID
-  token-secret: $SECRET
-
-  # Expiration. Optional.
-  # expiration: 2017-03-10T03:22:11Z
-
-  # Allowed usages.
-  usage-bootstrap-authentication: "true"
-  usage-bootstrap-signing: "true"
-
-  # Extra groups to authenticate the token as. Must start with "system:bootstrappers:"
-  auth-extra-groups: system:bootstrappers:node
----
-# enable bootstrapping nodes to create CSR
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: create-csrs-for-bootstrapping
-subjects:
-- kind: Group
-  name: system:bootstrappers
-  apiGroup: rbac.authorization.k8s.io
-roleRef:
-  kind: ClusterRole
-  name: system:node-bootstrapper
-  apiGroup: rbac.authorization.k8s.io
----
-# Approve all CSRs for the group "system:bootstrappers"
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: auto-approve-csrs-for-group
-subjects:
-- kind: Group
-  name: system:bootstrappers
-  apiGroup: rbac.authorization.k8s.io
-roleRef:
-  kind: ClusterRole
-  name: system:certificates.k8s.io:certificatesigningrequests:nodeclient
-  apiGroup: rbac.authorization.k8s.io
----
-# Approve renewal CSRs for the group "system:nodes"
-apiVersion: rbac.authorization.k8s.io/v1
-kind: ClusterRoleBinding
-metadata:
-  name: auto-approve-renewals-for-nodes
-subjects:
-- kind: Group
-  name: system:nodes
-  apiGroup: rbac.authorization.k8s.io
-roleRef:
-  kind: ClusterRole
-  name: system:certificates.k8s.io:certificatesigningrequests:selfnodeclient
-  apiGroup: rbac.authorization.k8s.io
-EOF
@@ -4,19 +4,20 @@ set -e
 set -x
 
 usage() {
-  echo "USAGE: ${0} [Control plane IP] [TOKEN] [CA String]"
+  echo "USAGE: ${0} [Control plane endpoint] [TOKEN] [CA cert hash]"
+  echo "The arguments are the ones of the join-command of the BootstrapToken Secret"
   exit 1
 }
 
-CONTROLPLANE_IP=$1
+ENDPOINT=$1
 TOKEN=$2
-CA=$3
+CA_CERT_HASH=$3
 KUBELETVERSION=1.27.5
 KUBERNETES_VERSION=v1.27
 PROJECT_PATH=stable:/v1.29
 
-if [[ -z ${CONTROLPLANE_IP} ]]; then
-  echo "Control plane ip is not set"
+if [[ -z ${ENDPOINT} ]]; then
+  echo "Control plane endpoint is not set"
   usage
 fi
 
@@ -25,8 +26,8 @@ if [[ -z ${TOKEN} ]]; then
   usage
 fi
 
-if [[ -z ${CA} ]]; then
-  echo "CA is not set"
+if [[ -z ${CA_CERT_HASH} ]]; then
+  echo "CA cert hash is not set"
   usage
 fi
 
@@ -74,7 +75,7 @@ sudo apt install cri-o -y
 sudo systemctl start crio
 sudo systemctl enable crio
 
-# Install Kubelet
+# Install Kubelet and kubeadm
 
 sudo apt-get update && sudo apt-get install -y apt-transport-https curl
 curl -fsSL https://pkgs.k8s.io/core:/stable:/${KUBERNETES_VERSION}/deb/Release.key | gpg --dearmor -o /etc/apt/keyrings/kubernetes-apt-keyring.gpg
@@ -82,84 +83,16 @@ cat <<EOF | sudo tee /etc/apt/sources.list.d/kubernetes.list
 deb [signed-by=/etc/apt/keyrings/kubernetes-apt-keyring.gpg] https://pkgs.k8s.io/core:/stable:/${KUBERNETES_VERSION}/deb/ /
 EOF
 sudo apt-get update
-sudo apt-get install -y kubelet
-sudo apt-mark hold kubelet
+sudo apt-get install -y kubelet kubeadm
+sudo apt-mark hold kubelet kubeadm
 
 ###################
-# Kubelet setup
+# Join the cluster
 ###################
 
-mkdir -p /var/lib/kubelet
-mkdir -p /var/lib/kubelet/pki
-mkdir -p /var/lib/kubelet/manifests
-mkdir -p /etc/kubernetes
-
-cat <<EOF > /var/lib/kubelet/kubelet-config.yaml
-apiVersion: kubelet.config.k8s.io/v1beta1
-kind: KubeletConfiguration
-containerRuntimeEndpoint: /run/crio/crio.sock
-cgroupDriver: systemd
-authentication:
-  anonymous:
-    enabled: false
-  webhook:
-    cacheTTL: 0s
-    enabled: true
-  x509:
-    clientCAFile: /var/lib/kubelet/pki/ca.crt
-authorization:
-  mode: Webhook
-  webhook:
-    cacheAuthorizedTTL: 0s
-    cacheUnauthorizedTTL: 0s
-clusterDNS:
-  - 10.32.0.2
-clusterDomain: cluster.local
-rotateCertificates: true
-serverTLSBootstrap: false
-EOF
-
-cat <<EOF > /var/lib/kubelet/bootstrap-kubeconfig
-apiVersion: v1
-clusters:
-- cluster:
-    certificate-authority: /var/lib/kubelet/pki/ca.crt
-    server: https://$CONTROLPLANE_IP:6443
-  name: bootstrap
-contexts:
-- context:
-    cluster: bootstrap
-    user: kubelet-bootstrap
-  name: bootstrap
-current-context: bootstrap
-kind: Config
-preferences: {}
-users:
-- name: kubelet-bootstrap
-  user:
-    token: $TOKEN
-EOF
-
-
-cat <<EOF > /etc/systemd/system/kubelet.service
-[Unit]
-Description=Kubelet Service
-Requires=cri-o.service
-After=cri-o.service
-
-[Service]
-Restart=always
-
-ExecStart=/usr/bin/kubelet \\
-  --config=/var/lib/kubelet/kubelet-config.yaml \\
-  --bootstrap-kubeconfig=/var/lib/kubelet/bootstrap-kubeconfig \\
-  --kubeconfig=/var/lib/kubelet/kubeconfig
-
-
-[Install]
-WantedBy=multi-user.target
-EOF
-
-systemctl daemon-reload
-
-echo "$CA" | base64 -d > /var/lib/kubelet/pki/ca.crt
+# kubeadm discovers the cluster through the cluster-info ConfigMap signed with the token, checks its CA against the
+# hash then writes the bootstrap kubeconfig and the kubelet configuration published by the operator
+sudo kubeadm join "${ENDPOINT}" \
+  --token "${TOKEN}" \
+  --discovery-token-ca-cert-hash "${CA_CERT_HASH}" \
+  --cri-socket unix:///var/run/crio/crio.sock
@@ -0,0 +1,398 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+const (
+	DefaultCoreDNSVersion = "v1.10.1"
+	// DefaultKonnectivityVersion is the version of the konnectivity server, the agents default to it
+	DefaultKonnectivityVersion = "v0.0.37"
+
+	// KonnectivityAgentPort is the port of the konnectivity server the agents connect to through the Loadbalancer
+	KonnectivityAgentPort = 8091
+)
+
+// Addons are applied in kube-system of the guest cluster
+const (
+	CoreDNSAddon           = "coredns"
+	KonnectivityAgentAddon = "konnectivity-agent"
+	KubeProxyAddon         = "kube-proxy"
+)
+
+// Mount paths of the service account token in the pods of the guest cluster
+const (
+	serviceAccountCAPath    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// Addon is an addon of a ControlPlane and the objects it applies to the guest cluster
+type Addon struct {
+	Name    string
+	Enabled bool
+	Version string
+	Objects []client.Object
+}
+
+// ControlPlaneAddons returns the addons of the ControlPlane, the ControlPlane must be defaulted and have an endpoint
+func ControlPlaneAddons(cp clusterv1alpha1.ControlPlane) ([]Addon, error) {
+	coreDNSVersion := CoaleseString(cp.Spec.Addons.CoreDNS.Version, DefaultCoreDNSVersion)
+	konnectivityVersion := CoaleseString(cp.Spec.Addons.KonnectivityAgent.Version, DefaultKonnectivityVersion)
+	// kube-proxy must not be newer than kube-apiserver, it follows the ControlPlane once its upgrade is rolled out
+	kubeProxyVersion := CoaleseString(cp.Spec.Addons.KubeProxy.Version, cp.Status.Version, cp.Spec.Version)
+
+	endpoint, err := url.Parse(cp.Status.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %w", cp.Status.Endpoint, err)
+	}
+	kubeProxyObjects, err := KubeProxyObjects(cp, kubeProxyVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Addon{
+		{Name: CoreDNSAddon, Enabled: cp.Spec.Addons.CoreDNS.Enabled, Version: coreDNSVersion, Objects: CoreDNSObjects(cp, coreDNSVersion)},
+		{Name: KonnectivityAgentAddon, Enabled: cp.Spec.Addons.KonnectivityAgent.Enabled, Version: konnectivityVersion, Objects: KonnectivityAgentObjects(endpoint.Hostname(), konnectivityVersion)},
+		{Name: KubeProxyAddon, Enabled: cp.Spec.Addons.KubeProxy.Enabled, Version: kubeProxyVersion, Objects: kubeProxyObjects},
+	}, nil
+}
+
+// addonLabels are set on every object of an addon, only the objects carrying them are removed with the addon
+func addonLabels(addon string, more map[string]string) map[string]string {
+	return labels(addon, addon, mergeLabels(map[string]string{
+		"app.kubernetes.io/managed-by":    GuestFieldOwner,
+		"addonmanager.kubernetes.io/mode": "Reconcile",
+	}, more))
+}
+
+func mergeLabels(l, more map[string]string) map[string]string {
+	for k, v := range more {
+		l[k] = v
+	}
+	return l
+}
+
+func systemMeta(name string, l map[string]string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceSystem, Labels: l}
+}
+
+// CoreDNSObjects are the objects of CoreDNS, the kube-dns Service takes the tenth IP of the Service ranges as the
+// clusterDNS of the kubelet configuration
+func CoreDNSObjects(cp clusterv1alpha1.ControlPlane, version string) []client.Object {
+	selector := map[string]string{"k8s-app": "kube-dns"}
+	l := addonLabels(CoreDNSAddon, selector)
+
+	corefile := fmt.Sprintf(`.:53 {
+    errors
+    health {
+        lameduck 5s
+    }
+    ready
+    kubernetes %s in-addr.arpa ip6.arpa {
+        pods insecure
+        fallthrough in-addr.arpa ip6.arpa
+        ttl 30
+    }
+    prometheus :9153
+    forward . /etc/resolv.conf {
+        max_concurrent 1000
+    }
+    cache 30
+    loop
+    reload
+    loadbalance
+}
+`, cp.Spec.Network.DNSDomain)
+
+	replicas, privilegeEscalation, readOnlyRootFilesystem := int32(2), false, true
+	service := &corev1.Service{
+		ObjectMeta: systemMeta("kube-dns", mergeLabels(addonLabels(CoreDNSAddon, selector), map[string]string{"kubernetes.io/cluster-service": "true", "kubernetes.io/name": "CoreDNS"})),
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports: []corev1.ServicePort{
+				{Name: "dns", Port: 53, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromInt(53)},
+				{Name: "dns-tcp", Port: 53, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(53)},
+				{Name: "metrics", Port: 9153, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(9153)},
+			},
+		},
+	}
+	if ips := cp.Spec.Network.DNSServiceIPs(); len(ips) > 0 {
+		service.Spec.ClusterIP = ips[0]
+		service.Spec.ClusterIPs = ips
+		if len(ips) > 1 {
+			policy := corev1.IPFamilyPolicyRequireDualStack
+			service.Spec.IPFamilyPolicy = &policy
+		}
+	}
+
+	return []client.Object{
+		&corev1.ServiceAccount{ObjectMeta: systemMeta("coredns", l)},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "system:coredns", Labels: l},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"endpoints", "services", "pods", "namespaces"}, Verbs: []string{"list", "watch"}},
+				{APIGroups: []string{"discovery.k8s.io"}, Resources: []string{"endpointslices"}, Verbs: []string{"list", "watch"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "system:coredns", Labels: l},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "coredns", Namespace: metav1.NamespaceSystem}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "system:coredns", APIGroup: rbacv1.GroupName},
+		},
+		&corev1.ConfigMap{ObjectMeta: systemMeta("coredns", l), Data: map[string]string{"Corefile": corefile}},
+		&appsv1.Deployment{
+			ObjectMeta: systemMeta("coredns", l),
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: selector},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: l},
+					Spec: corev1.PodSpec{
+						PriorityClassName:  "system-cluster-critical",
+						ServiceAccountName: "coredns",
+						DNSPolicy:          corev1.DNSDefault,
+						NodeSelector:       map[string]string{corev1.LabelOSStable: "linux"},
+						Tolerations:        []corev1.Toleration{{Key: "CriticalAddonsOnly", Operator: corev1.TolerationOpExists}},
+						Affinity: &corev1.Affinity{
+							PodAntiAffinity: &corev1.PodAntiAffinity{
+								PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+									Weight: 100,
+									PodAffinityTerm: corev1.PodAffinityTerm{
+										LabelSelector: &metav1.LabelSelector{MatchLabels: selector},
+										TopologyKey:   corev1.LabelHostname,
+									},
+								}},
+							},
+						},
+						Volumes: []corev1.Volume{
+							{Name: "config-volume", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "coredns"}}}},
+						},
+						Containers: []corev1.Container{{
+							Name:  "coredns",
+							Image: fmt.Sprintf("registry.k8s.io/coredns/coredns:%s", version),
+							Args:  []string{"-conf", "/etc/coredns/Corefile"},
+							Ports: []corev1.ContainerPort{
+								{Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP},
+								{Name: "dns-tcp", ContainerPort: 53, Protocol: corev1.ProtocolTCP},
+								{Name: "metrics", ContainerPort: 9153, Protocol: corev1.ProtocolTCP},
+							},
+							Resources: corev1.ResourceRequirements{
+								Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("170Mi")},
+								Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("70Mi")},
+							},
+							VolumeMounts: []corev1.VolumeMount{{Name: "config-volume", MountPath: "/etc/coredns", ReadOnly: true}},
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: &privilegeEscalation,
+								ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+								Capabilities:             &corev1.Capabilities{Add: []corev1.Capability{"NET_BIND_SERVICE"}, Drop: []corev1.Capability{"ALL"}},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler:        corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/health", Port: intstr.FromInt(8080)}},
+								InitialDelaySeconds: 60,
+								TimeoutSeconds:      5,
+								FailureThreshold:    5,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt(8181)}},
+							},
+						}},
+					},
+				},
+			},
+		},
+		service,
+	}
+}
+
+// KonnectivityAgentObjects are the objects of konnectivity-agent. The agents connect to the konnectivity server
+// through the Loadbalancer and authenticate with a token of the konnectivity-agent service account of kube-system,
+// as expected by the konnectivity container of kube-apiserver.
+func KonnectivityAgentObjects(host, version string) []client.Object {
+	selector := map[string]string{"k8s-app": "konnectivity-agent"}
+	l := addonLabels(KonnectivityAgentAddon, selector)
+
+	return []client.Object{
+		&corev1.ServiceAccount{ObjectMeta: systemMeta("konnectivity-agent", l)},
+		// The konnectivity server reviews the tokens of the agents with its own identity
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "system:konnectivity-server", Labels: l},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "system:konnectivity-server", APIGroup: rbacv1.GroupName}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "system:auth-delegator", APIGroup: rbacv1.GroupName},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: systemMeta("konnectivity-agent", l),
+			Spec: appsv1.DaemonSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: selector},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: l},
+					Spec: corev1.PodSpec{
+						HostNetwork:        true,
+						PriorityClassName:  "system-cluster-critical",
+						ServiceAccountName: "konnectivity-agent",
+						Tolerations:        []corev1.Toleration{{Key: "CriticalAddonsOnly", Operator: corev1.TolerationOpExists}},
+						Volumes: []corev1.Volume{{
+							Name: "konnectivity-agent-token",
+							VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{{
+								ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "konnectivity-agent-token", Audience: "system:konnectivity-server"},
+							}}}},
+						}},
+						Containers: []corev1.Container{{
+							Name:    "konnectivity-agent",
+							Image:   fmt.Sprintf("registry.k8s.io/kas-network-proxy/proxy-agent:%s", version),
+							Command: []string{"/proxy-agent"},
+							Args: []string{
+								"--logtostderr=true",
+								"--ca-cert=" + serviceAccountCAPath,
+								"--proxy-server-host=" + host,
+								fmt.Sprintf("--proxy-server-port=%d", KonnectivityAgentPort),
+								"--agent-identifiers=host=$(NODE_NAME)&ipv4=$(HOST_IP)",
+								"--agent-id=$(NODE_NAME)",
+								"--service-account-token-path=/var/run/secrets/tokens/konnectivity-agent-token",
+							},
+							Env: []corev1.EnvVar{
+								{Name: "NODE_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"}}},
+								{Name: "HOST_IP", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "status.hostIP"}}},
+							},
+							VolumeMounts: []corev1.VolumeMount{{Name: "konnectivity-agent-token", MountPath: "/var/run/secrets/tokens"}},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler:        corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8093)}},
+								InitialDelaySeconds: 15,
+								TimeoutSeconds:      15,
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// kubeProxyConfiguration is the subset of the kubeproxy.config.k8s.io/v1alpha1 KubeProxyConfiguration the operator sets
+type kubeProxyConfiguration struct {
+	APIVersion       string `json:"apiVersion"`
+	Kind             string `json:"kind"`
+	ClientConnection struct {
+		Kubeconfig string `json:"kubeconfig"`
+	} `json:"clientConnection"`
+	ClusterCIDR string `json:"clusterCIDR"`
+	Mode        string `json:"mode"`
+}
+
+// KubeProxyObjects are the objects of kube-proxy. It reaches kube-apiserver through the Loadbalancer, the kubernetes
+// Service is only routed once kube-proxy runs.
+func KubeProxyObjects(cp clusterv1alpha1.ControlPlane, version string) ([]client.Object, error) {
+	selector := map[string]string{"k8s-app": "kube-proxy"}
+	l := addonLabels(KubeProxyAddon, selector)
+
+	config := kubeProxyConfiguration{
+		APIVersion:  "kubeproxy.config.k8s.io/v1alpha1",
+		Kind:        "KubeProxyConfiguration",
+		ClusterCIDR: strings.Join(cp.Spec.Network.PodCIDRs, ","),
+		Mode:        "iptables",
+	}
+	config.ClientConnection.Kubeconfig = "/var/lib/kube-proxy/kubeconfig.conf"
+	// JSON is valid YAML
+	configData, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"default": {Server: cp.Status.Endpoint, CertificateAuthority: serviceAccountCAPath},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"default": {TokenFile: serviceAccountTokenPath},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"default": {Cluster: "default", AuthInfo: "default", Namespace: metav1.NamespaceDefault},
+		},
+		CurrentContext: "default",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hostPathType, privileged := corev1.HostPathFileOrCreate, true
+	return []client.Object{
+		&corev1.ServiceAccount{ObjectMeta: systemMeta("kube-proxy", l)},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubeadm:node-proxier", Labels: l},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "kube-proxy", Namespace: metav1.NamespaceSystem}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "system:node-proxier", APIGroup: rbacv1.GroupName},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: systemMeta("kube-proxy", l),
+			Data:       map[string]string{"config.conf": string(configData), "kubeconfig.conf": string(kubeconfig)},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: systemMeta("kube-proxy", l),
+			Spec: appsv1.DaemonSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: selector},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: l},
+					Spec: corev1.PodSpec{
+						HostNetwork:        true,
+						PriorityClassName:  "system-node-critical",
+						ServiceAccountName: "kube-proxy",
+						NodeSelector:       map[string]string{corev1.LabelOSStable: "linux"},
+						Tolerations:        []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+						Volumes: []corev1.Volume{
+							{Name: "kube-proxy", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "kube-proxy"}}}},
+							{Name: "xtables-lock", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/run/xtables.lock", Type: &hostPathType}}},
+							{Name: "lib-modules", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/lib/modules"}}},
+						},
+						Containers: []corev1.Container{{
+							Name:  "kube-proxy",
+							Image: fmt.Sprintf("registry.k8s.io/kube-proxy:%s", version),
+							Command: []string{
+								"/usr/local/bin/kube-proxy",
+								"--config=/var/lib/kube-proxy/config.conf",
+								"--hostname-override=$(NODE_NAME)",
+							},
+							Env: []corev1.EnvVar{
+								{Name: "NODE_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"}}},
+							},
+							SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "kube-proxy", MountPath: "/var/lib/kube-proxy"},
+								{Name: "xtables-lock", MountPath: "/run/xtables.lock"},
+								{Name: "lib-modules", MountPath: "/lib/modules", ReadOnly: true},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// AddonsReconciler applies the addons of a ControlPlane to its guest cluster
+type AddonsReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+
+	// GuestClient returns a client of the guest cluster the addons are applied to
+	GuestClient GuestClientFunc
+}
+
+func NewAddonsReconciler(mgr manager.Manager) *AddonsReconciler {
+	return &AddonsReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		log:         log.Log.WithName("addons-reconciler"),
+		GuestClient: NewGuestClient,
+	}
+}
+
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=controlplanes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=controlplanes/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// Reconcile applies the enabled addons of the ControlPlane to kube-system of its guest cluster with the admin
+// kubeconfig, using server-side apply so that a version change upgrades them. A disabled addon is removed, only the
+// objects labeled as managed by the operator are deleted.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
+func (r *AddonsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cp := &clusterv1alpha1.ControlPlane{}
+	if err := r.Get(ctx, req.NamespacedName, cp); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	// The guest cluster goes away with the ControlPlane
+	if !cp.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	cp.SetDefaults()
+
+	enabled := cp.Spec.Addons.CoreDNS.Enabled || cp.Spec.Addons.KonnectivityAgent.Enabled || cp.Spec.Addons.KubeProxy.Enabled
+	if !enabled && meta.FindStatusCondition(cp.Status.Conditions, clusterv1alpha1.ConditionAddonsReady) == nil {
+		return ctrl.Result{}, nil
+	}
+
+	if cp.Status.Endpoint == "" {
+		r.log.Info("ControlPlane endpoint is not registered, requeing", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, cp, metav1.ConditionFalse, "EndpointPending", fmt.Sprintf("Waiting for the Loadbalancer of ControlPlane %s", cp.Name))
+	}
+
+	guest, err := r.GuestClient(ctx, r.Client, cp)
+	if errors.Is(err, ErrGuestNotReady) {
+		r.log.Info("Admin kubeconfig not issued yet, requeing", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, cp, metav1.ConditionFalse, "KubeconfigPending", "Waiting for the admin kubeconfig of the ControlPlane")
+	}
+	if err != nil {
+		r.log.Error(err, "failed to create guest cluster client", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	addons, err := ControlPlaneAddons(*cp)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	applied := []string{}
+	for _, addon := range addons {
+		if addon.Enabled {
+			err = r.ApplyAddon(ctx, guest, addon)
+		} else {
+			err = r.RemoveAddon(ctx, guest, addon)
+		}
+		if err != nil {
+			// kube-apiserver may not be available yet
+			r.log.Error(err, "failed to reconcile addon in the guest cluster", "name", cp.Name, "namespace", req.Namespace, "addon", addon.Name)
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, r.UpdateCondition(ctx, cp, metav1.ConditionFalse, "GuestUnreachable", fmt.Sprintf("Failed to reconcile addon %s in the guest cluster: %s", addon.Name, err))
+		}
+		if addon.Enabled {
+			applied = append(applied, addon.Name+" "+addon.Version)
+		}
+	}
+
+	if len(applied) == 0 {
+		meta.RemoveStatusCondition(&cp.Status.Conditions, clusterv1alpha1.ConditionAddonsReady)
+		return ctrl.Result{}, r.Status().Update(ctx, cp)
+	}
+	return ctrl.Result{}, r.UpdateCondition(ctx, cp, metav1.ConditionTrue, "AddonsApplied", fmt.Sprintf("Applied %s", strings.Join(applied, ", ")))
+}
+
+// ApplyAddon server-side applies the objects of the addon to the guest cluster
+func (r *AddonsReconciler) ApplyAddon(ctx context.Context, guest client.Client, addon Addon) error {
+	for _, obj := range addon.Objects {
+		if err := ApplyToGuest(ctx, guest, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveAddon deletes the objects of the addon applied by the operator, the ones created by hand are kept
+func (r *AddonsReconciler) RemoveAddon(ctx context.Context, guest client.Client, addon Addon) error {
+	for _, obj := range addon.Objects {
+		if err := guest.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if obj.GetLabels()["app.kubernetes.io/managed-by"] != GuestFieldOwner {
+			continue
+		}
+		if err := guest.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		r.log.Info("Removed addon object", "addon", addon.Name, "name", obj.GetName(), "namespace", obj.GetNamespace())
+	}
+	return nil
+}
+
+func (r *AddonsReconciler) UpdateCondition(ctx context.Context, cp *clusterv1alpha1.ControlPlane, status metav1.ConditionStatus, reason, message string) error {
+	meta.SetStatusCondition(&cp.Status.Conditions, metav1.Condition{
+		Type:               clusterv1alpha1.ConditionAddonsReady,
+		Status:             status,
+		ObservedGeneration: cp.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if err := r.Status().Update(ctx, cp); err != nil {
+		r.log.Error(err, "failed to update ControlPlane status", "name", cp.Name, "namespace", cp.Namespace)
+		return err
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// It is named as the ControlPlane reconciler already reconciles ControlPlanes.
+func (r *AddonsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("addons").
+		For(&clusterv1alpha1.ControlPlane{}).
+		Complete(r)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+var _ = Describe("Addons controller", Ordered, func() {
+	ctx := context.Background()
+	nsName := "addons"
+	cp := &clusterv1alpha1.ControlPlane{}
+
+	BeforeAll(func() {
+		By("Creating client namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+
+		By("Creating a ControlPlane with every addon, exposed by its Loadbalancer")
+		// The Services of the guest cluster are allocated by the test environment in 10.0.0.0/24
+		cp = &clusterv1alpha1.ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: nsName},
+			Spec: clusterv1alpha1.ControlPlaneSpec{
+				Version: "v1.27.5",
+				Network: clusterv1alpha1.ControlPlaneNetwork{PodCIDRs: []string{"10.200.0.0/16"}, ServiceCIDRs: []string{"10.0.0.0/24"}},
+				PKI:     clusterv1alpha1.PkiSpec{Backend: clusterv1alpha1.PkiBackendNative},
+				Addons: clusterv1alpha1.ControlPlaneAddons{
+					CoreDNS:           clusterv1alpha1.ControlPlaneAddon{Enabled: true},
+					KonnectivityAgent: clusterv1alpha1.ControlPlaneAddon{Enabled: true},
+					KubeProxy:         clusterv1alpha1.ControlPlaneAddon{Enabled: true},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		service := &corev1.Service{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "demo-kube-apiserver", Namespace: nsName}, service)
+		}, timeout, interval).Should(Succeed())
+		service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.40"}}
+		Expect(k8sClient.Status().Update(ctx, service)).Should(Succeed())
+	})
+
+	addonsReady := func() string {
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: "demo", Namespace: nsName}, cp); err != nil {
+			return ""
+		}
+		if c := meta.FindStatusCondition(cp.Status.Conditions, clusterv1alpha1.ConditionAddonsReady); c != nil && c.Status == metav1.ConditionTrue {
+			return c.Message
+		}
+		return ""
+	}
+
+	It("Applies the addons to the guest cluster", func() {
+		Eventually(addonsReady, timeout, interval).Should(Equal("Applied coredns v1.10.1, konnectivity-agent v0.0.37, kube-proxy v1.27.5"))
+
+		By("Checking CoreDNS answers on the tenth IP of the Service range")
+		dns := &corev1.Service{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-dns", Namespace: "kube-system"}, dns)).Should(Succeed())
+		Expect(dns.Spec.ClusterIP).Should(Equal("10.0.0.10"))
+		coredns := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "coredns", Namespace: "kube-system"}, coredns)).Should(Succeed())
+		Expect(coredns.Data["Corefile"]).Should(ContainSubstring("kubernetes cluster.local in-addr.arpa ip6.arpa"))
+
+		By("Checking konnectivity-agent connects to the konnectivity server through the Loadbalancer")
+		agent := &appsv1.DaemonSet{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "konnectivity-agent", Namespace: "kube-system"}, agent)).Should(Succeed())
+		Expect(agent.Spec.Template.Spec.ServiceAccountName).Should(Equal("konnectivity-agent"))
+		Expect(agent.Spec.Template.Spec.Containers[0].Args).Should(ContainElements("--proxy-server-host=10.0.0.40", "--proxy-server-port=8091"))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "konnectivity-agent", Namespace: "kube-system"}, &corev1.ServiceAccount{})).Should(Succeed())
+
+		By("Checking kube-proxy reaches kube-apiserver through the Loadbalancer")
+		proxy := &appsv1.DaemonSet{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-proxy", Namespace: "kube-system"}, proxy)).Should(Succeed())
+		Expect(proxy.Spec.Template.Spec.Containers[0].Image).Should(Equal("registry.k8s.io/kube-proxy:v1.27.5"))
+		proxyConfig := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-proxy", Namespace: "kube-system"}, proxyConfig)).Should(Succeed())
+		Expect(proxyConfig.Data["kubeconfig.conf"]).Should(ContainSubstring("server: https://10.0.0.40:6443"))
+		Expect(proxyConfig.Data["config.conf"]).Should(ContainSubstring(`"clusterCIDR":"10.200.0.0/16"`))
+	})
+
+	It("Upgrades and removes the addons", func() {
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "demo", Namespace: nsName}, cp)).Should(Succeed())
+		cp.Spec.Addons.CoreDNS.Version = "v1.11.1"
+		cp.Spec.Addons.KubeProxy.Enabled = false
+		Expect(k8sClient.Update(ctx, cp)).Should(Succeed())
+
+		Eventually(addonsReady, timeout, interval).Should(Equal("Applied coredns v1.11.1, konnectivity-agent v0.0.37"))
+		coredns := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "coredns", Namespace: "kube-system"}, coredns)).Should(Succeed())
+		Expect(coredns.Spec.Template.Spec.Containers[0].Image).Should(Equal("registry.k8s.io/coredns/coredns:v1.11.1"))
+		err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-proxy", Namespace: "kube-system"}, &appsv1.DaemonSet{})
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+	})
+})
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// DefaultAdmissionPlugins are enabled on kube-apiserver, on top of the ones it enables by default
+var DefaultAdmissionPlugins = []string{"NamespaceLifecycle", "NodeRestriction", "LimitRanger", "ServiceAccount", "DefaultStorageClass", "ResourceQuota"}
+
+// Flags of the components the extra args can override
+func kubeAPIServerDefaultArgs() map[string]string {
+	return map[string]string{
+		"allow-privileged":                "true",
+		"runtime-config":                  "api/all=true",
+		"authorization-mode":              "RBAC,Node",
+		"kubelet-preferred-address-types": "ExternalIP,InternalIP,Hostname",
+	}
+}
+
+func kubeControllerManagerDefaultArgs() map[string]string {
+	return map[string]string{
+		"authentication-skip-lookup":      "true",
+		"allocate-node-cidrs":             "true",
+		"use-service-account-credentials": "true",
+		"cloud-provider":                  "external",
+		"controllers":                     "*,bootstrapsigner,tokencleaner",
+	}
+}
+
+func kubeSchedulerDefaultArgs() map[string]string {
+	return map[string]string{
+		"authentication-skip-lookup": "true",
+	}
+}
+
+// MergeArgs renders the defaults overridden by the extra args and the feature gates as sorted --name=value flags.
+// The managed flags of the component are dropped, the webhook rejects them.
+func MergeArgs(component string, defaults, extraArgs map[string]string, featureGates map[string]bool) []string {
+	args := map[string]string{}
+	for name, value := range defaults {
+		args[name] = value
+	}
+	for name, value := range extraArgs {
+		if !clusterv1alpha1.IsManagedFlag(component, name) {
+			args[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	flags := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		flags = append(flags, fmt.Sprintf("--%s=%s", name, args[name]))
+	}
+
+	if len(featureGates) > 0 {
+		gates := make([]string, 0, len(featureGates))
+		for name, enabled := range featureGates {
+			gates = append(gates, fmt.Sprintf("%s=%t", name, enabled))
+		}
+		sort.Strings(gates)
+		flags = append(flags, "--feature-gates="+strings.Join(gates, ","))
+	}
+	return flags
+}
+
+// KubeAPIServerArgs are the flags of kube-apiserver not set from the rest of the spec
+func KubeAPIServerArgs(kas clusterv1alpha1.KubeAPIServer) []string {
+	defaults := kubeAPIServerDefaultArgs()
+	// kube-apiserver refuses both flags
+	if _, ok := kas.Spec.ExtraArgs["authorization-config"]; ok {
+		delete(defaults, "authorization-mode")
+	}
+	flags := MergeArgs(clusterv1alpha1.ComponentKubeAPIServer, defaults, kas.Spec.ExtraArgs, kas.Spec.FeatureGates)
+
+	disabled := map[string]bool{}
+	for _, name := range kas.Spec.AdmissionPlugins.Disable {
+		disabled[name] = true
+	}
+	enabled := []string{}
+	seen := map[string]bool{}
+	for _, name := range append(append([]string{}, DefaultAdmissionPlugins...), kas.Spec.AdmissionPlugins.Enable...) {
+		if !disabled[name] && !seen[name] {
+			enabled = append(enabled, name)
+			seen[name] = true
+		}
+	}
+	flags = append(flags, "--enable-admission-plugins="+strings.Join(enabled, ","))
+	if len(kas.Spec.AdmissionPlugins.Disable) > 0 {
+		flags = append(flags, "--disable-admission-plugins="+strings.Join(kas.Spec.AdmissionPlugins.Disable, ","))
+	}
+	return flags
+}
+
+// KubeControllerManagerArgs are the flags of kube-controller-manager not set from the rest of the spec
+func KubeControllerManagerArgs(kcm *clusterv1alpha1.KubeControllerManager) []string {
+	return MergeArgs(clusterv1alpha1.ComponentKubeControllerManager, kubeControllerManagerDefaultArgs(), kcm.Spec.ExtraArgs, kcm.Spec.FeatureGates)
+}
+
+// NodeCIDRMaskSizeArgs are the node range flags of kube-controller-manager for the pod ranges of clusterCIDR,
+// the -ipv4 and -ipv6 flags are only accepted with a dual-stack range
+func NodeCIDRMaskSizeArgs(clusterCIDR string, ipv4, ipv6 int32) []string {
+	if ipv4 == 0 {
+		ipv4 = clusterv1alpha1.DefaultNodeCIDRMaskSize
+	}
+	if ipv6 == 0 {
+		ipv6 = clusterv1alpha1.DefaultNodeCIDRMaskSizeIPv6
+	}
+
+	hasIPv4, hasIPv6 := false, false
+	for _, cidr := range strings.Split(clusterCIDR, ",") {
+		if ip, _, err := net.ParseCIDR(cidr); err == nil {
+			hasIPv4 = hasIPv4 || ip.To4() != nil
+			hasIPv6 = hasIPv6 || ip.To4() == nil
+		}
+	}
+
+	switch {
+	case hasIPv4 && hasIPv6:
+		return []string{"--node-cidr-mask-size-ipv4", strconv.Itoa(int(ipv4)), "--node-cidr-mask-size-ipv6", strconv.Itoa(int(ipv6))}
+	case hasIPv6:
+		return []string{"--node-cidr-mask-size", strconv.Itoa(int(ipv6))}
+	default:
+		return []string{"--node-cidr-mask-size", strconv.Itoa(int(ipv4))}
+	}
+}
+
+// KubeSchedulerArgs are the flags of kube-scheduler not set from the rest of the spec
+func KubeSchedulerArgs(ks *clusterv1alpha1.KubeScheduler) []string {
+	return MergeArgs(clusterv1alpha1.ComponentKubeScheduler, kubeSchedulerDefaultArgs(), ks.Spec.ExtraArgs, ks.Spec.FeatureGates)
+}
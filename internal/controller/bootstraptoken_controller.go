@@ -0,0 +1,432 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// Keys of the Secret a BootstrapToken is published to
+const (
+	BootstrapTokenKey       = "token"
+	BootstrapTokenIDKey     = "token-id"
+	BootstrapTokenSecretKey = "token-secret"
+	BootstrapEndpointKey    = "endpoint"
+	BootstrapCACertHashKey  = "ca-cert-hash"
+	BootstrapJoinCommandKey = "join-command"
+)
+
+// Format of the bootstrap tokens, <token-id>.<token-secret>
+var (
+	bootstrapTokenIDRegexp     = regexp.MustCompile(`^[a-z0-9]{6}$`)
+	bootstrapTokenSecretRegexp = regexp.MustCompile(`^[a-z0-9]{16}$`)
+)
+
+const bootstrapTokenCharset = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// BootstrapTokenReconciler reconciles a BootstrapToken object
+type BootstrapTokenReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+
+	// GuestClient returns a client of the guest cluster the token is created in
+	GuestClient GuestClientFunc
+}
+
+func NewBootstrapTokenReconciler(mgr manager.Manager) *BootstrapTokenReconciler {
+	return &BootstrapTokenReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		log:         log.Log.WithName("bootstraptoken-reconciler"),
+		GuestClient: NewGuestClient,
+	}
+}
+
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=bootstraptokens,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=bootstraptokens/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=bootstraptokens/finalizers,verbs=update
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=controlplanes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// The token is generated once and kept in the management Secret, it is written to kube-system of the guest cluster
+// with the admin kubeconfig of the ControlPlane, along with the RBAC letting kubelets request and renew their
+// certificates. The token signs the cluster-info ConfigMap published with the kubeadm configuration of the guest
+// cluster, and the management Secret holds the kubeadm join command using it. An expired token is removed from both
+// clusters, a deleted BootstrapToken revokes it.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
+func (r *BootstrapTokenReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	bt := &clusterv1alpha1.BootstrapToken{}
+	if err := r.Get(ctx, req.NamespacedName, bt); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.log.Error(err, "failed to get BootstrapToken resource", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	if !bt.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.Revoke(ctx, bt)
+	}
+
+	if controllerutil.AddFinalizer(bt, clusterv1alpha1.BootstrapTokenFinalizer) {
+		if err := r.Update(ctx, bt); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	cp := &clusterv1alpha1.ControlPlane{}
+	if err := r.Get(ctx, types.NamespacedName{Name: bt.Spec.ControlPlane, Namespace: req.Namespace}, cp); err != nil {
+		r.log.Info("failed to get ControlPlane for BootstrapToken, requeing", "name", bt.Spec.ControlPlane, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, bt, metav1.ConditionFalse, "ControlPlaneNotFound", fmt.Sprintf("ControlPlane %s not found", bt.Spec.ControlPlane))
+	}
+	cp.SetDefaults()
+
+	guest, err := r.GuestClient(ctx, r.Client, cp)
+	if errors.Is(err, ErrGuestNotReady) {
+		r.log.Info("ControlPlane admin kubeconfig is not issued, requeing", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, bt, metav1.ConditionFalse, "KubeconfigPending", fmt.Sprintf("Waiting for the admin kubeconfig of ControlPlane %s", cp.Name))
+	}
+	if err != nil {
+		r.log.Error(err, "failed to create guest cluster client", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	secretName := CoaleseString(bt.Spec.SecretName, fmt.Sprintf("%s-token", bt.Name))
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: req.Namespace}}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	// The Secret is deleted once the token expires, it must not be one the BootstrapToken did not create
+	if secret.UID != "" && !metav1.IsControlledBy(secret, bt) {
+		r.log.Info("Secret is not controlled by the BootstrapToken", "name", secretName, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdateCondition(ctx, bt, metav1.ConditionFalse, "SecretConflict", fmt.Sprintf("Secret %s already exists and is not controlled by the BootstrapToken", secretName))
+	}
+
+	expiration := bootstrapTokenExpiration(bt)
+	if expiration != nil && !time.Now().Before(expiration.Time) {
+		if err := r.DeleteGuestToken(ctx, guest, bt.Status.TokenID); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := client.IgnoreNotFound(r.Delete(ctx, secret)); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.log.Info("BootstrapToken expired, token removed", "name", req.Name, "namespace", req.Namespace, "token-id", bt.Status.TokenID)
+		bt.Status.ObservedGeneration = bt.Generation
+		bt.Status.SecretName = ""
+		bt.Status.ExpirationTime = expiration
+		return ctrl.Result{}, r.UpdateCondition(ctx, bt, metav1.ConditionFalse, "Expired", fmt.Sprintf("Token expired at %s", expiration.Format(time.RFC3339)))
+	}
+
+	if cp.Status.Endpoint == "" {
+		r.log.Info("ControlPlane endpoint is not registered, requeing", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, bt, metav1.ConditionFalse, "EndpointPending", fmt.Sprintf("Waiting for the Loadbalancer of ControlPlane %s", cp.Name))
+	}
+
+	caSecret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cp.Spec.PKI.CA.Name, Namespace: req.Namespace}, caSecret); err != nil {
+		r.log.Info("CA secret not found, requeing", "name", cp.Spec.PKI.CA.Name, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, bt, metav1.ConditionFalse, "CAPending", fmt.Sprintf("Waiting for CA secret %s", cp.Spec.PKI.CA.Name))
+	}
+	caCertHash, err := CACertHash(caSecret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The token is kept in the management Secret, a new one is generated when it is lost
+	tokenID, tokenSecret := string(secret.Data[BootstrapTokenIDKey]), string(secret.Data[BootstrapTokenSecretKey])
+	if !bootstrapTokenIDRegexp.MatchString(tokenID) || !bootstrapTokenSecretRegexp.MatchString(tokenSecret) {
+		if tokenID, err = randomString(bootstrapTokenCharset, 6); err != nil {
+			return ctrl.Result{}, err
+		}
+		if tokenSecret, err = randomString(bootstrapTokenCharset, 16); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.log.Info("Generated bootstrap token", "name", req.Name, "namespace", req.Namespace, "token-id", tokenID)
+	}
+	if bt.Status.TokenID != "" && bt.Status.TokenID != tokenID {
+		if err := r.DeleteGuestToken(ctx, guest, bt.Status.TokenID); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	err = r.CreateOrPatch(ctx, secret, bt, func() error {
+		secret.Data = map[string][]byte{
+			BootstrapTokenKey:       []byte(tokenID + "." + tokenSecret),
+			BootstrapTokenIDKey:     []byte(tokenID),
+			BootstrapTokenSecretKey: []byte(tokenSecret),
+			CACertKey:               caSecret.Data[corev1.TLSCertKey],
+			BootstrapEndpointKey:    []byte(cp.Status.Endpoint),
+			BootstrapCACertHashKey:  []byte(caCertHash),
+		}
+		// kubeadm join authenticates with the token and discovers the cluster through the signature of cluster-info
+		if hasUsage(bt, clusterv1alpha1.BootstrapTokenUsageAuthentication) && hasUsage(bt, clusterv1alpha1.BootstrapTokenUsageSigning) {
+			secret.Data[BootstrapJoinCommandKey] = []byte(KubeadmJoinCommand(cp.Status.Endpoint, tokenID+"."+tokenSecret, caCertHash))
+		}
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.ReconcileGuestToken(ctx, guest, bt, tokenID, tokenSecret, expiration); err != nil {
+		r.log.Error(err, "failed to create bootstrap token in the guest cluster", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdateCondition(ctx, bt, metav1.ConditionFalse, "GuestUnreachable", fmt.Sprintf("Failed to create the token in the guest cluster: %s", err))
+	}
+	if err := ReconcileKubeadm(ctx, guest, *cp, caSecret.Data[corev1.TLSCertKey]); err != nil {
+		r.log.Error(err, "failed to publish the kubeadm configuration in the guest cluster", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdateCondition(ctx, bt, metav1.ConditionFalse, "GuestUnreachable", fmt.Sprintf("Failed to publish the kubeadm configuration in the guest cluster: %s", err))
+	}
+	if err := SignClusterInfo(ctx, guest, tokenID, tokenSecret, hasUsage(bt, clusterv1alpha1.BootstrapTokenUsageSigning)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	bt.Status.ObservedGeneration = bt.Generation
+	bt.Status.TokenID = tokenID
+	bt.Status.SecretName = secretName
+	bt.Status.ExpirationTime = expiration
+
+	result := ctrl.Result{}
+	if expiration != nil {
+		result.RequeueAfter = time.Until(expiration.Time) + time.Second
+	}
+	return result, r.UpdateCondition(ctx, bt, metav1.ConditionTrue, "Created", fmt.Sprintf("Token %s created in the guest cluster, published to secret %s", tokenID, secretName))
+}
+
+// ReconcileGuestToken writes the token and the RBAC of the kubelet TLS bootstrapping to the guest cluster
+func (r *BootstrapTokenReconciler) ReconcileGuestToken(ctx context.Context, guest client.Client, bt *clusterv1alpha1.BootstrapToken, tokenID, tokenSecret string, expiration *metav1.Time) error {
+	token := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "bootstrap-token-" + tokenID, Namespace: metav1.NamespaceSystem}}
+	_, err := controllerutil.CreateOrPatch(ctx, guest, token, func() error {
+		token.Labels = labels("bootstrap-token", bt.Name, nil)
+		token.Type = corev1.SecretTypeBootstrapToken
+		token.Data = map[string][]byte{
+			"description":  []byte(CoaleseString(bt.Spec.Description, fmt.Sprintf("Bootstrap token %s/%s", bt.Namespace, bt.Name))),
+			"token-id":     []byte(tokenID),
+			"token-secret": []byte(tokenSecret),
+		}
+		if expiration != nil {
+			token.Data["expiration"] = []byte(expiration.UTC().Format(time.RFC3339))
+		}
+		for _, usage := range bt.Spec.Usages {
+			token.Data["usage-bootstrap-"+string(usage)] = []byte("true")
+		}
+		if len(bt.Spec.Groups) > 0 {
+			token.Data["auth-extra-groups"] = []byte(strings.Join(bt.Spec.Groups, ","))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range BootstrapClusterRoleBindings() {
+		crb := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: binding.Name}}
+		desired := binding
+		if _, err := controllerutil.CreateOrPatch(ctx, guest, crb, func() error {
+			crb.Subjects = desired.Subjects
+			crb.RoleRef = desired.RoleRef
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BootstrapClusterRoleBindings let the bootstrap tokens create the certificate signing requests of kubelets,
+// and approve them along with the renewals of the nodes
+func BootstrapClusterRoleBindings() []rbacv1.ClusterRoleBinding {
+	binding := func(name, group, role string) rbacv1.ClusterRoleBinding {
+		return rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: group, APIGroup: rbacv1.GroupName}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: role, APIGroup: rbacv1.GroupName},
+		}
+	}
+	return []rbacv1.ClusterRoleBinding{
+		binding("create-csrs-for-bootstrapping", "system:bootstrappers", "system:node-bootstrapper"),
+		binding("auto-approve-csrs-for-group", "system:bootstrappers", "system:certificates.k8s.io:certificatesigningrequests:nodeclient"),
+		binding("auto-approve-renewals-for-nodes", "system:nodes", "system:certificates.k8s.io:certificatesigningrequests:selfnodeclient"),
+	}
+}
+
+// DeleteGuestToken removes the token and its signature of cluster-info from the guest cluster, the RBAC is shared
+// by the tokens and kept
+func (r *BootstrapTokenReconciler) DeleteGuestToken(ctx context.Context, guest client.Client, tokenID string) error {
+	if tokenID == "" {
+		return nil
+	}
+	token := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "bootstrap-token-" + tokenID, Namespace: metav1.NamespaceSystem}}
+	if err := guest.Delete(ctx, token); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := SignClusterInfo(ctx, guest, tokenID, "", false); err != nil {
+		return err
+	}
+	r.log.Info("Revoked bootstrap token", "token-id", tokenID)
+	return nil
+}
+
+// Revoke deletes the token from the guest cluster then releases the BootstrapToken.
+// Nothing is left to revoke once the ControlPlane is deleted.
+func (r *BootstrapTokenReconciler) Revoke(ctx context.Context, bt *clusterv1alpha1.BootstrapToken) error {
+	if !controllerutil.ContainsFinalizer(bt, clusterv1alpha1.BootstrapTokenFinalizer) {
+		return nil
+	}
+
+	cp := &clusterv1alpha1.ControlPlane{}
+	err := r.Get(ctx, types.NamespacedName{Name: bt.Spec.ControlPlane, Namespace: bt.Namespace}, cp)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && cp.DeletionTimestamp.IsZero() && bt.Status.TokenID != "" {
+		guest, err := r.GuestClient(ctx, r.Client, cp)
+		if err != nil && !errors.Is(err, ErrGuestNotReady) {
+			r.log.Error(err, "failed to create guest cluster client", "name", cp.Name, "namespace", cp.Namespace)
+			return err
+		}
+		if err == nil {
+			if err := r.DeleteGuestToken(ctx, guest, bt.Status.TokenID); err != nil {
+				r.log.Error(err, "failed to revoke bootstrap token", "name", bt.Name, "namespace", bt.Namespace)
+				return err
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(bt, clusterv1alpha1.BootstrapTokenFinalizer)
+	return r.Update(ctx, bt)
+}
+
+// hasUsage returns whether the token is allowed the usage
+func hasUsage(bt *clusterv1alpha1.BootstrapToken, usage clusterv1alpha1.BootstrapTokenUsage) bool {
+	for _, u := range bt.Spec.Usages {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
+
+// bootstrapTokenExpiration returns the expiry of the token, nil when it never expires
+func bootstrapTokenExpiration(bt *clusterv1alpha1.BootstrapToken) *metav1.Time {
+	if bt.Spec.TTL.Duration <= 0 {
+		return nil
+	}
+	expiration := metav1.NewTime(bt.CreationTimestamp.Add(bt.Spec.TTL.Duration))
+	return &expiration
+}
+
+// randomString returns n characters of the charset picked with crypto/rand
+func randomString(charset string, n int) (string, error) {
+	b := make([]byte, n)
+	for i := range b {
+		k, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[k.Int64()]
+	}
+	return string(b), nil
+}
+
+func (r *BootstrapTokenReconciler) UpdateCondition(ctx context.Context, bt *clusterv1alpha1.BootstrapToken, status metav1.ConditionStatus, reason, message string) error {
+	meta.SetStatusCondition(&bt.Status.Conditions, metav1.Condition{
+		Type:               clusterv1alpha1.ConditionReady,
+		Status:             status,
+		ObservedGeneration: bt.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if err := r.Status().Update(ctx, bt); err != nil {
+		r.log.Error(err, "failed to update BootstrapToken status", "name", bt.Name, "namespace", bt.Namespace)
+		return err
+	}
+	return nil
+}
+
+// RequestsForControlPlane maps a ControlPlane to the BootstrapTokens of its guest cluster
+func (r *BootstrapTokenReconciler) RequestsForControlPlane(obj client.Object) []reconcile.Request {
+	bts := &clusterv1alpha1.BootstrapTokenList{}
+	if err := r.List(context.Background(), bts, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.log.Error(err, "failed to list BootstrapTokens", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, bt := range bts.Items {
+		if bt.Spec.ControlPlane == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: bt.Name, Namespace: bt.Namespace}})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BootstrapTokenReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1alpha1.BootstrapToken{}).
+		Owns(&corev1.Secret{}).
+		Watches(&source.Kind{Type: &clusterv1alpha1.ControlPlane{}}, handler.EnqueueRequestsFromMapFunc(r.RequestsForControlPlane)).
+		Complete(r)
+}
+
+func (r *BootstrapTokenReconciler) CreateOrPatch(ctx context.Context, obj client.Object, owner metav1.Object, f controllerutil.MutateFn) error {
+	if err := ctrl.SetControllerReference(owner, obj, r.Scheme); err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to set controller reference on %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+
+	result, err := controllerutil.CreateOrPatch(ctx, r.Client, obj, f)
+	if err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to create or patch %s", obj.GetObjectKind().GroupVersionKind().Kind), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+	r.log.Info(fmt.Sprintf("%s/%s was %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), result))
+	return nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+var _ = Describe("BootstrapToken controller", Ordered, func() {
+	ctx := context.Background()
+	nsName := "bootstrap-token"
+
+	BeforeAll(func() {
+		By("Creating client namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+
+		By("Creating a ControlPlane exposed by its Loadbalancer")
+		cp := &clusterv1alpha1.ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: nsName},
+			Spec: clusterv1alpha1.ControlPlaneSpec{
+				Version: "v1.27.5",
+				PKI:     clusterv1alpha1.PkiSpec{Backend: clusterv1alpha1.PkiBackendNative},
+			},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		service := &corev1.Service{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "demo-kube-apiserver", Namespace: nsName}, service)
+		}, timeout, interval).Should(Succeed())
+		service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.30"}}
+		Expect(k8sClient.Status().Update(ctx, service)).Should(Succeed())
+	})
+
+	It("Creates the token in the guest cluster and revokes it on deletion", func() {
+		bt := &clusterv1alpha1.BootstrapToken{
+			ObjectMeta: metav1.ObjectMeta{Name: "workers", Namespace: nsName},
+			Spec: clusterv1alpha1.BootstrapTokenSpec{
+				ControlPlane: "demo",
+				TTL:          metav1.Duration{Duration: time.Hour},
+				Usages:       []clusterv1alpha1.BootstrapTokenUsage{clusterv1alpha1.BootstrapTokenUsageAuthentication, clusterv1alpha1.BootstrapTokenUsageSigning},
+				Groups:       []string{"system:bootstrappers:workers"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, bt)).Should(Succeed())
+
+		By("Waiting for the token to be created")
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "workers", Namespace: nsName}, bt)
+			return err == nil && meta.IsStatusConditionTrue(bt.Status.Conditions, clusterv1alpha1.ConditionReady)
+		}, timeout, interval).Should(BeTrue())
+		Expect(bt.Status.TokenID).Should(MatchRegexp(`^[a-z0-9]{6}$`))
+		Expect(bt.Status.SecretName).Should(Equal("workers-token"))
+		Expect(bt.Status.ExpirationTime).ShouldNot(BeNil())
+		Expect(bt.Finalizers).Should(ContainElement(clusterv1alpha1.BootstrapTokenFinalizer))
+
+		By("Checking the token is published with the CA")
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "workers-token", Namespace: nsName}, secret)).Should(Succeed())
+		Expect(string(secret.Data["token"])).Should(MatchRegexp(`^` + bt.Status.TokenID + `\.[a-z0-9]{16}$`))
+		ca := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "demo-ca", Namespace: nsName}, ca)).Should(Succeed())
+		Expect(secret.Data["ca.crt"]).Should(Equal(ca.Data["tls.crt"]))
+
+		By("Checking the token and the RBAC of the guest cluster")
+		token := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "bootstrap-token-" + bt.Status.TokenID, Namespace: "kube-system"}, token)).Should(Succeed())
+		Expect(token.Type).Should(Equal(corev1.SecretTypeBootstrapToken))
+		Expect(token.Data["token-secret"]).Should(Equal(secret.Data["token-secret"]))
+		Expect(string(token.Data["usage-bootstrap-authentication"])).Should(Equal("true"))
+		Expect(string(token.Data["usage-bootstrap-signing"])).Should(Equal("true"))
+		Expect(string(token.Data["auth-extra-groups"])).Should(Equal("system:bootstrappers:workers"))
+		Expect(string(token.Data["expiration"])).Should(Equal(bt.Status.ExpirationTime.UTC().Format(time.RFC3339)))
+		for _, name := range []string{"create-csrs-for-bootstrapping", "auto-approve-csrs-for-group", "auto-approve-renewals-for-nodes"} {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name}, &rbacv1.ClusterRoleBinding{})).Should(Succeed(), name)
+		}
+
+		By("Checking the configuration kubeadm join discovers")
+		clusterInfo := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "cluster-info", Namespace: "kube-public"}, clusterInfo)).Should(Succeed())
+		Expect(clusterInfo.Data["kubeconfig"]).Should(ContainSubstring("server: https://10.0.0.30:6443"))
+		Expect(clusterInfo.Data).Should(HaveKeyWithValue("jws-kubeconfig-"+bt.Status.TokenID,
+			DetachedSignature(clusterInfo.Data["kubeconfig"], bt.Status.TokenID, string(secret.Data["token-secret"]))))
+		kubeadmConfig := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kubeadm-config", Namespace: "kube-system"}, kubeadmConfig)).Should(Succeed())
+		Expect(kubeadmConfig.Data["ClusterConfiguration"]).Should(ContainSubstring(`"controlPlaneEndpoint":"10.0.0.30:6443"`))
+		kubeletConfig := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kubelet-config", Namespace: "kube-system"}, kubeletConfig)).Should(Succeed())
+		Expect(kubeletConfig.Data["kubelet"]).Should(ContainSubstring(`"clusterDNS":["10.32.0.10"]`))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kubeadm:bootstrap-signer-clusterinfo", Namespace: "kube-public"}, &rbacv1.RoleBinding{})).Should(Succeed())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kubeadm:get-nodes"}, &rbacv1.ClusterRoleBinding{})).Should(Succeed())
+
+		By("Checking the kubeadm join command")
+		hash, err := CACertHash(ca.Data["tls.crt"])
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(string(secret.Data["ca-cert-hash"])).Should(Equal(hash))
+		Expect(string(secret.Data["join-command"])).Should(Equal("kubeadm join 10.0.0.30:6443 --token " + string(secret.Data["token"]) + " --discovery-token-ca-cert-hash " + hash))
+
+		By("Revoking the token when the BootstrapToken is deleted")
+		Expect(k8sClient.Delete(ctx, bt)).Should(Succeed())
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "bootstrap-token-" + bt.Status.TokenID, Namespace: "kube-system"}, token)
+			return apierrors.IsNotFound(err)
+		}, timeout, interval).Should(BeTrue())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "cluster-info", Namespace: "kube-public"}, clusterInfo)).Should(Succeed())
+		Expect(clusterInfo.Data).ShouldNot(HaveKey("jws-kubeconfig-" + bt.Status.TokenID))
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "workers", Namespace: nsName}, bt)
+			return apierrors.IsNotFound(err)
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("Removes an expired token", func() {
+		bt := &clusterv1alpha1.BootstrapToken{
+			ObjectMeta: metav1.ObjectMeta{Name: "expired", Namespace: nsName},
+			Spec: clusterv1alpha1.BootstrapTokenSpec{
+				ControlPlane: "demo",
+				TTL:          metav1.Duration{Duration: time.Second},
+				Usages:       []clusterv1alpha1.BootstrapTokenUsage{clusterv1alpha1.BootstrapTokenUsageAuthentication},
+			},
+		}
+		Expect(k8sClient.Create(ctx, bt)).Should(Succeed())
+
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "expired", Namespace: nsName}, bt); err != nil {
+				return ""
+			}
+			if c := meta.FindStatusCondition(bt.Status.Conditions, clusterv1alpha1.ConditionReady); c != nil {
+				return c.Reason
+			}
+			return ""
+		}, timeout, interval).Should(Equal("Expired"))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "expired-token", Namespace: nsName}, &corev1.Secret{})).ShouldNot(Succeed())
+		if bt.Status.TokenID != "" {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "bootstrap-token-" + bt.Status.TokenID, Namespace: "kube-system"}, &corev1.Secret{})).ShouldNot(Succeed())
+		}
+	})
+
+	It("Leaves a Secret it does not control untouched", func() {
+		foreign := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "foreign", Namespace: nsName},
+			Data:       map[string][]byte{"key": []byte("value")},
+		}
+		Expect(k8sClient.Create(ctx, foreign)).Should(Succeed())
+
+		bt := &clusterv1alpha1.BootstrapToken{
+			ObjectMeta: metav1.ObjectMeta{Name: "conflict", Namespace: nsName},
+			Spec: clusterv1alpha1.BootstrapTokenSpec{
+				ControlPlane: "demo",
+				SecretName:   "foreign",
+				TTL:          metav1.Duration{Duration: time.Second},
+				Usages:       []clusterv1alpha1.BootstrapTokenUsage{clusterv1alpha1.BootstrapTokenUsageAuthentication},
+			},
+		}
+		Expect(k8sClient.Create(ctx, bt)).Should(Succeed())
+
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "conflict", Namespace: nsName}, bt); err != nil {
+				return ""
+			}
+			if c := meta.FindStatusCondition(bt.Status.Conditions, clusterv1alpha1.ConditionReady); c != nil {
+				return c.Reason
+			}
+			return ""
+		}, timeout, interval).Should(Equal("SecretConflict"))
+		Consistently(func() map[string][]byte {
+			secret := &corev1.Secret{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "foreign", Namespace: nsName}, secret); err != nil {
+				return nil
+			}
+			return secret.Data
+		}, 2*interval, interval).Should(Equal(foreign.Data))
+	})
+})
@@ -19,9 +19,14 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -61,10 +66,10 @@ func NewControlPlaneReconciler(mgr manager.Manager) *ControlPlaneReconciler {
 //+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=controlplanes/finalizers,verbs=update
 //+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=loadbalancers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=pkis,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcds,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=kubeapiservers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=kubecontrollermanagers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=kubeschedulers,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=kubeschedulers,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -87,6 +92,10 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
+	// Defaults are set by the mutating webhook, they are applied again for ControlPlanes
+	// created while webhooks were disabled
+	cp.SetDefaults()
+
 	// Create loadbalancer
 	lb := &clusterv1alpha1.Loadbalancer{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}
 
@@ -106,6 +115,23 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	r.log.Info(fmt.Sprintf("Loadbalancer was: %s", result))
 
+	etcdName := ControlPlaneEtcdName(*cp)
+
+	// Etcd certificates must be valid for every Etcd of the ControlPlane, including the ones being restored
+	etcdDNSNames := EtcdDNSNames(etcdName, req.Namespace)
+	if cp.Spec.Etcd != nil {
+		etcds := &clusterv1alpha1.EtcdList{}
+		if err := r.List(ctx, etcds, client.InNamespace(req.Namespace)); err != nil {
+			r.log.Error(err, "failed to list Etcd", "name", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{}, err
+		}
+		for i := range etcds.Items {
+			if etcds.Items[i].Name != etcdName && metav1.IsControlledBy(&etcds.Items[i], cp) {
+				etcdDNSNames = append(etcdDNSNames, EtcdDNSNames(etcds.Items[i].Name, req.Namespace)...)
+			}
+		}
+	}
+
 	// Create PKI
 	pki := &clusterv1alpha1.Pki{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}
 	if err := ctrl.SetControllerReference(cp, pki, r.Scheme); err != nil {
@@ -116,6 +142,13 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	result, err = controllerutil.CreateOrPatch(ctx, r.Client, pki, func() error {
 		pki.Spec = cp.Spec.PKI
 		pki.Spec.ControlPlaneIP = lb.Status.IP
+		pki.Spec.ControlPlaneIPs = lb.Status.IPs
+		// kube-apiserver is reached through the kubernetes Service of the guest cluster
+		pki.Spec.KubeAPIServer.IPAddresses = appendMissing(cp.Spec.PKI.KubeAPIServer.IPAddresses, cp.Spec.Network.KubernetesServiceIPs()...)
+		pki.Spec.KubeAPIServer.DNSNames = appendMissing(cp.Spec.PKI.KubeAPIServer.DNSNames, cp.Spec.Network.KubernetesServiceDNSNames()...)
+		if cp.Spec.Etcd != nil {
+			pki.Spec.ETCD.DNSNames = append(append([]string{}, cp.Spec.PKI.ETCD.DNSNames...), etcdDNSNames...)
+		}
 		return nil
 	})
 	if err != nil {
@@ -125,6 +158,40 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	r.log.Info(fmt.Sprintf("PKI was: %s", result))
 
+	// Create Etcd
+	var etcd *clusterv1alpha1.Etcd
+	if cp.Spec.Etcd != nil {
+		etcd = &clusterv1alpha1.Etcd{ObjectMeta: metav1.ObjectMeta{Name: etcdName, Namespace: req.Namespace}}
+		if err := ctrl.SetControllerReference(cp, etcd, r.Scheme); err != nil {
+			r.log.Error(err, "failed to set controller reference on Etcd", "name", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{}, err
+		}
+
+		result, err = controllerutil.CreateOrPatch(ctx, r.Client, etcd, func() error {
+			// Keep the snapshot a restored Etcd was seeded from
+			snapshot := etcd.Spec.Snapshot
+			etcd.Spec = *cp.Spec.Etcd
+			if etcd.Spec.Snapshot == nil {
+				etcd.Spec.Snapshot = snapshot
+			}
+			etcd.Spec.TLS.ServerSecretName = CoaleseString(cp.Spec.Etcd.TLS.ServerSecretName, cp.Spec.PKI.ETCD.Server)
+			etcd.Spec.TLS.PeerSecretName = CoaleseString(cp.Spec.Etcd.TLS.PeerSecretName, cp.Spec.PKI.ETCD.Peer)
+			return nil
+		})
+		if err != nil {
+			r.log.Error(err, "failed to create or patch Etcd", "name", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{}, err
+		}
+		r.log.Info(fmt.Sprintf("Etcd was: %s", result))
+	}
+
+	// Versions of the components during upgrades
+	versions, err := r.PlanUpgrade(ctx, cp)
+	if err != nil {
+		r.log.Error(err, "failed to plan ControlPlane upgrade", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
 	// Create ApiServer
 
 	kas := &clusterv1alpha1.KubeAPIServer{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}
@@ -134,6 +201,7 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	result, err = controllerutil.CreateOrPatch(ctx, r.Client, kas, func() error {
+		kmsPlugin := KMSPluginInUse(kas.Spec.Encryption, kas.Status.Encryption)
 		kas.Spec = cp.Spec.KubeApiServer
 
 		if kas.Spec.Deployment.Labels == nil {
@@ -144,8 +212,28 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			kas.Spec.Deployment.Labels[k] = v
 		}
 
+		// kube-apiserver advertises an IP of the family of the primary Service range
 		kas.Spec.Options.AdvertiseAddress = lb.Status.IP
-		kas.Spec.Version = CoaleseString(cp.Spec.KubeApiServer.Version, cp.Spec.Version)
+		if len(lb.Status.IPs) > 0 && len(cp.Spec.Network.ServiceCIDRs) > 0 {
+			kas.Spec.Options.AdvertiseAddress = IPOfFamily(lb.Status.IPs, cp.Spec.Network.ServiceCIDRs[0])
+		}
+		kas.Spec.Version = CoaleseString(cp.Spec.KubeApiServer.Version, versions.KubeAPIServer)
+
+		// The Secrets are rewritten with the admin kubeconfig during a key rotation
+		if encryption := cp.Spec.KubeApiServer.Encryption; encryption != nil {
+			kas.Spec.Encryption = encryption.DeepCopy()
+			kas.Spec.Encryption.AdminKubeconfigSecretName = CoaleseString(encryption.AdminKubeconfigSecretName, fmt.Sprintf("%s-kubeconfig", cp.Spec.PKI.Admin.Name))
+			// The plugin is kept until the rotation to another provider removes its key
+			if kmsPlugin != nil && (encryption.KMS == nil || encryption.KMS.Name != kmsPlugin.Name) {
+				r.log.Info("KMS plugin still decrypts Secrets, keeping it", "name", req.Name, "namespace", req.Namespace, "plugin", kmsPlugin.Name)
+				kas.Spec.Encryption.KMS = kmsPlugin
+			}
+		}
+
+		if etcd != nil {
+			kas.Spec.ETCDservers = EtcdEndpoints(*etcd)
+			kas.Spec.TLS.ETCDClientSecretName = CoaleseString(cp.Spec.KubeApiServer.TLS.ETCDClientSecretName, cp.Spec.PKI.ETCD.Client)
+		}
 
 		return nil
 	})
@@ -167,7 +255,7 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	result, err = controllerutil.CreateOrPatch(ctx, r.Client, kcm, func() error {
 		kcm.Spec = cp.Spec.KubeControllerManager
-		kcm.Spec.Version = CoaleseString(cp.Spec.KubeControllerManager.Version, cp.Spec.Version)
+		kcm.Spec.Version = CoaleseString(cp.Spec.KubeControllerManager.Version, versions.KubeControllerManager)
 		return nil
 	})
 	if err != nil {
@@ -188,7 +276,7 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	result, err = controllerutil.CreateOrPatch(ctx, r.Client, ks, func() error {
 		ks.Spec = cp.Spec.KubeScheduler
-		ks.Spec.Version = CoaleseString(cp.Spec.KubeScheduler.Version, cp.Spec.Version)
+		ks.Spec.Version = CoaleseString(cp.Spec.KubeScheduler.Version, versions.KubeScheduler)
 		return nil
 	})
 	if err != nil {
@@ -197,16 +285,107 @@ func (r *ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 	r.log.Info(fmt.Sprintf("KubeScheduler was: %s", result))
 
+	///
+	/// Status
+	///
+
+	if err := r.UpdateStatus(ctx, cp, lb, pki, etcd, kas, kcm, ks); err != nil {
+		r.log.Error(err, "failed to update ControlPlane status", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// UpdateStatus aggregates the status of every component into the ControlPlane conditions
+func (r *ControlPlaneReconciler) UpdateStatus(ctx context.Context, cp *clusterv1alpha1.ControlPlane, lb *clusterv1alpha1.Loadbalancer, pki *clusterv1alpha1.Pki, etcd *clusterv1alpha1.Etcd, kas *clusterv1alpha1.KubeAPIServer, kcm *clusterv1alpha1.KubeControllerManager, ks *clusterv1alpha1.KubeScheduler) error {
+	cp.Status.ObservedGeneration = cp.Generation
+
+	cp.Status.Endpoint = ""
+	if lb.Status.IP != "" {
+		cp.Status.Endpoint = "https://" + net.JoinHostPort(lb.Status.IP, strconv.Itoa(int(lb.Spec.Port)))
+	}
+
+	cp.Status.KubeconfigSecretRef = nil
+	if pki.Status.AdminKubeconfig != "" {
+		cp.Status.KubeconfigSecretRef = &corev1.LocalObjectReference{Name: pki.Status.AdminKubeconfig}
+	}
+
+	lbCondition := metav1.Condition{Type: clusterv1alpha1.ConditionLoadbalancerReady, Status: metav1.ConditionFalse, Reason: "WaitingForIP", Message: "Loadbalancer has no ingress IP yet"}
+	if lb.Status.IP != "" {
+		lbCondition.Status = metav1.ConditionTrue
+		lbCondition.Reason = "IPAllocated"
+		lbCondition.Message = fmt.Sprintf("Loadbalancer IP is %s", strings.Join(appendMissing([]string{lb.Status.IP}, lb.Status.IPs...), ", "))
+	}
+
+	pkiCondition := metav1.Condition{Type: clusterv1alpha1.ConditionPKIReady, Status: metav1.ConditionFalse, Reason: "CertificatesPending", Message: "Waiting for certificates to be issued"}
+	if pki.Status.Ready {
+		pkiCondition.Status = metav1.ConditionTrue
+		pkiCondition.Reason = "CertificatesIssued"
+		pkiCondition.Message = "All certificates are issued"
+	} else if ca := meta.FindStatusCondition(pki.Status.Conditions, clusterv1alpha1.ConditionCAValid); ca != nil && ca.Status == metav1.ConditionFalse {
+		pkiCondition.Reason = ca.Reason
+		pkiCondition.Message = ca.Message
+	}
+
+	conditions := []metav1.Condition{
+		lbCondition,
+		pkiCondition,
+		componentCondition(clusterv1alpha1.ConditionAPIServerAvailable, kas.Generation, kas.Status.ObservedGeneration, kas.Status.Conditions),
+		componentCondition(clusterv1alpha1.ConditionControllerManagerAvailable, kcm.Generation, kcm.Status.ObservedGeneration, kcm.Status.Conditions),
+		componentCondition(clusterv1alpha1.ConditionSchedulerAvailable, ks.Generation, ks.Status.ObservedGeneration, ks.Status.Conditions),
+	}
+
+	if etcd != nil {
+		conditions = append(conditions, componentCondition(clusterv1alpha1.ConditionEtcdAvailable, etcd.Generation, etcd.Status.ObservedGeneration, etcd.Status.Conditions))
+	} else {
+		meta.RemoveStatusCondition(&cp.Status.Conditions, clusterv1alpha1.ConditionEtcdAvailable)
+	}
+
+	notReady := []string{}
+	for _, c := range conditions {
+		if c.Status != metav1.ConditionTrue {
+			notReady = append(notReady, c.Type)
+		}
+		c.ObservedGeneration = cp.Generation
+		meta.SetStatusCondition(&cp.Status.Conditions, c)
+	}
+
+	readyCondition := metav1.Condition{Type: clusterv1alpha1.ConditionReady, Status: metav1.ConditionTrue, ObservedGeneration: cp.Generation, Reason: "ControlPlaneReady", Message: "All components are ready"}
+	if len(notReady) > 0 {
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = "ComponentsNotReady"
+		readyCondition.Message = fmt.Sprintf("Waiting for %s", strings.Join(notReady, ", "))
+	}
+	meta.SetStatusCondition(&cp.Status.Conditions, readyCondition)
+
+	return r.Status().Update(ctx, cp)
+}
+
+// ControlPlaneEtcdName returns the name of the Etcd used by the ControlPlane
+func ControlPlaneEtcdName(cp clusterv1alpha1.ControlPlane) string {
+	return CoaleseString(cp.Annotations[clusterv1alpha1.EtcdNameAnnotation], cp.Name)
+}
+
+// componentCondition mirrors the Available condition of a component resource into a ControlPlane condition
+func componentCondition(conditionType string, generation, observedGeneration int64, conditions []metav1.Condition) metav1.Condition {
+	available := meta.FindStatusCondition(conditions, clusterv1alpha1.ConditionAvailable)
+	if available == nil || observedGeneration != generation {
+		return metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: "Progressing", Message: "Waiting for the component to be reconciled"}
+	}
+
+	return metav1.Condition{Type: conditionType, Status: available.Status, Reason: available.Reason, Message: available.Message}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1alpha1.ControlPlane{}).
 		Owns(&clusterv1alpha1.Pki{}).
+		Owns(&clusterv1alpha1.Etcd{}).
 		Owns(&clusterv1alpha1.KubeAPIServer{}).
 		Owns(&clusterv1alpha1.KubeControllerManager{}).
+		Owns(&clusterv1alpha1.KubeScheduler{}).
 		Owns(&clusterv1alpha1.Loadbalancer{}).
 		Complete(r)
 }
@@ -23,8 +23,10 @@ import (
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
 )
@@ -189,4 +191,149 @@ var _ = Describe("controlplane controller", Ordered, func() {
 
 	})
 
+	It("Reports status conditions", func() {
+		cp := &clusterv1alpha1.ControlPlane{}
+
+		By("Aggregating component conditions")
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "client-a", Namespace: clientNamespace}, cp)
+			if err != nil || cp.Status.ObservedGeneration != cp.Generation {
+				return false
+			}
+
+			for _, t := range []string{
+				clusterv1alpha1.ConditionLoadbalancerReady,
+				clusterv1alpha1.ConditionPKIReady,
+				clusterv1alpha1.ConditionAPIServerAvailable,
+				clusterv1alpha1.ConditionControllerManagerAvailable,
+				clusterv1alpha1.ConditionSchedulerAvailable,
+			} {
+				if meta.FindStatusCondition(cp.Status.Conditions, t) == nil {
+					return false
+				}
+			}
+			return true
+		}, timeout, interval).Should(BeTrue())
+
+		By("Not being ready without a loadbalancer IP")
+		Expect(meta.IsStatusConditionFalse(cp.Status.Conditions, clusterv1alpha1.ConditionLoadbalancerReady)).Should(BeTrue())
+		Expect(meta.IsStatusConditionFalse(cp.Status.Conditions, clusterv1alpha1.ConditionReady)).Should(BeTrue())
+		Expect(cp.Status.Endpoint).Should(BeEmpty())
+	})
+
+	It("Adds the kubernetes Service of the network to the kube-apiserver certificate", func() {
+		cp := &clusterv1alpha1.ControlPlane{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "client-a", Namespace: clientNamespace}, cp)).Should(Succeed())
+		cp.Spec.Network = clusterv1alpha1.ControlPlaneNetwork{ServiceCIDRs: []string{"10.96.0.0/12"}, DNSDomain: "client-a.local"}
+		Expect(k8sClient.Update(ctx, cp)).Should(Succeed())
+
+		pki := &clusterv1alpha1.Pki{}
+		Eventually(func() []string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "client-a", Namespace: clientNamespace}, pki); err != nil {
+				return nil
+			}
+			return pki.Spec.KubeAPIServer.DNSNames
+		}, timeout, interval).Should(ContainElement("kubernetes.default.svc.client-a.local"))
+		Expect(pki.Spec.KubeAPIServer.IPAddresses).Should(Equal([]string{"127.0.0.1", "10.0.0.1", "10.32.0.1", "10.96.0.1"}))
+		Expect(pki.Spec.KubeAPIServer.DNSNames).Should(HaveLen(7))
+	})
+
+	It("Upgrades the components one after the other when the version changes during the first rollout", func() {
+		By("Creating a ControlPlane in its own namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "upgrade"}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+		key := types.NamespacedName{Name: "upgrade", Namespace: "upgrade"}
+		cp := &clusterv1alpha1.ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec:       clusterv1alpha1.ControlPlaneSpec{Version: "v1.26.1"},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		kas := &clusterv1alpha1.KubeAPIServer{}
+		kcm := &clusterv1alpha1.KubeControllerManager{}
+		ks := &clusterv1alpha1.KubeScheduler{}
+		specVersions := func() []string {
+			versions := []string{"", "", ""}
+			if err := k8sClient.Get(ctx, key, kas); err == nil {
+				versions[0] = kas.Spec.Version
+			}
+			if err := k8sClient.Get(ctx, key, kcm); err == nil {
+				versions[1] = kcm.Spec.Version
+			}
+			if err := k8sClient.Get(ctx, key, ks); err == nil {
+				versions[2] = ks.Spec.Version
+			}
+			return versions
+		}
+		// rollOut reports the spec version of the component as running, as its controller does once the Deployment is rolled out
+		rollOut := func(obj client.Object) {
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, key, obj); err != nil {
+					return err
+				}
+				switch component := obj.(type) {
+				case *clusterv1alpha1.KubeAPIServer:
+					component.Status.Version = component.Spec.Version
+				case *clusterv1alpha1.KubeControllerManager:
+					component.Status.Version = component.Spec.Version
+				case *clusterv1alpha1.KubeScheduler:
+					component.Status.Version = component.Spec.Version
+				}
+				return k8sClient.Status().Update(ctx, obj)
+			}, timeout, interval).Should(Succeed())
+		}
+		upgrading := func() string {
+			if err := k8sClient.Get(ctx, key, cp); err != nil {
+				return ""
+			}
+			condition := meta.FindStatusCondition(cp.Status.Conditions, clusterv1alpha1.ConditionUpgrading)
+			if condition == nil {
+				return ""
+			}
+			return condition.Reason
+		}
+
+		Eventually(specVersions, timeout, interval).Should(Equal([]string{"v1.26.1", "v1.26.1", "v1.26.1"}))
+
+		By("Changing the version once only kube-apiserver runs v1.26.1")
+		rollOut(kas)
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, key, cp); err != nil {
+				return err
+			}
+			cp.Spec.Version = "v1.27.1"
+			return k8sClient.Update(ctx, cp)
+		}, timeout, interval).Should(Succeed())
+
+		Eventually(specVersions, timeout, interval).Should(Equal([]string{"v1.27.1", "v1.26.1", "v1.26.1"}))
+		Eventually(upgrading, timeout, interval).Should(Equal("UpgradingKubeAPIServer"))
+		Expect(cp.Status.Upgrade).ShouldNot(BeNil())
+		Expect(cp.Status.Upgrade.From).Should(Equal("v1.26.1"))
+		Expect(cp.Status.Version).Should(BeEmpty())
+
+		By("Rolling out kube-apiserver")
+		rollOut(kas)
+		Eventually(specVersions, timeout, interval).Should(Equal([]string{"v1.27.1", "v1.27.1", "v1.26.1"}))
+		Eventually(upgrading, timeout, interval).Should(Equal("UpgradingKubeControllerManager"))
+
+		By("Rolling out kube-controller-manager")
+		rollOut(kcm)
+		Eventually(specVersions, timeout, interval).Should(Equal([]string{"v1.27.1", "v1.27.1", "v1.27.1"}))
+		Eventually(upgrading, timeout, interval).Should(Equal("UpgradingKubeScheduler"))
+
+		By("Rolling out kube-scheduler")
+		rollOut(ks)
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, key, cp); err != nil {
+				return ""
+			}
+			return cp.Status.Version
+		}, timeout, interval).Should(Equal("v1.27.1"))
+		Expect(upgrading()).Should(BeElementOf("UpgradeCompleted", "UpToDate"))
+		Expect(cp.Status.Upgrade).Should(BeNil())
+		Expect(cp.Status.UpgradeHistory).Should(HaveLen(1))
+		Expect(cp.Status.UpgradeHistory[0].From).Should(Equal("v1.26.1"))
+		Expect(cp.Status.UpgradeHistory[0].To).Should(Equal("v1.27.1"))
+	})
+
 })
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// Number of completed upgrades kept in the ControlPlane status
+const upgradeHistoryLimit = 10
+
+// componentVersions are the versions applied to each component of a ControlPlane
+type componentVersions struct {
+	KubeAPIServer         string
+	KubeControllerManager string
+	KubeScheduler         string
+}
+
+// PlanUpgrade returns the versions of the components and records the upgrade progress in the ControlPlane status.
+//
+// A new ControlPlane gets the spec version on every component at once. Afterwards a version change is
+// rolled out following the version skew policy: kube-apiserver first, then kube-controller-manager once
+// kube-apiserver is fully rolled out, then kube-scheduler. A version change during an upgrade is only planned
+// once the upgrade completes, the components running the new version cannot be rolled back. A version change
+// before the first rollout completes is an upgrade from the version kube-apiserver runs.
+func (r *ControlPlaneReconciler) PlanUpgrade(ctx context.Context, cp *clusterv1alpha1.ControlPlane) (componentVersions, error) {
+	target := cp.Spec.Version
+	current := cp.Status.Version
+
+	kas := &clusterv1alpha1.KubeAPIServer{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cp.Name, Namespace: cp.Namespace}, kas); client.IgnoreNotFound(err) != nil {
+		return componentVersions{}, err
+	}
+	kcm := &clusterv1alpha1.KubeControllerManager{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cp.Name, Namespace: cp.Namespace}, kcm); client.IgnoreNotFound(err) != nil {
+		return componentVersions{}, err
+	}
+	ks := &clusterv1alpha1.KubeScheduler{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cp.Name, Namespace: cp.Namespace}, ks); client.IgnoreNotFound(err) != nil {
+		return componentVersions{}, err
+	}
+
+	// Before the first rollout completes, a version change is an upgrade from the version kube-apiserver runs
+	if current == "" {
+		switch {
+		case cp.Status.Upgrade != nil:
+			current = cp.Status.Upgrade.From
+		case cp.Spec.KubeApiServer.Version == "" && kas.Status.Version != "" && kas.Status.Version != target:
+			r.log.Info("ControlPlane version changed before its first rollout completed", "name", cp.Name, "namespace", cp.Namespace, "running", kas.Status.Version, "version", target)
+			current = kas.Status.Version
+		}
+	}
+
+	pending := ""
+	if current != "" && cp.Status.Upgrade != nil && cp.Status.Upgrade.To != target {
+		r.log.Info("ControlPlane version changed during an upgrade, completing it first", "name", cp.Name, "namespace", cp.Namespace, "upgrade", cp.Status.Upgrade.To, "version", target)
+		pending, target = target, cp.Status.Upgrade.To
+	}
+
+	// Component versions set explicitly take precedence over the ControlPlane version
+	kasUpgraded := kas.Status.Version == CoaleseString(cp.Spec.KubeApiServer.Version, target)
+	kcmUpgraded := kcm.Status.Version == CoaleseString(cp.Spec.KubeControllerManager.Version, target)
+	ksUpgraded := ks.Status.Version == CoaleseString(cp.Spec.KubeScheduler.Version, target)
+
+	upgrading := metav1.Condition{Type: clusterv1alpha1.ConditionUpgrading, Status: metav1.ConditionFalse, ObservedGeneration: cp.Generation, Reason: "UpToDate", Message: fmt.Sprintf("Running %s", target)}
+
+	// First rollout
+	if current == "" {
+		if kasUpgraded && kcmUpgraded && ksUpgraded {
+			cp.Status.Version = target
+		}
+		meta.SetStatusCondition(&cp.Status.Conditions, upgrading)
+		return componentVersions{target, target, target}, nil
+	}
+
+	if target == current {
+		cp.Status.Upgrade = nil
+		meta.SetStatusCondition(&cp.Status.Conditions, upgrading)
+		return componentVersions{current, current, current}, nil
+	}
+
+	if err := clusterv1alpha1.ValidateUpgrade(current, target); err != nil {
+		r.log.Info("rejecting ControlPlane upgrade", "name", cp.Name, "namespace", cp.Namespace, "reason", err.Error())
+		cp.Status.Upgrade = nil
+		upgrading.Reason = "UpgradeRejected"
+		upgrading.Message = err.Error()
+		meta.SetStatusCondition(&cp.Status.Conditions, upgrading)
+		return componentVersions{current, current, current}, nil
+	}
+
+	if cp.Status.Upgrade == nil {
+		cp.Status.Upgrade = &clusterv1alpha1.ControlPlaneUpgrade{From: current, To: target, StartTime: metav1.Now()}
+	}
+
+	versions := componentVersions{target, current, current}
+	switch {
+	case !kasUpgraded:
+		cp.Status.Upgrade.Component = "KubeAPIServer"
+	case !kcmUpgraded:
+		versions.KubeControllerManager = target
+		cp.Status.Upgrade.Component = "KubeControllerManager"
+	case !ksUpgraded:
+		versions.KubeControllerManager = target
+		versions.KubeScheduler = target
+		cp.Status.Upgrade.Component = "KubeScheduler"
+	default:
+		cp.Status.UpgradeHistory = append(cp.Status.UpgradeHistory, clusterv1alpha1.ControlPlaneUpgradeHistory{
+			From:           cp.Status.Upgrade.From,
+			To:             target,
+			StartTime:      cp.Status.Upgrade.StartTime,
+			CompletionTime: metav1.Now(),
+		})
+		if len(cp.Status.UpgradeHistory) > upgradeHistoryLimit {
+			cp.Status.UpgradeHistory = cp.Status.UpgradeHistory[len(cp.Status.UpgradeHistory)-upgradeHistoryLimit:]
+		}
+
+		r.log.Info("ControlPlane upgraded", "name", cp.Name, "namespace", cp.Namespace, "from", cp.Status.Upgrade.From, "to", target)
+		cp.Status.Version = target
+		cp.Status.Upgrade = nil
+		upgrading.Reason = "UpgradeCompleted"
+		if pending != "" {
+			upgrading.Message = fmt.Sprintf("Running %s, planning %s", target, pending)
+		}
+		meta.SetStatusCondition(&cp.Status.Conditions, upgrading)
+		return componentVersions{target, target, target}, nil
+	}
+
+	upgrading.Status = metav1.ConditionTrue
+	upgrading.Reason = fmt.Sprintf("Upgrading%s", cp.Status.Upgrade.Component)
+	upgrading.Message = fmt.Sprintf("Upgrading %s from %s to %s", cp.Status.Upgrade.Component, current, target)
+	if pending != "" {
+		upgrading.Message += fmt.Sprintf(", %s is planned once it completes", pending)
+	}
+	meta.SetStatusCondition(&cp.Status.Conditions, upgrading)
+
+	return versions, nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+var _ = Describe("ControlPlane upgrade", func() {
+
+	DescribeTable("Completes an upgrade before planning a version change",
+		func(version string) {
+			objectMeta := metav1.ObjectMeta{Name: "demo", Namespace: "default"}
+			kas := &clusterv1alpha1.KubeAPIServer{ObjectMeta: objectMeta, Status: clusterv1alpha1.KubeAPIServerStatus{Version: "v1.28.2"}}
+			kcm := &clusterv1alpha1.KubeControllerManager{ObjectMeta: objectMeta, Status: clusterv1alpha1.KubeControllerManagerStatus{Version: "v1.27.5"}}
+			ks := &clusterv1alpha1.KubeScheduler{ObjectMeta: objectMeta, Status: clusterv1alpha1.KubeSchedulerStatus{Version: "v1.27.5"}}
+			r := &ControlPlaneReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(kas, kcm, ks).Build(),
+				Scheme: scheme.Scheme,
+				log:    log.Log.WithName("controlplane-reconciler"),
+			}
+
+			By("Changing the version once kube-apiserver runs v1.28.2")
+			cp := &clusterv1alpha1.ControlPlane{
+				ObjectMeta: objectMeta,
+				Spec:       clusterv1alpha1.ControlPlaneSpec{Version: version},
+				Status: clusterv1alpha1.ControlPlaneStatus{
+					Version: "v1.27.5",
+					Upgrade: &clusterv1alpha1.ControlPlaneUpgrade{From: "v1.27.5", To: "v1.28.2", Component: "KubeControllerManager", StartTime: metav1.Now()},
+				},
+			}
+			versions, err := r.PlanUpgrade(context.Background(), cp)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(versions).Should(Equal(componentVersions{"v1.28.2", "v1.28.2", "v1.27.5"}))
+			Expect(cp.Status.Upgrade).ShouldNot(BeNil())
+			Expect(cp.Status.Upgrade.To).Should(Equal("v1.28.2"))
+			Expect(meta.FindStatusCondition(cp.Status.Conditions, clusterv1alpha1.ConditionUpgrading).Message).Should(ContainSubstring(version + " is planned once it completes"))
+		},
+		Entry("revert", "v1.27.5"),
+		Entry("retarget", "v1.29.0"),
+	)
+
+})
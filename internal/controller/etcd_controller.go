@@ -0,0 +1,442 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+const (
+	DefaultEtcdVersion     = "3.5.9-0"
+	DefaultEtcdStorageSize = "1Gi"
+)
+
+// EtcdReconciler reconciles a Etcd object
+type EtcdReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+}
+
+func NewEtcdReconciler(mgr manager.Manager) *EtcdReconciler {
+	return &EtcdReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		log:    log.Log.WithName("etcd-reconciler"),
+	}
+}
+
+// EtcdServiceName is the name of the headless service of the etcd members
+func EtcdServiceName(etcd string) string {
+	return fmt.Sprintf("%s-etcd", etcd)
+}
+
+// EtcdClientServiceName is the name of the service load balancing etcd clients
+func EtcdClientServiceName(etcd string) string {
+	return fmt.Sprintf("%s-etcd-client", etcd)
+}
+
+// EtcdDNSNames returns the names etcd server and peer certificates must be valid for
+func EtcdDNSNames(etcd, namespace string) []string {
+	headless := EtcdServiceName(etcd)
+	client := EtcdClientServiceName(etcd)
+	return []string{
+		"localhost",
+		client,
+		fmt.Sprintf("%s.%s.svc", client, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", client, namespace),
+		fmt.Sprintf("*.%s.%s.svc", headless, namespace),
+		fmt.Sprintf("*.%s.%s.svc.cluster.local", headless, namespace),
+	}
+}
+
+// EtcdEndpoints returns the client URLs of every member of the cluster
+func EtcdEndpoints(etcd clusterv1alpha1.Etcd) string {
+	endpoints := []string{}
+	for i := int32(0); i < etcd.Spec.Replicas; i++ {
+		endpoints = append(endpoints, fmt.Sprintf("https://%s-%d.%s.%s.svc:2379", EtcdServiceName(etcd.Name), i, EtcdServiceName(etcd.Name), etcd.Namespace))
+	}
+	return strings.Join(endpoints, ",")
+}
+
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcds,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcds/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcds/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
+func (r *EtcdReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	etcd := &clusterv1alpha1.Etcd{}
+	if err := r.Get(ctx, req.NamespacedName, etcd); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		r.log.Error(err, "failed to get Etcd resource", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	//////////
+	// Checks
+	//////////
+	if err := r.Get(ctx, types.NamespacedName{Name: etcd.Spec.TLS.ServerSecretName, Namespace: req.Namespace}, &corev1.Secret{}); err != nil {
+		r.log.Info("failed to get server tls secret for Etcd, requeing", "name", etcd.Spec.TLS.ServerSecretName, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+	}
+
+	if err := r.Get(ctx, types.NamespacedName{Name: etcd.Spec.TLS.PeerSecretName, Namespace: req.Namespace}, &corev1.Secret{}); err != nil {
+		r.log.Info("failed to get peer tls secret for Etcd, requeing", "name", etcd.Spec.TLS.PeerSecretName, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+	}
+
+	////////////
+	// Services
+	////////////
+	headless := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: EtcdServiceName(etcd.Name), Namespace: req.Namespace}}
+	err := r.CreateOrPatch(ctx, headless, etcd, func() error {
+		headless.Labels = labels("etcd", etcd.Name, map[string]string{})
+		headless.Spec.ClusterIP = corev1.ClusterIPNone
+		// Members must resolve each other before being ready to bootstrap the cluster
+		headless.Spec.PublishNotReadyAddresses = true
+		headless.Spec.Selector = labels("etcd", etcd.Name, map[string]string{})
+		headless.Spec.Ports = []corev1.ServicePort{
+			{Name: "client", Port: 2379, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(2379)},
+			{Name: "peer", Port: 2380, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(2380)},
+		}
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	clientService := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: EtcdClientServiceName(etcd.Name), Namespace: req.Namespace}}
+	err = r.CreateOrPatch(ctx, clientService, etcd, func() error {
+		clientService.Labels = labels("etcd", etcd.Name, map[string]string{})
+		clientService.Spec.Selector = labels("etcd", etcd.Name, map[string]string{})
+		clientService.Spec.Ports = []corev1.ServicePort{
+			{Name: "client", Port: 2379, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(2379)},
+		}
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	////////////
+	// StatefulSet
+	////////////
+	statefulset := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: EtcdServiceName(etcd.Name), Namespace: req.Namespace}}
+	err = r.CreateOrPatch(ctx, statefulset, etcd, func() error {
+		sts := GenerateEtcdStatefulSet(*etcd)
+		statefulset.Labels = sts.Labels
+
+		// Volume claim templates and selector are immutable
+		if statefulset.CreationTimestamp.IsZero() {
+			statefulset.Spec = sts.Spec
+			return nil
+		}
+		statefulset.Spec.Replicas = sts.Spec.Replicas
+		statefulset.Spec.Template = sts.Spec.Template
+		return nil
+	})
+	if err != nil {
+		r.log.Error(err, "failed to create or patch Etcd statefulset", "name", statefulset.Name, "namespace", statefulset.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	// Update Status
+	etcd.Status.ObservedGeneration = etcd.Generation
+	etcd.Status.Replicas = statefulset.Status.Replicas
+	etcd.Status.ReadyReplicas = statefulset.Status.ReadyReplicas
+	etcd.Status.Endpoints = EtcdEndpoints(*etcd)
+
+	condition := metav1.Condition{
+		Type:               clusterv1alpha1.ConditionAvailable,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: etcd.Generation,
+		Reason:             "QuorumLost",
+		Message:            fmt.Sprintf("%d/%d members ready", statefulset.Status.ReadyReplicas, etcd.Spec.Replicas),
+	}
+	if etcd.Spec.Replicas > 0 && statefulset.Status.ReadyReplicas > etcd.Spec.Replicas/2 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "QuorumReached"
+	}
+	meta.SetStatusCondition(&etcd.Status.Conditions, condition)
+
+	if err := r.Status().Update(ctx, etcd); err != nil {
+		r.log.Error(err, "failed to update Etcd status", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EtcdReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1alpha1.Etcd{}).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&corev1.Service{}).
+		Complete(r)
+}
+
+func GenerateEtcdStatefulSet(etcd clusterv1alpha1.Etcd) appsv1.StatefulSet {
+	headless := EtcdServiceName(etcd.Name)
+
+	members := []string{}
+	for i := int32(0); i < etcd.Spec.Replicas; i++ {
+		member := fmt.Sprintf("%s-%d", headless, i)
+		members = append(members, fmt.Sprintf("%s=https://%s.%s.%s.svc:2380", member, member, headless, etcd.Namespace))
+	}
+
+	storage := etcd.Spec.Storage.Size
+	if storage.IsZero() {
+		storage = resource.MustParse(DefaultEtcdStorageSize)
+	}
+
+	volumes := []corev1.Volume{
+		{Name: "server", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: etcd.Spec.TLS.ServerSecretName}}},
+		{Name: "peer", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: etcd.Spec.TLS.PeerSecretName}}},
+	}
+
+	initContainers := []corev1.Container{}
+	if etcd.Spec.Snapshot != nil {
+		volumes = append(volumes, snapshotVolume(etcd.Spec.Snapshot.EtcdSnapshotStorage))
+		initContainers = GenerateEtcdRestoreContainers(etcd, strings.Join(members, ","))
+	}
+
+	return appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      headless,
+			Namespace: etcd.Namespace,
+			Labels:    labels("etcd", etcd.Name, map[string]string{}),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:            &etcd.Spec.Replicas,
+			ServiceName:         headless,
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels("etcd", etcd.Name, map[string]string{}),
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels("etcd", etcd.Name, map[string]string{}),
+				},
+				Spec: corev1.PodSpec{
+					Volumes:        volumes,
+					InitContainers: initContainers,
+					Containers: []corev1.Container{
+						{
+							Name:  "etcd",
+							Image: fmt.Sprintf("registry.k8s.io/etcd:%s", CoaleseString(etcd.Spec.Version, DefaultEtcdVersion)),
+							Command: []string{
+								"etcd",
+								"--name=$(POD_NAME)",
+								"--data-dir=/var/lib/etcd",
+
+								"--listen-client-urls=https://0.0.0.0:2379",
+								fmt.Sprintf("--advertise-client-urls=https://$(POD_NAME).%s.%s.svc:2379", headless, etcd.Namespace),
+								"--listen-peer-urls=https://0.0.0.0:2380",
+								fmt.Sprintf("--initial-advertise-peer-urls=https://$(POD_NAME).%s.%s.svc:2380", headless, etcd.Namespace),
+								"--listen-metrics-urls=http://0.0.0.0:2381",
+
+								fmt.Sprintf("--initial-cluster=%s", strings.Join(members, ",")),
+								"--initial-cluster-state=new",
+								fmt.Sprintf("--initial-cluster-token=%s", headless),
+
+								"--client-cert-auth",
+								"--cert-file=/etc/etcd/tls/server/tls.crt",
+								"--key-file=/etc/etcd/tls/server/tls.key",
+								"--trusted-ca-file=/etc/etcd/tls/server/ca.crt",
+
+								"--peer-client-cert-auth",
+								"--peer-cert-file=/etc/etcd/tls/peer/tls.crt",
+								"--peer-key-file=/etc/etcd/tls/peer/tls.key",
+								"--peer-trusted-ca-file=/etc/etcd/tls/peer/ca.crt",
+							},
+							Env: []corev1.EnvVar{
+								{
+									Name: "POD_NAME",
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{
+											FieldPath: "metadata.name",
+										},
+									},
+								},
+							},
+							Ports: []corev1.ContainerPort{
+								{Name: "client", ContainerPort: 2379},
+								{Name: "peer", ContainerPort: 2380},
+								{Name: "metrics", ContainerPort: 2381},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "data", MountPath: "/var/lib/etcd"},
+								{Name: "server", MountPath: "/etc/etcd/tls/server"},
+								{Name: "peer", MountPath: "/etc/etcd/tls/peer"},
+							},
+							LivenessProbe: &corev1.Probe{
+								InitialDelaySeconds: 10,
+								TimeoutSeconds:      15,
+								FailureThreshold:    8,
+								PeriodSeconds:       10,
+								SuccessThreshold:    1,
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Port:   intstr.FromInt(2381),
+										Path:   "/health?exclude=NOSPACE&serializable=true",
+										Scheme: corev1.URISchemeHTTP,
+									},
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								TimeoutSeconds:   15,
+								FailureThreshold: 3,
+								PeriodSeconds:    10,
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Port:   intstr.FromInt(2381),
+										Path:   "/health",
+										Scheme: corev1.URISchemeHTTP,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "data"},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						StorageClassName: etcd.Spec.Storage.StorageClassName,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: storage,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Restores the snapshot in /snapshot when the member has no data yet
+const restoreScript = `set -eu
+[ -d /var/lib/etcd/member ] && exit 0
+rm -rf /var/lib/etcd/restore
+etcdutl snapshot restore "/snapshot/${SNAPSHOT}" \
+  --name "${POD_NAME}" \
+  --initial-cluster "${INITIAL_CLUSTER}" \
+  --initial-cluster-token "${INITIAL_CLUSTER_TOKEN}" \
+  --initial-advertise-peer-urls "https://${POD_NAME}.${PEER_DOMAIN}:2380" \
+  --data-dir /var/lib/etcd/restore
+mv /var/lib/etcd/restore/member /var/lib/etcd/member
+rm -rf /var/lib/etcd/restore
+`
+
+// Downloads the snapshot to /snapshot when the member has no data yet
+const downloadScript = `set -eu
+[ -d /var/lib/etcd/member ] && exit 0
+KEY="${S3_PREFIX:+${S3_PREFIX%/}/}${SNAPSHOT}"
+mc alias set source "${S3_ENDPOINT}" "${S3_ACCESS_KEY_ID}" "${S3_SECRET_ACCESS_KEY}"
+mc cp "source/${S3_BUCKET}/${KEY}" "/snapshot/${SNAPSHOT}"
+`
+
+// GenerateEtcdRestoreContainers returns the init containers seeding new members from the Etcd snapshot
+func GenerateEtcdRestoreContainers(etcd clusterv1alpha1.Etcd, initialCluster string) []corev1.Container {
+	var root int64 = 0
+	headless := EtcdServiceName(etcd.Name)
+	snapshot := etcd.Spec.Snapshot
+
+	containers := []corev1.Container{}
+	if snapshot.S3 != nil {
+		containers = append(containers, corev1.Container{
+			Name:    "download-snapshot",
+			Image:   CoaleseString(snapshot.S3.Image, DefaultMinioClientImage),
+			Command: []string{"/bin/sh", "-c", downloadScript},
+			Env:     append(s3Env(*snapshot.S3), corev1.EnvVar{Name: "SNAPSHOT", Value: snapshot.Snapshot}),
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "data", MountPath: "/var/lib/etcd"},
+				{Name: "snapshot", MountPath: "/snapshot"},
+			},
+		})
+	}
+
+	return append(containers, corev1.Container{
+		Name:    "restore-snapshot",
+		Image:   DefaultEtcdToolsImage,
+		Command: []string{"/bin/bash", "-c", restoreScript},
+		Env: []corev1.EnvVar{
+			{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+			{Name: "SNAPSHOT", Value: snapshot.Snapshot},
+			{Name: "INITIAL_CLUSTER", Value: initialCluster},
+			{Name: "INITIAL_CLUSTER_TOKEN", Value: headless},
+			{Name: "PEER_DOMAIN", Value: fmt.Sprintf("%s.%s.svc", headless, etcd.Namespace)},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "data", MountPath: "/var/lib/etcd"},
+			{Name: "snapshot", MountPath: "/snapshot", ReadOnly: snapshot.PVC != nil},
+		},
+		SecurityContext: &corev1.SecurityContext{RunAsUser: &root},
+	})
+}
+
+func (r *EtcdReconciler) CreateOrPatch(ctx context.Context, obj client.Object, owner metav1.Object, f controllerutil.MutateFn) error {
+	if err := ctrl.SetControllerReference(owner, obj, r.Scheme); err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to set controller reference on %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+
+	result, err := controllerutil.CreateOrPatch(ctx, r.Client, obj, f)
+	if err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to create or patch %s", obj.GetObjectKind().GroupVersionKind().Kind), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+	r.log.Info(fmt.Sprintf("%s/%s was %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), result))
+	return nil
+}
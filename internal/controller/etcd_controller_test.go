@@ -0,0 +1,97 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Etcd controller", Ordered, func() {
+	ctx := context.Background()
+	nsName := "etcd"
+
+	BeforeAll(func() {
+		By("Creating client namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+	})
+
+	It("Deploy etcd statefulset", func() {
+
+		// Deploy requirements
+		server := GenerateSecret("etcd-server", nsName, map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""})
+		peer := GenerateSecret("etcd-peer", nsName, map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""})
+
+		Expect(k8sClient.Create(ctx, server)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, peer)).Should(Succeed())
+
+		crd := &clusterv1alpha1.Etcd{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster",
+				Namespace: nsName,
+			},
+			Spec: clusterv1alpha1.EtcdSpec{
+				Version:  "3.5.9-0",
+				Replicas: 3,
+				TLS: clusterv1alpha1.EtcdTLS{
+					ServerSecretName: "etcd-server",
+					PeerSecretName:   "etcd-peer",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, crd)).Should(Succeed())
+
+		By("Checking statefulset image and members")
+		sts := &appsv1.StatefulSet{}
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "cluster-etcd", Namespace: nsName}, sts)
+			if err != nil || *sts.Spec.Replicas != 3 || len(sts.Spec.VolumeClaimTemplates) != 1 {
+				return false
+			}
+
+			for _, v := range sts.Spec.Template.Spec.Containers {
+				if v.Name == "etcd" && v.Image == "registry.k8s.io/etcd:3.5.9-0" {
+					return strings.Contains(strings.Join(v.Command, " "), "cluster-etcd-2=https://cluster-etcd-2.cluster-etcd.etcd.svc:2380")
+				}
+			}
+			return false
+		}, timeout, interval).Should(BeTrue())
+
+		By("Checking services")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "cluster-etcd", Namespace: nsName}, &corev1.Service{})).Should(Succeed())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "cluster-etcd-client", Namespace: nsName}, &corev1.Service{})).Should(Succeed())
+
+		By("Publishing client endpoints in status")
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: crd.Name, Namespace: nsName}, crd); err != nil {
+				return ""
+			}
+			return crd.Status.Endpoints
+		}, timeout, interval).Should(Equal("https://cluster-etcd-0.cluster-etcd.etcd.svc:2379,https://cluster-etcd-1.cluster-etcd.etcd.svc:2379,https://cluster-etcd-2.cluster-etcd.etcd.svc:2379"))
+	})
+
+})
@@ -0,0 +1,414 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+const (
+	DefaultEtcdToolsImage   = "docker.io/bitnami/etcd:3.5.9"
+	DefaultMinioClientImage = "docker.io/minio/mc:RELEASE.2023-10-14T01-57-03Z"
+
+	// EtcdBackupLabel is set on snapshot jobs with the name of their EtcdBackup
+	EtcdBackupLabel = "cluster.kubeception.ulfo.fr/etcd-backup"
+)
+
+// snapshotResult is written by snapshot jobs in their termination message
+type snapshotResult struct {
+	Snapshot string `json:"snapshot"`
+	Size     int64  `json:"size"`
+	Revision int64  `json:"revision"`
+	Checksum string `json:"checksum"`
+}
+
+// Takes the snapshot in /snapshot and describes it in /snapshot/<snapshot>.json
+const snapshotScript = `set -eu
+SNAPSHOT="${SNAPSHOT_PREFIX}-$(date -u +%Y%m%d%H%M%S).db"
+etcdctl snapshot save "/snapshot/${SNAPSHOT}"
+REVISION=$(etcdutl snapshot status "/snapshot/${SNAPSHOT}" --write-out=json | sed -n 's/.*"revision":\([0-9]*\).*/\1/p')
+SIZE=$(stat -c %s "/snapshot/${SNAPSHOT}")
+CHECKSUM=$(sha256sum "/snapshot/${SNAPSHOT}" | cut -d ' ' -f 1)
+printf '{"snapshot":"%s","size":%s,"revision":%s,"checksum":"sha256:%s"}' "${SNAPSHOT}" "${SIZE}" "${REVISION}" "${CHECKSUM}" > "/snapshot/${SNAPSHOT}.json"
+echo "${SNAPSHOT}" > /snapshot/name
+cat "/snapshot/${SNAPSHOT}.json" > /dev/termination-log
+`
+
+// Uploads the snapshot taken by snapshotScript
+const uploadScript = `set -eu
+SNAPSHOT=$(cat /snapshot/name)
+KEY="${S3_PREFIX:+${S3_PREFIX%/}/}${SNAPSHOT}"
+mc alias set target "${S3_ENDPOINT}" "${S3_ACCESS_KEY_ID}" "${S3_SECRET_ACCESS_KEY}"
+mc cp "/snapshot/${SNAPSHOT}" "target/${S3_BUCKET}/${KEY}"
+mc cp "/snapshot/${SNAPSHOT}.json" "target/${S3_BUCKET}/${KEY}.json"
+cat "/snapshot/${SNAPSHOT}.json" > /dev/termination-log
+`
+
+// EtcdBackupReconciler reconciles a EtcdBackup object
+type EtcdBackupReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+}
+
+func NewEtcdBackupReconciler(mgr manager.Manager) *EtcdBackupReconciler {
+	return &EtcdBackupReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		log:    log.Log.WithName("etcdbackup-reconciler"),
+	}
+}
+
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcdbackups,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcdbackups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcdbackups/finalizers,verbs=update
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=controlplanes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
+func (r *EtcdBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	backup := &clusterv1alpha1.EtcdBackup{}
+	if err := r.Get(ctx, req.NamespacedName, backup); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		r.log.Error(err, "failed to get EtcdBackup resource", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	// Single backups are never taken twice
+	if backup.Spec.Schedule == "" && (backup.Status.Phase == clusterv1alpha1.EtcdBackupCompleted || backup.Status.Phase == clusterv1alpha1.EtcdBackupFailed) {
+		return ctrl.Result{}, nil
+	}
+
+	//////////
+	// Checks
+	//////////
+	cp := &clusterv1alpha1.ControlPlane{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.ControlPlane, Namespace: req.Namespace}, cp); err != nil {
+		r.log.Info("failed to get ControlPlane for EtcdBackup, requeing", "name", backup.Spec.ControlPlane, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+	}
+
+	if cp.Spec.Etcd == nil {
+		r.log.Info("ControlPlane has no managed etcd, nothing to backup", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdatePhase(ctx, backup, clusterv1alpha1.EtcdBackupFailed)
+	}
+
+	clientSecret := CoaleseString(cp.Spec.KubeApiServer.TLS.ETCDClientSecretName, cp.Spec.PKI.ETCD.Client)
+	if err := r.Get(ctx, types.NamespacedName{Name: clientSecret, Namespace: req.Namespace}, &corev1.Secret{}); err != nil {
+		r.log.Info("failed to get etcd client tls secret for EtcdBackup, requeing", "name", clientSecret, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+	}
+
+	template := GenerateEtcdBackupJobTemplate(*backup, ControlPlaneEtcdName(*cp), clientSecret)
+
+	////////////
+	// Single backup
+	////////////
+	if backup.Spec.Schedule == "" {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-snapshot", backup.Name), Namespace: req.Namespace}}
+		err := r.CreateOrPatch(ctx, job, backup, func() error {
+			job.Labels = template.Labels
+			// Job template is immutable
+			if job.CreationTimestamp.IsZero() {
+				job.Spec = template.Spec
+			}
+			return nil
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		backup.Status.StartTime = job.Status.StartTime
+		backup.Status.Phase = clusterv1alpha1.EtcdBackupPending
+		if job.Status.Active > 0 {
+			backup.Status.Phase = clusterv1alpha1.EtcdBackupRunning
+		}
+		if jobFinished(*job, batchv1.JobFailed) {
+			backup.Status.Phase = clusterv1alpha1.EtcdBackupFailed
+		}
+		if jobFinished(*job, batchv1.JobComplete) {
+			if err := r.SetSnapshotResult(ctx, backup, *job); err != nil {
+				return ctrl.Result{}, err
+			}
+			backup.Status.Phase = clusterv1alpha1.EtcdBackupCompleted
+		}
+
+		if err := r.Status().Update(ctx, backup); err != nil {
+			r.log.Error(err, "failed to update EtcdBackup status", "name", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	////////////
+	// Scheduled backups
+	////////////
+	cronjob := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-snapshot", backup.Name), Namespace: req.Namespace}}
+	err := r.CreateOrPatch(ctx, cronjob, backup, func() error {
+		cronjob.Labels = template.Labels
+		cronjob.Spec.Schedule = backup.Spec.Schedule
+		cronjob.Spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+		cronjob.Spec.JobTemplate = template
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	backup.Status.Phase = clusterv1alpha1.EtcdBackupScheduled
+	backup.Status.LastScheduleTime = cronjob.Status.LastScheduleTime
+
+	// Report the most recent successful snapshot
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(req.Namespace), client.MatchingLabels{EtcdBackupLabel: backup.Name}); err != nil {
+		r.log.Error(err, "failed to list EtcdBackup jobs", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	var last *batchv1.Job
+	for i, job := range jobs.Items {
+		if !jobFinished(job, batchv1.JobComplete) || job.Status.CompletionTime == nil {
+			continue
+		}
+		if last == nil || job.Status.CompletionTime.After(last.Status.CompletionTime.Time) {
+			last = &jobs.Items[i]
+		}
+	}
+	if last != nil {
+		backup.Status.StartTime = last.Status.StartTime
+		if err := r.SetSnapshotResult(ctx, backup, *last); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.Status().Update(ctx, backup); err != nil {
+		r.log.Error(err, "failed to update EtcdBackup status", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetSnapshotResult reads the termination message of a successful snapshot job into the backup status
+func (r *EtcdBackupReconciler) SetSnapshotResult(ctx context.Context, backup *clusterv1alpha1.EtcdBackup, job batchv1.Job) error {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		r.log.Error(err, "failed to list snapshot job pods", "name", job.Name, "namespace", job.Namespace)
+		return err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated == nil || status.State.Terminated.Message == "" {
+				continue
+			}
+
+			result := snapshotResult{}
+			if err := json.Unmarshal([]byte(status.State.Terminated.Message), &result); err != nil {
+				r.log.Error(err, "failed to parse snapshot job result", "name", pod.Name, "namespace", pod.Namespace)
+				return nil
+			}
+
+			backup.Status.Snapshot = result.Snapshot
+			backup.Status.Location = SnapshotLocation(backup.Spec.Storage, result.Snapshot)
+			backup.Status.Size = result.Size
+			backup.Status.Revision = result.Revision
+			backup.Status.Checksum = result.Checksum
+			backup.Status.CompletionTime = job.Status.CompletionTime
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (r *EtcdBackupReconciler) UpdatePhase(ctx context.Context, backup *clusterv1alpha1.EtcdBackup, phase string) error {
+	backup.Status.Phase = phase
+	return r.Status().Update(ctx, backup)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EtcdBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1alpha1.EtcdBackup{}).
+		Owns(&batchv1.CronJob{}).
+		// Jobs of scheduled backups are owned by the CronJob
+		Watches(&source.Kind{Type: &batchv1.Job{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+			name, ok := obj.GetLabels()[EtcdBackupLabel]
+			if !ok {
+				return nil
+			}
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+		})).
+		Complete(r)
+}
+
+func jobFinished(job batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == conditionType && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// SnapshotLocation returns the URL of a snapshot in a storage
+func SnapshotLocation(storage clusterv1alpha1.EtcdSnapshotStorage, snapshot string) string {
+	if storage.S3 != nil {
+		return fmt.Sprintf("s3://%s/%s", storage.S3.Bucket, path.Join(storage.S3.Prefix, snapshot))
+	}
+	if storage.PVC != nil {
+		return fmt.Sprintf("pvc://%s/%s", storage.PVC.ClaimName, snapshot)
+	}
+	return ""
+}
+
+// snapshotVolume mounts the snapshot PVC, or an emptyDir when snapshots are transferred to S3
+func snapshotVolume(storage clusterv1alpha1.EtcdSnapshotStorage) corev1.Volume {
+	if storage.PVC != nil {
+		return corev1.Volume{Name: "snapshot", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: storage.PVC.ClaimName}}}
+	}
+	return corev1.Volume{Name: "snapshot", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+}
+
+// s3Env configures the MinIO client scripts
+func s3Env(s3 clusterv1alpha1.EtcdSnapshotS3) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+		{Name: "S3_BUCKET", Value: s3.Bucket},
+		{Name: "S3_PREFIX", Value: s3.Prefix},
+		{Name: "S3_ACCESS_KEY_ID", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: s3.CredentialsSecretName}, Key: "access-key-id"}}},
+		{Name: "S3_SECRET_ACCESS_KEY", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: s3.CredentialsSecretName}, Key: "secret-access-key"}}},
+		{Name: "MC_CONFIG_DIR", Value: "/tmp/.mc"},
+	}
+}
+
+// GenerateEtcdBackupJobTemplate returns the job snapshotting the etcd cluster through its client service
+func GenerateEtcdBackupJobTemplate(backup clusterv1alpha1.EtcdBackup, etcd, clientSecret string) batchv1.JobTemplateSpec {
+	var root int64 = 0
+	var backoff int32 = 2
+
+	snapshot := corev1.Container{
+		Name:    "snapshot",
+		Image:   CoaleseString(backup.Spec.Image, DefaultEtcdToolsImage),
+		Command: []string{"/bin/bash", "-c", snapshotScript},
+		Env: []corev1.EnvVar{
+			{Name: "SNAPSHOT_PREFIX", Value: backup.Name},
+			{Name: "ETCDCTL_API", Value: "3"},
+			{Name: "ETCDCTL_ENDPOINTS", Value: fmt.Sprintf("https://%s.%s.svc:2379", EtcdClientServiceName(etcd), backup.Namespace)},
+			{Name: "ETCDCTL_CACERT", Value: "/etc/etcd/tls/client/ca.crt"},
+			{Name: "ETCDCTL_CERT", Value: "/etc/etcd/tls/client/tls.crt"},
+			{Name: "ETCDCTL_KEY", Value: "/etc/etcd/tls/client/tls.key"},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "snapshot", MountPath: "/snapshot"},
+			{Name: "etcd-client", MountPath: "/etc/etcd/tls/client"},
+		},
+		SecurityContext: &corev1.SecurityContext{RunAsUser: &root},
+	}
+
+	spec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Volumes: []corev1.Volume{
+			snapshotVolume(backup.Spec.Storage),
+			{Name: "etcd-client", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: clientSecret}}},
+		},
+		Containers: []corev1.Container{snapshot},
+	}
+
+	if backup.Spec.Storage.S3 != nil {
+		spec.InitContainers = []corev1.Container{snapshot}
+		spec.Containers = []corev1.Container{
+			{
+				Name:    "upload",
+				Image:   CoaleseString(backup.Spec.Storage.S3.Image, DefaultMinioClientImage),
+				Command: []string{"/bin/sh", "-c", uploadScript},
+				Env:     s3Env(*backup.Spec.Storage.S3),
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "snapshot", MountPath: "/snapshot"},
+				},
+			},
+		}
+	}
+
+	return batchv1.JobTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: labels("etcd-backup", backup.Name, map[string]string{EtcdBackupLabel: backup.Name}),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoff,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels("etcd-backup", backup.Name, map[string]string{EtcdBackupLabel: backup.Name}),
+				},
+				Spec: spec,
+			},
+		},
+	}
+}
+
+func (r *EtcdBackupReconciler) CreateOrPatch(ctx context.Context, obj client.Object, owner metav1.Object, f controllerutil.MutateFn) error {
+	if err := ctrl.SetControllerReference(owner, obj, r.Scheme); err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to set controller reference on %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+
+	result, err := controllerutil.CreateOrPatch(ctx, r.Client, obj, f)
+	if err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to create or patch %s", obj.GetObjectKind().GroupVersionKind().Kind), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+	r.log.Info(fmt.Sprintf("%s/%s was %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), result))
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("EtcdBackup controller", Ordered, func() {
+	ctx := context.Background()
+	nsName := "etcd-backup"
+
+	BeforeAll(func() {
+		By("Creating client namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+
+		client := GenerateSecret("etcd-client", nsName, map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""})
+		Expect(k8sClient.Create(ctx, client)).Should(Succeed())
+
+		cp := &clusterv1alpha1.ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: nsName},
+			Spec: clusterv1alpha1.ControlPlaneSpec{
+				Version: "v1.27.5",
+				PKI:     clusterv1alpha1.PkiSpec{ETCD: clusterv1alpha1.PKIEtcd{Client: "etcd-client"}},
+				Etcd:    &clusterv1alpha1.EtcdSpec{Replicas: 1},
+			},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+	})
+
+	It("Snapshots etcd to a PVC", func() {
+		backup := &clusterv1alpha1.EtcdBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "once", Namespace: nsName},
+			Spec: clusterv1alpha1.EtcdBackupSpec{
+				ControlPlane: "cluster",
+				Storage: clusterv1alpha1.EtcdSnapshotStorage{
+					PVC: &clusterv1alpha1.EtcdSnapshotPVC{ClaimName: "backups"},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, backup)).Should(Succeed())
+
+		job := &batchv1.Job{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "once-snapshot", Namespace: nsName}, job)
+		}, timeout, interval).Should(Succeed())
+
+		Expect(job.Labels).Should(HaveKeyWithValue(EtcdBackupLabel, "once"))
+		Expect(job.Spec.Template.Spec.Containers).Should(HaveLen(1))
+		Expect(job.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "ETCDCTL_ENDPOINTS", Value: "https://cluster-etcd-client.etcd-backup.svc:2379"}))
+		Expect(job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).Should(Equal("backups"))
+
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: backup.Name, Namespace: nsName}, backup); err != nil {
+				return ""
+			}
+			return backup.Status.Phase
+		}, timeout, interval).Should(Equal(clusterv1alpha1.EtcdBackupPending))
+	})
+
+	It("Schedules snapshots to S3", func() {
+		backup := &clusterv1alpha1.EtcdBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: nsName},
+			Spec: clusterv1alpha1.EtcdBackupSpec{
+				ControlPlane: "cluster",
+				Schedule:     "0 2 * * *",
+				Storage: clusterv1alpha1.EtcdSnapshotStorage{
+					S3: &clusterv1alpha1.EtcdSnapshotS3{
+						Endpoint:              "http://minio.minio.svc:9000",
+						Bucket:                "etcd",
+						CredentialsSecretName: "minio",
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, backup)).Should(Succeed())
+
+		cronjob := &batchv1.CronJob{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "nightly-snapshot", Namespace: nsName}, cronjob)
+		}, timeout, interval).Should(Succeed())
+
+		pod := cronjob.Spec.JobTemplate.Spec.Template.Spec
+		Expect(cronjob.Spec.Schedule).Should(Equal("0 2 * * *"))
+		Expect(pod.InitContainers).Should(HaveLen(1))
+		Expect(pod.InitContainers[0].Name).Should(Equal("snapshot"))
+		Expect(pod.Containers[0].Name).Should(Equal("upload"))
+		Expect(pod.Volumes[0].EmptyDir).ShouldNot(BeNil())
+	})
+
+})
@@ -0,0 +1,224 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// EtcdRestoreReconciler reconciles a EtcdRestore object
+type EtcdRestoreReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+}
+
+func NewEtcdRestoreReconciler(mgr manager.Manager) *EtcdRestoreReconciler {
+	return &EtcdRestoreReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		log:    log.Log.WithName("etcdrestore-reconciler"),
+	}
+}
+
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcdrestores,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcdrestores/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcdrestores/finalizers,verbs=update
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcdbackups,verbs=get;list;watch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=controlplanes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=etcds,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=kubeapiservers,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// A restore seeds a new Etcd from the snapshot, then points the ControlPlane
+// to it which rolls the kube-apiserver Deployment over to the new cluster.
+// The previous Etcd is left untouched and can be deleted once the restore is completed.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
+func (r *EtcdRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	restore := &clusterv1alpha1.EtcdRestore{}
+	if err := r.Get(ctx, req.NamespacedName, restore); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		r.log.Error(err, "failed to get EtcdRestore resource", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	if restore.Status.Phase == clusterv1alpha1.EtcdRestoreCompleted || restore.Status.Phase == clusterv1alpha1.EtcdRestoreFailed {
+		return ctrl.Result{}, nil
+	}
+
+	cp := &clusterv1alpha1.ControlPlane{}
+	if err := r.Get(ctx, types.NamespacedName{Name: restore.Spec.ControlPlane, Namespace: req.Namespace}, cp); err != nil {
+		r.log.Info("failed to get ControlPlane for EtcdRestore, requeing", "name", restore.Spec.ControlPlane, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+	}
+
+	if cp.Spec.Etcd == nil {
+		return ctrl.Result{}, r.UpdatePhase(ctx, restore, clusterv1alpha1.EtcdRestoreFailed, "ControlPlane has no managed etcd")
+	}
+
+	////////////
+	// Seed the new Etcd
+	////////////
+	if restore.Status.EtcdName == "" {
+		source, err := r.SnapshotSource(ctx, restore)
+		if err != nil {
+			return ctrl.Result{}, r.UpdatePhase(ctx, restore, clusterv1alpha1.EtcdRestoreFailed, err.Error())
+		}
+		if source == nil {
+			return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdatePhase(ctx, restore, clusterv1alpha1.EtcdRestorePending, fmt.Sprintf("Waiting for EtcdBackup %s to complete", restore.Spec.BackupName))
+		}
+
+		restore.Status.EtcdName = fmt.Sprintf("%s-%s", cp.Name, restore.Name)
+		restore.Status.PreviousEtcdName = ControlPlaneEtcdName(*cp)
+		restore.Status.Snapshot = source.Snapshot
+
+		etcd := &clusterv1alpha1.Etcd{ObjectMeta: metav1.ObjectMeta{Name: restore.Status.EtcdName, Namespace: req.Namespace}}
+		// The new Etcd belongs to the ControlPlane so it outlives the restore
+		err = r.CreateOrPatch(ctx, etcd, cp, func() error {
+			if etcd.CreationTimestamp.IsZero() {
+				etcd.Spec = *cp.Spec.Etcd
+				etcd.Spec.TLS.ServerSecretName = CoaleseString(cp.Spec.Etcd.TLS.ServerSecretName, cp.Spec.PKI.ETCD.Server)
+				etcd.Spec.TLS.PeerSecretName = CoaleseString(cp.Spec.Etcd.TLS.PeerSecretName, cp.Spec.PKI.ETCD.Peer)
+				etcd.Spec.Snapshot = source
+			}
+			return nil
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdatePhase(ctx, restore, clusterv1alpha1.EtcdRestoreRestoring, fmt.Sprintf("Seeding Etcd %s from %s", restore.Status.EtcdName, source.Snapshot))
+	}
+
+	etcd := &clusterv1alpha1.Etcd{}
+	if err := r.Get(ctx, types.NamespacedName{Name: restore.Status.EtcdName, Namespace: req.Namespace}, etcd); err != nil {
+		r.log.Error(err, "failed to get restored Etcd", "name", restore.Status.EtcdName, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	if !meta.IsStatusConditionTrue(etcd.Status.Conditions, clusterv1alpha1.ConditionAvailable) || etcd.Status.ObservedGeneration != etcd.Generation {
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+	}
+
+	////////////
+	// Switch the ControlPlane
+	////////////
+	if ControlPlaneEtcdName(*cp) != etcd.Name {
+		patch := client.MergeFrom(cp.DeepCopy())
+		if cp.Annotations == nil {
+			cp.Annotations = map[string]string{}
+		}
+		cp.Annotations[clusterv1alpha1.EtcdNameAnnotation] = etcd.Name
+		if err := r.Patch(ctx, cp, patch); err != nil {
+			r.log.Error(err, "failed to point ControlPlane to the restored Etcd", "name", cp.Name, "namespace", req.Namespace)
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdatePhase(ctx, restore, clusterv1alpha1.EtcdRestoreSwitching, "Rolling kube-apiserver over to the restored Etcd")
+	}
+
+	kas := &clusterv1alpha1.KubeAPIServer{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cp.Name, Namespace: req.Namespace}, kas); err != nil {
+		r.log.Error(err, "failed to get KubeAPIServer", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	if kas.Spec.ETCDservers != EtcdEndpoints(*etcd) || kas.Status.ObservedGeneration != kas.Generation || !meta.IsStatusConditionTrue(kas.Status.Conditions, clusterv1alpha1.ConditionAvailable) {
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+	}
+
+	now := metav1.Now()
+	restore.Status.CompletionTime = &now
+	return ctrl.Result{}, r.UpdatePhase(ctx, restore, clusterv1alpha1.EtcdRestoreCompleted, fmt.Sprintf("ControlPlane is using Etcd %s, %s can be deleted", etcd.Name, restore.Status.PreviousEtcdName))
+}
+
+// SnapshotSource resolves the snapshot to restore, it is nil while the referenced backup has no snapshot
+func (r *EtcdRestoreReconciler) SnapshotSource(ctx context.Context, restore *clusterv1alpha1.EtcdRestore) (*clusterv1alpha1.EtcdSnapshotSource, error) {
+	if restore.Spec.BackupName == "" {
+		if restore.Spec.Source == nil || restore.Spec.Source.Snapshot == "" {
+			return nil, fmt.Errorf("one of backup-name or source must be set")
+		}
+		return restore.Spec.Source, nil
+	}
+
+	backup := &clusterv1alpha1.EtcdBackup{}
+	if err := r.Get(ctx, types.NamespacedName{Name: restore.Spec.BackupName, Namespace: restore.Namespace}, backup); err != nil {
+		return nil, fmt.Errorf("failed to get EtcdBackup %s: %w", restore.Spec.BackupName, err)
+	}
+
+	if backup.Status.Snapshot == "" {
+		return nil, nil
+	}
+
+	return &clusterv1alpha1.EtcdSnapshotSource{
+		EtcdSnapshotStorage: backup.Spec.Storage,
+		Snapshot:            backup.Status.Snapshot,
+	}, nil
+}
+
+func (r *EtcdRestoreReconciler) UpdatePhase(ctx context.Context, restore *clusterv1alpha1.EtcdRestore, phase, message string) error {
+	restore.Status.Phase = phase
+	restore.Status.Message = message
+	return r.Status().Update(ctx, restore)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EtcdRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1alpha1.EtcdRestore{}).
+		Complete(r)
+}
+
+func (r *EtcdRestoreReconciler) CreateOrPatch(ctx context.Context, obj client.Object, owner metav1.Object, f controllerutil.MutateFn) error {
+	if err := ctrl.SetControllerReference(owner, obj, r.Scheme); err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to set controller reference on %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+
+	result, err := controllerutil.CreateOrPatch(ctx, r.Client, obj, f)
+	if err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to create or patch %s", obj.GetObjectKind().GroupVersionKind().Kind), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+	r.log.Info(fmt.Sprintf("%s/%s was %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), result))
+	return nil
+}
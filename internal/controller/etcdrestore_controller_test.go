@@ -0,0 +1,96 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("EtcdRestore controller", Ordered, func() {
+	ctx := context.Background()
+	nsName := "etcd-restore"
+
+	BeforeAll(func() {
+		By("Creating client namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+
+		server := GenerateSecret("etcd-server", nsName, map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""})
+		peer := GenerateSecret("etcd-peer", nsName, map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""})
+		Expect(k8sClient.Create(ctx, server)).Should(Succeed())
+		Expect(k8sClient.Create(ctx, peer)).Should(Succeed())
+
+		cp := &clusterv1alpha1.ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: nsName},
+			Spec: clusterv1alpha1.ControlPlaneSpec{
+				Version: "v1.27.5",
+				PKI:     clusterv1alpha1.PkiSpec{ETCD: clusterv1alpha1.PKIEtcd{Server: "etcd-server", Peer: "etcd-peer"}},
+				Etcd:    &clusterv1alpha1.EtcdSpec{Replicas: 3},
+			},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+	})
+
+	It("Seeds a new etcd from the snapshot", func() {
+		restore := &clusterv1alpha1.EtcdRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: nsName},
+			Spec: clusterv1alpha1.EtcdRestoreSpec{
+				ControlPlane: "cluster",
+				Source: &clusterv1alpha1.EtcdSnapshotSource{
+					EtcdSnapshotStorage: clusterv1alpha1.EtcdSnapshotStorage{
+						PVC: &clusterv1alpha1.EtcdSnapshotPVC{ClaimName: "backups"},
+					},
+					Snapshot: "once-20230101000000.db",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, restore)).Should(Succeed())
+
+		By("Creating the seeded etcd")
+		etcd := &clusterv1alpha1.Etcd{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "cluster-restore", Namespace: nsName}, etcd)
+		}, timeout, interval).Should(Succeed())
+		Expect(etcd.Spec.Snapshot.Snapshot).Should(Equal("once-20230101000000.db"))
+
+		sts := &appsv1.StatefulSet{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "cluster-restore-etcd", Namespace: nsName}, sts)
+		}, timeout, interval).Should(Succeed())
+		Expect(sts.Spec.Template.Spec.InitContainers).Should(HaveLen(1))
+		Expect(sts.Spec.Template.Spec.InitContainers[0].Name).Should(Equal("restore-snapshot"))
+
+		By("Waiting for the seeded etcd before switching")
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: restore.Name, Namespace: nsName}, restore); err != nil {
+				return ""
+			}
+			return restore.Status.Phase
+		}, timeout, interval).Should(Equal(clusterv1alpha1.EtcdRestoreRestoring))
+		Expect(restore.Status.PreviousEtcdName).Should(Equal("cluster"))
+	})
+
+})
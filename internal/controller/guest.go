@@ -0,0 +1,83 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// GuestFieldOwner is the field manager of the objects applied to the guest clusters
+const GuestFieldOwner = "kubeception-operator"
+
+// ErrGuestNotReady is returned while the admin kubeconfig of a ControlPlane is not issued yet
+var ErrGuestNotReady = errors.New("admin kubeconfig of the ControlPlane is not issued yet")
+
+// GuestClientFunc returns a client of the guest cluster of a ControlPlane
+type GuestClientFunc func(ctx context.Context, c client.Client, cp *clusterv1alpha1.ControlPlane) (client.Client, error)
+
+// NewGuestClient returns a client of the guest cluster of the ControlPlane, authenticated with its admin kubeconfig
+func NewGuestClient(ctx context.Context, c client.Client, cp *clusterv1alpha1.ControlPlane) (client.Client, error) {
+	if cp.Status.KubeconfigSecretRef == nil {
+		return nil, ErrGuestNotReady
+	}
+	kubeconfig := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: cp.Status.KubeconfigSecretRef.Name, Namespace: cp.Namespace}, kubeconfig); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrGuestNotReady
+		}
+		return nil, err
+	}
+	if len(kubeconfig.Data["kubeconfig.yml"]) == 0 {
+		return nil, ErrGuestNotReady
+	}
+	return GuestClientFromKubeconfig(kubeconfig.Data["kubeconfig.yml"])
+}
+
+// GuestClientFromKubeconfig returns a client of the cluster the kubeconfig points to
+func GuestClientFromKubeconfig(kubeconfig []byte) (client.Client, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid admin kubeconfig: %w", err)
+	}
+	config.Timeout = 30 * time.Second
+	return client.New(config, client.Options{})
+}
+
+// ApplyToGuest server-side applies the object to the guest cluster, taking over the fields set by other managers
+func ApplyToGuest(ctx context.Context, guest client.Client, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, guest.Scheme())
+	if err != nil {
+		return err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	if err := guest.Patch(ctx, obj, client.Apply, client.ForceOwnership, client.FieldOwner(GuestFieldOwner)); err != nil {
+		return fmt.Errorf("failed to apply %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// GuestResourceSetResyncPeriod is how often the resources of the Reconcile mode are applied again, reverting the
+// changes made in the guest clusters
+const GuestResourceSetResyncPeriod = 10 * time.Minute
+
+// errInvalidManifest is returned when the manifests of a ConfigMap or a Secret cannot be decoded
+var errInvalidManifest = errors.New("invalid manifest")
+
+// GuestResourceSetReconciler reconciles a GuestResourceSet object
+type GuestResourceSetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+
+	// GuestClient returns a client of the guest cluster the resources are applied to
+	GuestClient GuestClientFunc
+}
+
+func NewGuestResourceSetReconciler(mgr manager.Manager) *GuestResourceSetReconciler {
+	return &GuestResourceSetReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		log:         log.Log.WithName("guestresourceset-reconciler"),
+		GuestClient: NewGuestClient,
+	}
+}
+
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=guestresourcesets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=guestresourcesets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=controlplanes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// The manifests of the ConfigMaps and Secrets are server-side applied to the guest cluster of every selected
+// ControlPlane with its admin kubeconfig, the result of each ControlPlane is reported in the status. In the ApplyOnce
+// mode a guest cluster the manifests were applied to is skipped. The applied objects are kept in the guest clusters
+// when the GuestResourceSet is deleted or a ControlPlane is no longer selected.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
+func (r *GuestResourceSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	grs := &clusterv1alpha1.GuestResourceSet{}
+	if err := r.Get(ctx, req.NamespacedName, grs); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&grs.Spec.ControlPlaneSelector)
+	if err != nil {
+		return ctrl.Result{}, r.UpdateCondition(ctx, grs, metav1.ConditionFalse, "InvalidSelector", err.Error())
+	}
+
+	objects, hash, err := r.Manifests(ctx, grs)
+	if errors.Is(err, errInvalidManifest) {
+		r.log.Error(err, "failed to decode manifests", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdateCondition(ctx, grs, metav1.ConditionFalse, "InvalidManifest", err.Error())
+	}
+	if apierrors.IsNotFound(err) {
+		r.log.Info("Resource not found, requeing", "name", req.Name, "namespace", req.Namespace, "error", err.Error())
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, grs, metav1.ConditionFalse, "ResourcesPending", fmt.Sprintf("Waiting for the resources: %s", err))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	cps := &clusterv1alpha1.ControlPlaneList{}
+	if err := r.List(ctx, cps, client.InNamespace(req.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return ctrl.Result{}, err
+	}
+	sort.Slice(cps.Items, func(i, j int) bool { return cps.Items[i].Name < cps.Items[j].Name })
+
+	previous := map[string]clusterv1alpha1.GuestResourceSetControlPlane{}
+	for _, cp := range grs.Status.ControlPlanes {
+		previous[cp.Name] = cp
+	}
+
+	results := []clusterv1alpha1.GuestResourceSetControlPlane{}
+	failed := []string{}
+	for i := range cps.Items {
+		cp := &cps.Items[i]
+		if !cp.DeletionTimestamp.IsZero() {
+			continue
+		}
+		result, found := previous[cp.Name]
+		// A hash is only recorded once the manifests were applied
+		if !found || grs.Spec.Mode != clusterv1alpha1.GuestResourceSetModeApplyOnce || result.Hash == "" {
+			result = r.ApplyToControlPlane(ctx, cp, objects, hash, result)
+		}
+		if !result.Applied {
+			failed = append(failed, cp.Name)
+		}
+		results = append(results, result)
+	}
+
+	grs.Status.ObservedGeneration = grs.Generation
+	grs.Status.ControlPlanes = results
+	if len(failed) > 0 {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, r.UpdateCondition(ctx, grs, metav1.ConditionFalse, "ApplyFailed", fmt.Sprintf("Failed to apply the resources to %s", strings.Join(failed, ", ")))
+	}
+
+	result := ctrl.Result{}
+	if grs.Spec.Mode != clusterv1alpha1.GuestResourceSetModeApplyOnce {
+		result.RequeueAfter = GuestResourceSetResyncPeriod
+	}
+	return result, r.UpdateCondition(ctx, grs, metav1.ConditionTrue, "Applied", fmt.Sprintf("Applied %d objects to %d ControlPlanes", len(objects), len(results)))
+}
+
+// Manifests decodes the objects of the ConfigMaps and Secrets of the GuestResourceSet, Namespaces and
+// CustomResourceDefinitions first, and returns them with the hash of the manifests
+func (r *GuestResourceSetReconciler) Manifests(ctx context.Context, grs *clusterv1alpha1.GuestResourceSet) ([]*unstructured.Unstructured, string, error) {
+	objects := []*unstructured.Unstructured{}
+	hash := sha256.New()
+	for _, resource := range grs.Spec.Resources {
+		data := map[string][]byte{}
+		key := types.NamespacedName{Name: resource.Name, Namespace: grs.Namespace}
+		switch resource.Kind {
+		case clusterv1alpha1.GuestResourceKindSecret:
+			secret := &corev1.Secret{}
+			if err := r.Get(ctx, key, secret); err != nil {
+				return nil, "", err
+			}
+			data = secret.Data
+		default:
+			cm := &corev1.ConfigMap{}
+			if err := r.Get(ctx, key, cm); err != nil {
+				return nil, "", err
+			}
+			for k, v := range cm.Data {
+				data[k] = []byte(v)
+			}
+		}
+
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(hash, "%s/%s/%s\n", resource.Kind, resource.Name, k)
+			hash.Write(data[k])
+
+			decoded, err := decodeManifests(data[k])
+			if err != nil {
+				return nil, "", fmt.Errorf("%w in key %s of %s %s: %s", errInvalidManifest, k, resource.Kind, resource.Name, err)
+			}
+			objects = append(objects, decoded...)
+		}
+	}
+
+	// The namespaced objects and custom resources need their Namespace and CustomResourceDefinition
+	order := func(obj *unstructured.Unstructured) int {
+		switch obj.GroupVersionKind().GroupKind().String() {
+		case "CustomResourceDefinition.apiextensions.k8s.io":
+			return 0
+		case "Namespace":
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(objects, func(i, j int) bool { return order(objects[i]) < order(objects[j]) })
+	return objects, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// decodeManifests decodes the YAML documents or the JSON objects of the data
+func decodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	objects := []*unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, err
+		}
+		// Empty documents
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("apiVersion, kind and metadata.name are required")
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// ApplyToControlPlane applies the objects to the guest cluster of the ControlPlane and returns the result, the
+// previous one is kept when the guest cluster is unreachable
+func (r *GuestResourceSetReconciler) ApplyToControlPlane(ctx context.Context, cp *clusterv1alpha1.ControlPlane, objects []*unstructured.Unstructured, hash string, previous clusterv1alpha1.GuestResourceSetControlPlane) clusterv1alpha1.GuestResourceSetControlPlane {
+	result := previous
+	result.Name = cp.Name
+	result.Applied = false
+
+	guest, err := r.GuestClient(ctx, r.Client, cp)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	for _, obj := range objects {
+		obj = obj.DeepCopy()
+		if obj.GetNamespace() == "" {
+			mapping, err := guest.RESTMapper().RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+			if err != nil {
+				result.Message = fmt.Sprintf("failed to apply %s %s: %s", obj.GetKind(), obj.GetName(), err)
+				return result
+			}
+			if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				obj.SetNamespace(metav1.NamespaceDefault)
+			}
+		}
+		if err := ApplyToGuest(ctx, guest, obj); err != nil {
+			r.log.Error(err, "failed to apply resource to the guest cluster", "name", cp.Name, "namespace", cp.Namespace)
+			result.Message = err.Error()
+			return result
+		}
+	}
+
+	// The time is kept while the same manifests are applied again, the status only changes with them
+	if !previous.Applied || previous.Hash != hash || previous.LastAppliedTime == nil {
+		now := metav1.Now()
+		result.LastAppliedTime = &now
+	}
+	result.Applied = true
+	result.Hash = hash
+	result.Objects = int32(len(objects))
+	result.Message = ""
+	return result
+}
+
+func (r *GuestResourceSetReconciler) UpdateCondition(ctx context.Context, grs *clusterv1alpha1.GuestResourceSet, status metav1.ConditionStatus, reason, message string) error {
+	meta.SetStatusCondition(&grs.Status.Conditions, metav1.Condition{
+		Type:               clusterv1alpha1.ConditionReady,
+		Status:             status,
+		ObservedGeneration: grs.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if err := r.Status().Update(ctx, grs); err != nil {
+		r.log.Error(err, "failed to update GuestResourceSet status", "name", grs.Name, "namespace", grs.Namespace)
+		return err
+	}
+	return nil
+}
+
+// RequestsForControlPlane maps a ControlPlane to the GuestResourceSets selecting it
+func (r *GuestResourceSetReconciler) RequestsForControlPlane(obj client.Object) []reconcile.Request {
+	sets := &clusterv1alpha1.GuestResourceSetList{}
+	if err := r.List(context.Background(), sets, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.log.Error(err, "failed to list GuestResourceSets", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, grs := range sets.Items {
+		selector, err := metav1.LabelSelectorAsSelector(&grs.Spec.ControlPlaneSelector)
+		if err == nil && selector.Matches(k8slabels.Set(obj.GetLabels())) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: grs.Name, Namespace: grs.Namespace}})
+		}
+	}
+	return requests
+}
+
+// requestsForResource maps a ConfigMap or a Secret to the GuestResourceSets listing it
+func (r *GuestResourceSetReconciler) requestsForResource(kind clusterv1alpha1.GuestResourceKind) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		sets := &clusterv1alpha1.GuestResourceSetList{}
+		if err := r.List(context.Background(), sets, client.InNamespace(obj.GetNamespace())); err != nil {
+			r.log.Error(err, "failed to list GuestResourceSets", "namespace", obj.GetNamespace())
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for _, grs := range sets.Items {
+			for _, resource := range grs.Spec.Resources {
+				if resource.Kind == kind && resource.Name == obj.GetName() {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: grs.Name, Namespace: grs.Namespace}})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GuestResourceSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1alpha1.GuestResourceSet{}).
+		Watches(&source.Kind{Type: &clusterv1alpha1.ControlPlane{}}, handler.EnqueueRequestsFromMapFunc(r.RequestsForControlPlane)).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForResource(clusterv1alpha1.GuestResourceKindConfigMap))).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForResource(clusterv1alpha1.GuestResourceKindSecret))).
+		Complete(r)
+}
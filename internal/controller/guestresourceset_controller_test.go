@@ -0,0 +1,185 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+var _ = Describe("GuestResourceSet controller", Ordered, func() {
+	ctx := context.Background()
+	nsName := "guest-resources"
+
+	manifests := func(value string) string {
+		return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: tenant-settings
+  namespace: tenant-system
+data:
+  value: ` + value + `
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: tenant-system
+`
+	}
+
+	BeforeAll(func() {
+		By("Creating client namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+
+		By("Creating a selected and an unselected ControlPlane")
+		for name, labels := range map[string]map[string]string{"demo": {"tier": "standard"}, "other": nil} {
+			cp := &clusterv1alpha1.ControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsName, Labels: labels},
+				Spec: clusterv1alpha1.ControlPlaneSpec{
+					Version: "v1.27.5",
+					Network: clusterv1alpha1.ControlPlaneNetwork{PodCIDRs: []string{"10.200.0.0/16"}, ServiceCIDRs: []string{"10.0.0.0/24"}},
+					PKI:     clusterv1alpha1.PkiSpec{Backend: clusterv1alpha1.PkiBackendNative},
+				},
+			}
+			Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+		}
+
+		By("Creating the manifests")
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant-defaults", Namespace: nsName},
+			Data:       map[string]string{"settings.yaml": manifests("v1")},
+		}
+		Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant-secrets", Namespace: nsName},
+			StringData: map[string]string{"secret.yaml": "apiVersion: v1\nkind: Secret\nmetadata:\n  name: tenant-credentials\nstringData:\n  password: changeme\n"},
+		}
+		Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+	})
+
+	applied := func(name string) func() *clusterv1alpha1.GuestResourceSet {
+		return func() *clusterv1alpha1.GuestResourceSet {
+			grs := &clusterv1alpha1.GuestResourceSet{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: nsName}, grs); err != nil {
+				return nil
+			}
+			if !meta.IsStatusConditionTrue(grs.Status.Conditions, clusterv1alpha1.ConditionReady) {
+				return nil
+			}
+			return grs
+		}
+	}
+
+	settings := func() string {
+		cm := &corev1.ConfigMap{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: "tenant-settings", Namespace: "tenant-system"}, cm); err != nil {
+			return ""
+		}
+		return cm.Data["value"]
+	}
+
+	It("Applies the manifests to the selected guest clusters", func() {
+		grs := &clusterv1alpha1.GuestResourceSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant-defaults", Namespace: nsName},
+			Spec: clusterv1alpha1.GuestResourceSetSpec{
+				ControlPlaneSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "standard"}},
+				Resources: []clusterv1alpha1.GuestResource{
+					{Kind: clusterv1alpha1.GuestResourceKindConfigMap, Name: "tenant-defaults"},
+					{Kind: clusterv1alpha1.GuestResourceKindSecret, Name: "tenant-secrets"},
+				},
+				Mode: clusterv1alpha1.GuestResourceSetModeReconcile,
+			},
+		}
+		Expect(k8sClient.Create(ctx, grs)).Should(Succeed())
+
+		Eventually(applied("tenant-defaults"), timeout, interval).ShouldNot(BeNil())
+		grs = applied("tenant-defaults")()
+		Expect(grs.Status.ControlPlanes).To(HaveLen(1))
+		Expect(grs.Status.ControlPlanes[0].Name).To(Equal("demo"))
+		Expect(grs.Status.ControlPlanes[0].Applied).To(BeTrue())
+		Expect(grs.Status.ControlPlanes[0].Objects).To(Equal(int32(3)))
+		Expect(grs.Status.ControlPlanes[0].LastAppliedTime).NotTo(BeNil())
+
+		Expect(settings()).To(Equal("v1"))
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "tenant-credentials", Namespace: metav1.NamespaceDefault}, secret)).Should(Succeed())
+		Expect(secret.Labels).To(BeEmpty())
+	})
+
+	It("Applies the manifests again when they change", func() {
+		cm := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "tenant-defaults", Namespace: nsName}, cm)).Should(Succeed())
+		cm.Data["settings.yaml"] = manifests("v2")
+		Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+
+		Eventually(settings, timeout, interval).Should(Equal("v2"))
+	})
+
+	It("Applies the manifests only once in the ApplyOnce mode", func() {
+		grs := &clusterv1alpha1.GuestResourceSet{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "tenant-defaults", Namespace: nsName}, grs)).Should(Succeed())
+		grs.Spec.Mode = clusterv1alpha1.GuestResourceSetModeApplyOnce
+		Expect(k8sClient.Update(ctx, grs)).Should(Succeed())
+		Eventually(func() int64 {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "tenant-defaults", Namespace: nsName}, grs)).Should(Succeed())
+			return grs.Status.ObservedGeneration
+		}, timeout, interval).Should(Equal(grs.Generation))
+
+		cm := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "tenant-defaults", Namespace: nsName}, cm)).Should(Succeed())
+		cm.Data["settings.yaml"] = manifests("v3")
+		Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+
+		Consistently(settings, 2*interval, interval).Should(Equal("v2"))
+	})
+
+	It("Reports the manifests that cannot be decoded", func() {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: nsName},
+			Data:       map[string]string{"broken.yaml": "kind: ConfigMap\nmetadata:\n  name: broken\n"},
+		}
+		Expect(k8sClient.Create(ctx, cm)).Should(Succeed())
+		grs := &clusterv1alpha1.GuestResourceSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: nsName},
+			Spec: clusterv1alpha1.GuestResourceSetSpec{
+				ControlPlaneSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "standard"}},
+				Resources:            []clusterv1alpha1.GuestResource{{Kind: clusterv1alpha1.GuestResourceKindConfigMap, Name: "broken"}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, grs)).Should(Succeed())
+
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "broken", Namespace: nsName}, grs); err != nil {
+				return ""
+			}
+			if c := meta.FindStatusCondition(grs.Status.Conditions, clusterv1alpha1.ConditionReady); c != nil {
+				return c.Reason
+			}
+			return ""
+		}, timeout, interval).Should(Equal("InvalidManifest"))
+	})
+})
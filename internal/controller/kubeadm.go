@@ -0,0 +1,320 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// ConfigMaps kubeadm join reads from the guest cluster
+const (
+	// kube-public/cluster-info holds the endpoint and CA of the cluster, signed with the bootstrap tokens
+	ClusterInfoConfigMap = "cluster-info"
+	// kube-system/kubeadm-config holds the ClusterConfiguration
+	KubeadmConfigConfigMap = "kubeadm-config"
+	// kube-system/kubelet-config holds the KubeletConfiguration written to the nodes
+	KubeletConfigConfigMap = "kubelet-config"
+
+	// clusterInfoSignaturePrefix prefixes the keys of the cluster-info signatures, followed by the token ID
+	clusterInfoSignaturePrefix = "jws-kubeconfig-"
+)
+
+// Paths of the nodes joined with kubeadm
+const (
+	kubeadmCertificatesDir = "/etc/kubernetes/pki"
+	kubeadmStaticPodPath   = "/etc/kubernetes/manifests"
+)
+
+// kubeadmClusterConfiguration is the kubeadm.k8s.io/v1beta3 ClusterConfiguration
+type kubeadmClusterConfiguration struct {
+	APIVersion           string            `json:"apiVersion"`
+	Kind                 string            `json:"kind"`
+	ClusterName          string            `json:"clusterName"`
+	KubernetesVersion    string            `json:"kubernetesVersion"`
+	ControlPlaneEndpoint string            `json:"controlPlaneEndpoint"`
+	CertificatesDir      string            `json:"certificatesDir"`
+	ImageRepository      string            `json:"imageRepository"`
+	Networking           kubeadmNetworking `json:"networking"`
+}
+
+type kubeadmNetworking struct {
+	DNSDomain     string `json:"dnsDomain"`
+	PodSubnet     string `json:"podSubnet"`
+	ServiceSubnet string `json:"serviceSubnet"`
+}
+
+// kubeletConfiguration is the subset of the kubelet.config.k8s.io/v1beta1 KubeletConfiguration kubeadm sets
+type kubeletConfiguration struct {
+	APIVersion         string                `json:"apiVersion"`
+	Kind               string                `json:"kind"`
+	Authentication     kubeletAuthentication `json:"authentication"`
+	Authorization      kubeletAuthorization  `json:"authorization"`
+	CgroupDriver       string                `json:"cgroupDriver"`
+	ClusterDNS         []string              `json:"clusterDNS"`
+	ClusterDomain      string                `json:"clusterDomain"`
+	RotateCertificates bool                  `json:"rotateCertificates"`
+	StaticPodPath      string                `json:"staticPodPath"`
+}
+
+type kubeletAuthentication struct {
+	Anonymous struct {
+		Enabled bool `json:"enabled"`
+	} `json:"anonymous"`
+	Webhook struct {
+		Enabled bool `json:"enabled"`
+	} `json:"webhook"`
+	X509 struct {
+		ClientCAFile string `json:"clientCAFile"`
+	} `json:"x509"`
+}
+
+type kubeletAuthorization struct {
+	Mode string `json:"mode"`
+}
+
+// GenerateClusterInfo returns the kubeconfig of cluster-info, it has the endpoint and CA of the cluster but no user
+func GenerateClusterInfo(endpoint string, caPEM []byte) ([]byte, error) {
+	return clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"": {
+				Server:                   endpoint,
+				CertificateAuthorityData: caPEM,
+			},
+		},
+	})
+}
+
+// GenerateKubeadmClusterConfiguration returns the ClusterConfiguration of the ControlPlane
+func GenerateKubeadmClusterConfiguration(cp clusterv1alpha1.ControlPlane) ([]byte, error) {
+	// JSON is valid YAML, kubeadm accepts both
+	return json.Marshal(kubeadmClusterConfiguration{
+		APIVersion:           "kubeadm.k8s.io/v1beta3",
+		Kind:                 "ClusterConfiguration",
+		ClusterName:          cp.Name,
+		KubernetesVersion:    cp.Spec.Version,
+		ControlPlaneEndpoint: strings.TrimPrefix(cp.Status.Endpoint, "https://"),
+		CertificatesDir:      kubeadmCertificatesDir,
+		ImageRepository:      "registry.k8s.io",
+		Networking: kubeadmNetworking{
+			DNSDomain:     cp.Spec.Network.DNSDomain,
+			PodSubnet:     strings.Join(cp.Spec.Network.PodCIDRs, ","),
+			ServiceSubnet: strings.Join(cp.Spec.Network.ServiceCIDRs, ","),
+		},
+	})
+}
+
+// GenerateKubeletConfiguration returns the KubeletConfiguration of the nodes of the ControlPlane
+func GenerateKubeletConfiguration(cp clusterv1alpha1.ControlPlane) ([]byte, error) {
+	config := kubeletConfiguration{
+		APIVersion:         "kubelet.config.k8s.io/v1beta1",
+		Kind:               "KubeletConfiguration",
+		Authorization:      kubeletAuthorization{Mode: "Webhook"},
+		CgroupDriver:       "systemd",
+		ClusterDNS:         cp.Spec.Network.DNSServiceIPs(),
+		ClusterDomain:      cp.Spec.Network.DNSDomain,
+		RotateCertificates: true,
+		StaticPodPath:      kubeadmStaticPodPath,
+	}
+	config.Authentication.Webhook.Enabled = true
+	config.Authentication.X509.ClientCAFile = kubeadmCertificatesDir + "/ca.crt"
+	return json.Marshal(config)
+}
+
+// ReconcileKubeadm publishes the ConfigMaps kubeadm join reads in the guest cluster, and the RBAC letting the
+// bootstrap tokens and the nodes read them. The signatures of cluster-info are left to SignClusterInfo.
+func ReconcileKubeadm(ctx context.Context, guest client.Client, cp clusterv1alpha1.ControlPlane, caPEM []byte) error {
+	clusterInfo, err := GenerateClusterInfo(cp.Status.Endpoint, caPEM)
+	if err != nil {
+		return err
+	}
+	clusterConfiguration, err := GenerateKubeadmClusterConfiguration(cp)
+	if err != nil {
+		return err
+	}
+	kubeletConfiguration, err := GenerateKubeletConfiguration(cp)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ClusterInfoConfigMap, Namespace: metav1.NamespacePublic}}
+	if _, err := controllerutil.CreateOrPatch(ctx, guest, cm, func() error {
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		// The signatures of a previous content are invalid, every token signs the new one again
+		if cm.Data["kubeconfig"] != string(clusterInfo) {
+			for key := range cm.Data {
+				if strings.HasPrefix(key, clusterInfoSignaturePrefix) {
+					delete(cm.Data, key)
+				}
+			}
+		}
+		cm.Data["kubeconfig"] = string(clusterInfo)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	configMaps := map[string]map[string]string{
+		KubeadmConfigConfigMap: {"ClusterConfiguration": string(clusterConfiguration)},
+		KubeletConfigConfigMap: {"kubelet": string(kubeletConfiguration)},
+	}
+	for name, data := range configMaps {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceSystem}}
+		desired := data
+		if _, err := controllerutil.CreateOrPatch(ctx, guest, cm, func() error {
+			cm.Data = desired
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return reconcileKubeadmRBAC(ctx, guest)
+}
+
+// reconcileKubeadmRBAC lets anonymous users discover the cluster through cluster-info, and the bootstrap tokens and nodes
+// read the kubeadm and kubelet configurations and check whether their node already exists
+func reconcileKubeadmRBAC(ctx context.Context, guest client.Client) error {
+	joining := []rbacv1.Subject{
+		{Kind: rbacv1.GroupKind, Name: "system:bootstrappers", APIGroup: rbacv1.GroupName},
+		{Kind: rbacv1.GroupKind, Name: "system:nodes", APIGroup: rbacv1.GroupName},
+	}
+	anonymous := []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "system:anonymous", APIGroup: rbacv1.GroupName}}
+
+	roles := []struct {
+		name      string
+		namespace string
+		rules     []rbacv1.PolicyRule
+		subjects  []rbacv1.Subject
+	}{
+		{"kubeadm:bootstrap-signer-clusterinfo", metav1.NamespacePublic, configMapReader(ClusterInfoConfigMap), anonymous},
+		{"kubeadm:nodes-kubeadm-config", metav1.NamespaceSystem, configMapReader(KubeadmConfigConfigMap), joining},
+		{"kubeadm:kubelet-config", metav1.NamespaceSystem, configMapReader(KubeletConfigConfigMap), joining},
+	}
+	for _, r := range roles {
+		role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: r.name, Namespace: r.namespace}}
+		rules := r.rules
+		if _, err := controllerutil.CreateOrPatch(ctx, guest, role, func() error {
+			role.Rules = rules
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: r.name, Namespace: r.namespace}}
+		subjects, name := r.subjects, r.name
+		if _, err := controllerutil.CreateOrPatch(ctx, guest, binding, func() error {
+			binding.Subjects = subjects
+			binding.RoleRef = rbacv1.RoleRef{Kind: "Role", Name: name, APIGroup: rbacv1.GroupName}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	getNodes := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "kubeadm:get-nodes"}}
+	if _, err := controllerutil.CreateOrPatch(ctx, guest, getNodes, func() error {
+		getNodes.Rules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}}}
+		return nil
+	}); err != nil {
+		return err
+	}
+	binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "kubeadm:get-nodes"}}
+	_, err := controllerutil.CreateOrPatch(ctx, guest, binding, func() error {
+		binding.Subjects = joining[:1]
+		binding.RoleRef = rbacv1.RoleRef{Kind: "ClusterRole", Name: "kubeadm:get-nodes", APIGroup: rbacv1.GroupName}
+		return nil
+	})
+	return err
+}
+
+func configMapReader(name string) []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{name}, Verbs: []string{"get"}}}
+}
+
+// SignClusterInfo adds the signature of cluster-info with the token, or removes it when sign is false.
+// kube-controller-manager signs it too with the tokens allowed to, both signatures are the same.
+func SignClusterInfo(ctx context.Context, guest client.Client, tokenID, tokenSecret string, sign bool) error {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ClusterInfoConfigMap, Namespace: metav1.NamespacePublic}}
+	if err := guest.Get(ctx, client.ObjectKeyFromObject(cm), cm); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	patch := client.MergeFrom(cm.DeepCopy())
+	key := clusterInfoSignaturePrefix + tokenID
+	if sign {
+		cm.Data[key] = DetachedSignature(cm.Data["kubeconfig"], tokenID, tokenSecret)
+	} else {
+		delete(cm.Data, key)
+	}
+	if err := guest.Patch(ctx, cm, patch); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// DetachedSignature returns the JWS of the content signed with HS256 and the token secret, without its payload
+// (header..signature). It is the signature kube-controller-manager writes and kubeadm join verifies.
+func DetachedSignature(content, tokenID, tokenSecret string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"alg":"HS256","kid":%q}`, tokenID)))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(content))
+
+	mac := hmac.New(sha256.New, []byte(tokenSecret))
+	mac.Write([]byte(header + "." + payload))
+	return header + ".." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// CACertHash returns the hash of the public key of the CA kubeadm join pins with --discovery-token-ca-cert-hash
+func CACertHash(caPEM []byte) (string, error) {
+	block, _ := pem.Decode(caPEM)
+	if block == nil {
+		return "", errors.New("no certificate found in the CA")
+	}
+	ca, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(ca.RawSubjectPublicKeyInfo)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// KubeadmJoinCommand returns the command joining a node to the cluster of the endpoint with the token
+func KubeadmJoinCommand(endpoint, token, caCertHash string) string {
+	return fmt.Sprintf("kubeadm join %s --token %s --discovery-token-ca-cert-hash %s", strings.TrimPrefix(endpoint, "https://"), token, caCertHash)
+}
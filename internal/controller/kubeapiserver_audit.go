@@ -0,0 +1,133 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// Directories the audit policy, log, webhook kubeconfig and client certificate are mounted at in the kube-apiserver container
+const (
+	auditPolicyDir        = "/etc/kubernetes/audit-policy"
+	auditLogDir           = "/var/log/kubernetes/audit"
+	auditWebhookDir       = "/etc/kubernetes/audit-webhook"
+	auditWebhookClientDir = "/etc/kubernetes/audit-webhook-client"
+)
+
+// AuditPolicyConfigMapName is the ConfigMap the inline audit policy of a KubeAPIServer is written to
+func AuditPolicyConfigMapName(kas clusterv1alpha1.KubeAPIServer) string {
+	return fmt.Sprintf("%s-audit-policy", kas.Name)
+}
+
+// AuditWebhookSecretName is the Secret holding the kubeconfig of the audit webhook backend of a KubeAPIServer
+func AuditWebhookSecretName(kas clusterv1alpha1.KubeAPIServer) string {
+	return fmt.Sprintf("%s-audit-webhook", kas.Name)
+}
+
+// GenerateAuditWebhookKubeconfig returns the kubeconfig kube-apiserver reaches the audit webhook with. The client
+// certificate is read from its mounted Secret, so that a renewed certificate rolls kube-apiserver.
+func GenerateAuditWebhookKubeconfig(webhook clusterv1alpha1.AuditWebhookBackend) ([]byte, error) {
+	user := &clientcmdapi.AuthInfo{}
+	if webhook.ClientSecretName != "" {
+		user.ClientCertificate = auditWebhookClientDir + "/tls.crt"
+		user.ClientKey = auditWebhookClientDir + "/tls.key"
+	}
+
+	return clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"audit": {
+				Server:                   webhook.Server,
+				CertificateAuthorityData: []byte(webhook.CABundle),
+			},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{"audit": user},
+		Contexts: map[string]*clientcmdapi.Context{
+			"audit": {Cluster: "audit", AuthInfo: "audit"},
+		},
+		CurrentContext: "audit",
+	})
+}
+
+// configureAudit mounts the audit policy and backends in the kube-apiserver container and sets its --audit-* flags
+func configureAudit(kas clusterv1alpha1.KubeAPIServer, podSpec *corev1.PodSpec) {
+	audit := kas.Spec.Audit
+	if audit == nil {
+		return
+	}
+
+	// The ConfigMap of the user is mounted as is
+	policy := CoaleseString(audit.PolicyConfigMapName, AuditPolicyConfigMapName(kas))
+	volumes := []corev1.Volume{
+		{Name: "audit-policy", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: policy}}}},
+	}
+	mounts := []corev1.VolumeMount{{Name: "audit-policy", MountPath: auditPolicyDir}}
+	flags := []string{"--audit-policy-file=" + auditPolicyDir + "/policy.yaml"}
+	env := []corev1.EnvVar{}
+
+	if log := audit.Log; log != nil {
+		volumes = append(volumes, corev1.Volume{Name: "audit-log", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: log.ClaimName}}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "audit-log", MountPath: auditLogDir})
+		// Replicas share the volume, each one writes to its own directory
+		env = append(env, corev1.EnvVar{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}})
+		flags = append(flags,
+			"--audit-log-path="+auditLogDir+"/$(POD_NAME)/audit.log",
+			"--audit-log-format="+CoaleseString(log.Format, "json"),
+			"--audit-log-maxage="+strconv.Itoa(int(log.MaxAge)),
+			"--audit-log-maxbackup="+strconv.Itoa(int(log.MaxBackups)),
+			"--audit-log-maxsize="+strconv.Itoa(int(log.MaxSize)),
+		)
+	}
+
+	if webhook := audit.Webhook; webhook != nil {
+		volumes = append(volumes, corev1.Volume{Name: "audit-webhook", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: AuditWebhookSecretName(kas)}}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "audit-webhook", MountPath: auditWebhookDir})
+		if webhook.ClientSecretName != "" {
+			volumes = append(volumes, corev1.Volume{Name: "audit-webhook-client", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: webhook.ClientSecretName}}})
+			mounts = append(mounts, corev1.VolumeMount{Name: "audit-webhook-client", MountPath: auditWebhookClientDir})
+		}
+		flags = append(flags,
+			"--audit-webhook-config-file="+auditWebhookDir+"/kubeconfig.yml",
+			"--audit-webhook-mode="+CoaleseString(webhook.Mode, "batch"),
+		)
+		if webhook.BatchMaxSize > 0 {
+			flags = append(flags, "--audit-webhook-batch-max-size="+strconv.Itoa(int(webhook.BatchMaxSize)))
+		}
+		if webhook.BatchMaxWait != nil {
+			flags = append(flags, "--audit-webhook-batch-max-wait="+webhook.BatchMaxWait.Duration.String())
+		}
+		if webhook.InitialBackoff != nil {
+			flags = append(flags, "--audit-webhook-initial-backoff="+webhook.InitialBackoff.Duration.String())
+		}
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, volumes...)
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name != "kube-apiserver" {
+			continue
+		}
+		podSpec.Containers[i].Command = append(podSpec.Containers[i].Command, flags...)
+		podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, mounts...)
+		podSpec.Containers[i].Env = append(podSpec.Containers[i].Env, env...)
+	}
+}
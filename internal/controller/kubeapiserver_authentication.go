@@ -0,0 +1,162 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/version"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// Directory where the authentication ConfigMap is mounted in the kube-apiserver container
+const authenticationDir = "/etc/kubernetes/authentication"
+
+// AuthenticationConfigMapName is the ConfigMap holding the authentication configuration of a KubeAPIServer
+func AuthenticationConfigMapName(kas clusterv1alpha1.KubeAPIServer) string {
+	return fmt.Sprintf("%s-authentication", kas.Name)
+}
+
+// The subset of the apiserver.config.k8s.io AuthenticationConfiguration set by the operator
+type authenticationConfiguration struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	JWT        []jwtAuthenticator `json:"jwt"`
+}
+
+type jwtAuthenticator struct {
+	Issuer               jwtIssuer             `json:"issuer"`
+	ClaimValidationRules []claimValidationRule `json:"claimValidationRules,omitempty"`
+	ClaimMappings        claimMappings         `json:"claimMappings"`
+}
+
+type jwtIssuer struct {
+	URL                  string   `json:"url"`
+	Audiences            []string `json:"audiences"`
+	CertificateAuthority string   `json:"certificateAuthority,omitempty"`
+}
+
+type claimValidationRule struct {
+	Claim         string `json:"claim"`
+	RequiredValue string `json:"requiredValue"`
+}
+
+type claimMappings struct {
+	Username prefixedClaim  `json:"username"`
+	Groups   *prefixedClaim `json:"groups,omitempty"`
+}
+
+type prefixedClaim struct {
+	Claim  string `json:"claim"`
+	Prefix string `json:"prefix"`
+}
+
+// authenticationAPIVersion is the AuthenticationConfiguration API version served by a kube-apiserver version
+func authenticationAPIVersion(v string) string {
+	parsed, err := version.ParseSemantic(v)
+	if err == nil && parsed.AtLeast(version.MustParseSemantic("v1.34.0")) {
+		return "apiserver.config.k8s.io/v1"
+	}
+	return "apiserver.config.k8s.io/v1beta1"
+}
+
+// sortedClaims returns the required claims ordered by name, so that the rendered configuration is stable
+func sortedClaims(claims map[string]string) []string {
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthenticationFlags returns the kube-apiserver flags configuring the identity providers.
+//
+// From v1.30 the issuers are loaded from an AuthenticationConfiguration file. Older versions only get the first
+// issuer as --oidc-* flags, the webhooks reject more than one.
+func AuthenticationFlags(kas clusterv1alpha1.KubeAPIServer) []string {
+	issuers := kas.Spec.Authentication.OIDC
+	if len(issuers) == 0 {
+		return nil
+	}
+	if clusterv1alpha1.SupportsStructuredAuthentication(kas.Spec.Version) {
+		return []string{"--authentication-config=" + authenticationDir + "/authentication-config.json"}
+	}
+
+	o := issuers[0]
+	flags := []string{
+		"--oidc-issuer-url=" + o.IssuerURL,
+		"--oidc-client-id=" + o.ClientID,
+		"--oidc-username-claim=" + CoaleseString(o.UsernameClaim, "sub"),
+		"--oidc-username-prefix=" + CoaleseString(o.EffectiveUsernamePrefix(), "-"),
+	}
+	if o.GroupsClaim != "" {
+		flags = append(flags, "--oidc-groups-claim="+o.GroupsClaim)
+		if o.GroupsPrefix != "" {
+			flags = append(flags, "--oidc-groups-prefix="+o.GroupsPrefix)
+		}
+	}
+	for _, name := range sortedClaims(o.RequiredClaims) {
+		flags = append(flags, fmt.Sprintf("--oidc-required-claim=%s=%s", name, o.RequiredClaims[name]))
+	}
+	if o.CABundle != "" {
+		flags = append(flags, "--oidc-ca-file="+authenticationDir+"/oidc-ca.crt")
+	}
+	return flags
+}
+
+// GenerateAuthenticationConfig returns the content of the authentication ConfigMap, holding the files referenced by AuthenticationFlags
+func GenerateAuthenticationConfig(kas clusterv1alpha1.KubeAPIServer) (map[string]string, error) {
+	issuers := kas.Spec.Authentication.OIDC
+	if !clusterv1alpha1.SupportsStructuredAuthentication(kas.Spec.Version) {
+		data := map[string]string{}
+		if len(issuers) > 0 && issuers[0].CABundle != "" {
+			data["oidc-ca.crt"] = issuers[0].CABundle
+		}
+		return data, nil
+	}
+
+	config := authenticationConfiguration{
+		APIVersion: authenticationAPIVersion(kas.Spec.Version),
+		Kind:       "AuthenticationConfiguration",
+	}
+	for _, o := range issuers {
+		jwt := jwtAuthenticator{
+			Issuer: jwtIssuer{URL: o.IssuerURL, Audiences: []string{o.ClientID}, CertificateAuthority: o.CABundle},
+			ClaimMappings: claimMappings{
+				Username: prefixedClaim{Claim: CoaleseString(o.UsernameClaim, "sub"), Prefix: o.EffectiveUsernamePrefix()},
+			},
+		}
+		if o.GroupsClaim != "" {
+			jwt.ClaimMappings.Groups = &prefixedClaim{Claim: o.GroupsClaim, Prefix: o.GroupsPrefix}
+		}
+		for _, name := range sortedClaims(o.RequiredClaims) {
+			jwt.ClaimValidationRules = append(jwt.ClaimValidationRules, claimValidationRule{Claim: name, RequiredValue: o.RequiredClaims[name]})
+		}
+		config.JWT = append(config.JWT, jwt)
+	}
+
+	// JSON is valid YAML, kube-apiserver accepts both
+	content, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authentication-config.json": string(content)}, nil
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -30,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -91,9 +93,10 @@ func (r *KubeAPIServerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	konnectivityKubeconfigName := fmt.Sprintf("%s-kubeconfig", kas.Spec.TLS.KonnectivitySecretName)
 	konnectivityKubeconfig := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: konnectivityKubeconfigName, Namespace: req.Namespace}}
 	err := r.CreateOrPatch(ctx, konnectivityKubeconfig, kas, func() error {
+		// Konnectivity server runs next to kube-apiserver in the same pod
 		k, err := GenerateKubeconfigFromSecret(
 			*konnectivityCertSecret,
-			"https://kube-apiserver:6443",
+			"https://127.0.0.1:6443",
 		)
 		if err != nil {
 			r.log.Error(err, "failed to marshal konnectivity kubeconfig", "name", konnectivityKubeconfigName, "namespace", req.Namespace)
@@ -161,11 +164,114 @@ egressSelections:
 		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
 	}
 
+	// Check Etcd client
+	if kas.Spec.TLS.ETCDClientSecretName != "" {
+		if err := r.Get(ctx, types.NamespacedName{Name: kas.Spec.TLS.ETCDClientSecretName, Namespace: req.Namespace}, &corev1.Secret{}); err != nil {
+			r.log.Info("failed to get secret for Etcd client TLS Cert, requeing", "name", kas.Spec.TLS.ETCDClientSecretName, "namespace", req.Namespace)
+			return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		}
+	}
+
+	// Check front-proxy client
+	if kas.Spec.TLS.FrontProxyClientSecretName != "" {
+		if err := r.Get(ctx, types.NamespacedName{Name: kas.Spec.TLS.FrontProxyClientSecretName, Namespace: req.Namespace}, &corev1.Secret{}); err != nil {
+			r.log.Info("failed to get secret for front-proxy client TLS Cert, requeing", "name", kas.Spec.TLS.FrontProxyClientSecretName, "namespace", req.Namespace)
+			return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		}
+	}
+
+	// Identity providers, the ConfigMap is removed once they are all gone
+	authenticationConfig := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: AuthenticationConfigMapName(*kas), Namespace: req.Namespace}}
+	if len(kas.Spec.Authentication.OIDC) > 0 {
+		err = r.CreateOrPatch(ctx, authenticationConfig, kas, func() error {
+			data, err := GenerateAuthenticationConfig(*kas)
+			if err != nil {
+				return err
+			}
+			authenticationConfig.Data = data
+			return nil
+		})
+		if err != nil {
+			r.log.Error(err, "failed to create or patch authentication config", "name", authenticationConfig.Name, "namespace", authenticationConfig.Namespace)
+			return ctrl.Result{}, err
+		}
+	} else if err := r.Delete(ctx, authenticationConfig); client.IgnoreNotFound(err) != nil {
+		r.log.Error(err, "failed to delete authentication config", "name", authenticationConfig.Name, "namespace", authenticationConfig.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	// Audit policy, a ConfigMap referenced by the user is mounted instead
+	audit := kas.Spec.Audit
+	auditPolicy := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: AuditPolicyConfigMapName(*kas), Namespace: req.Namespace}}
+	if audit != nil && audit.Policy != "" {
+		err = r.CreateOrPatch(ctx, auditPolicy, kas, func() error {
+			auditPolicy.Data = map[string]string{"policy.yaml": audit.Policy}
+			return nil
+		})
+		if err != nil {
+			r.log.Error(err, "failed to create or patch audit policy", "name", auditPolicy.Name, "namespace", auditPolicy.Namespace)
+			return ctrl.Result{}, err
+		}
+	} else if err := r.Delete(ctx, auditPolicy); client.IgnoreNotFound(err) != nil {
+		r.log.Error(err, "failed to delete audit policy", "name", auditPolicy.Name, "namespace", auditPolicy.Namespace)
+		return ctrl.Result{}, err
+	}
+	if audit != nil && audit.PolicyConfigMapName != "" {
+		if err := r.Get(ctx, types.NamespacedName{Name: audit.PolicyConfigMapName, Namespace: req.Namespace}, &corev1.ConfigMap{}); err != nil {
+			r.log.Info("failed to get audit policy ConfigMap, requeing", "name", audit.PolicyConfigMapName, "namespace", req.Namespace)
+			return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		}
+	}
+
+	// Audit webhook kubeconfig
+	auditWebhook := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: AuditWebhookSecretName(*kas), Namespace: req.Namespace}}
+	if audit != nil && audit.Webhook != nil {
+		if audit.Webhook.ClientSecretName != "" {
+			if err := r.Get(ctx, types.NamespacedName{Name: audit.Webhook.ClientSecretName, Namespace: req.Namespace}, &corev1.Secret{}); err != nil {
+				r.log.Info("failed to get secret for audit webhook client TLS Cert, requeing", "name", audit.Webhook.ClientSecretName, "namespace", req.Namespace)
+				return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+			}
+		}
+
+		err = r.CreateOrPatch(ctx, auditWebhook, kas, func() error {
+			k, err := GenerateAuditWebhookKubeconfig(*audit.Webhook)
+			if err != nil {
+				return err
+			}
+			auditWebhook.Data = map[string][]byte{"kubeconfig.yml": k}
+			return nil
+		})
+		if err != nil {
+			r.log.Error(err, "failed to create or patch audit webhook kubeconfig", "name", auditWebhook.Name, "namespace", auditWebhook.Namespace)
+			return ctrl.Result{}, err
+		}
+	} else if err := r.Delete(ctx, auditWebhook); client.IgnoreNotFound(err) != nil {
+		r.log.Error(err, "failed to delete audit webhook kubeconfig", "name", auditWebhook.Name, "namespace", auditWebhook.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	// Encryption keys, rotated one step at a time
+	retryEncryption := false
+	if kas.Spec.Encryption != nil {
+		retryEncryption, err = r.ReconcileEncryption(ctx, kas)
+		if err != nil {
+			r.log.Error(err, "failed to reconcile encryption keys", "name", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Deployment APIServer & Konnectivity
+	deployment := r.GenerateDeployment(*kas)
+	if err := SetChecksumAnnotation(ctx, r.Client, req.Namespace, &deployment.Spec.Template); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.log.Info("failed to compute mounted secrets checksum, requeing", "error", err.Error(), "name", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
 	foundDeployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: kas.Spec.Deployment.Name, Namespace: req.Namespace}}
 	err = r.CreateOrPatch(ctx, foundDeployment, kas, func() error {
-
-		deployment := r.GenerateDeployment(*kas)
 		foundDeployment.Labels = deployment.Labels
 		foundDeployment.Spec = deployment.Spec
 
@@ -176,6 +282,23 @@ egressSelections:
 		return ctrl.Result{}, err
 	}
 
+	// Update Status
+	kas.Status.ObservedGeneration = kas.Generation
+	kas.Status.Replicas = foundDeployment.Status.Replicas
+	kas.Status.AvailableReplicas = foundDeployment.Status.AvailableReplicas
+	kas.Status.UpdatedReplicas = foundDeployment.Status.UpdatedReplicas
+	if rolledOut(*foundDeployment) {
+		kas.Status.Version = kas.Spec.Version
+	}
+	meta.SetStatusCondition(&kas.Status.Conditions, availableCondition(*foundDeployment, kas.Generation))
+	if err := r.Status().Update(ctx, kas); err != nil {
+		r.log.Error(err, "failed to update KubeAPIServer status", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	if retryEncryption {
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
 	// TODO: create a specific controller for this ! with admin access
 	// Deploy APIServer RBAC to remote control plane
 	// kubeconfig, err := utils.GenerateKubeconfigFromSecret("kube-apiserver", "kube-system", *kubeapiserverCertSecret, fmt.Sprintf("https://%s:6443", "51.159.205.41"))
@@ -225,13 +348,16 @@ func (r *KubeAPIServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&clusterv1alpha1.KubeAPIServer{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.ConfigMap{}).
+		// Roll the Deployment when a mounted certificate is renewed or the audit policy of the user changes
+		Watches(&source.Kind{Type: &corev1.Secret{}}, EnqueueDeploymentOwnersMounting(r.Client, "KubeAPIServer")).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, EnqueueDeploymentOwnersMounting(r.Client, "KubeAPIServer")).
 		Complete(r)
 }
 
 func (r *KubeAPIServerReconciler) GenerateDeployment(kas clusterv1alpha1.KubeAPIServer) appsv1.Deployment {
 	konnectivityKubeconfigName := fmt.Sprintf("%s-kubeconfig", kas.Spec.TLS.KonnectivitySecretName)
 
-	return appsv1.Deployment{
+	deployment := appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      kas.Spec.Deployment.Name,
 			Namespace: kas.Namespace,
@@ -263,7 +389,7 @@ func (r *KubeAPIServerReconciler) GenerateDeployment(kas clusterv1alpha1.KubeAPI
 					Containers: []corev1.Container{
 						{
 							Name:    "konnectivity",
-							Image:   "registry.k8s.io/kas-network-proxy/proxy-server:v0.0.37",
+							Image:   fmt.Sprintf("registry.k8s.io/kas-network-proxy/proxy-server:%s", DefaultKonnectivityVersion),
 							Command: []string{"/proxy-server"},
 							Args: []string{
 								"--logtostderr=true",
@@ -319,17 +445,12 @@ func (r *KubeAPIServerReconciler) GenerateDeployment(kas clusterv1alpha1.KubeAPI
 						{
 							Name:  "kube-apiserver",
 							Image: fmt.Sprintf("registry.k8s.io/kube-apiserver:%s", kas.Spec.Version),
-							Command: []string{
+							Command: append([]string{
 								"/usr/local/bin/kube-apiserver",
 								"--advertise-address",
 								kas.Spec.Options.AdvertiseAddress,
-								"--allow-privileged",
-								"--runtime-config",
-								"api/all=true",
 								"--service-cluster-ip-range",
 								kas.Spec.Options.ServiceClusterIpRange,
-								"--authorization-mode",
-								"RBAC,Node",
 								"--client-ca-file",
 								"/var/lib/kubernetes/tls/kube-apiserver/ca.crt",
 								"--etcd-servers",
@@ -349,23 +470,11 @@ func (r *KubeAPIServerReconciler) GenerateDeployment(kas clusterv1alpha1.KubeAPI
 								"--egress-selector-config-file",
 								"/etc/kubernetes/konnectivity-egress/egress.yaml",
 								"--enable-bootstrap-token-auth",
-								"--enable-admission-plugins",
-								"NamespaceLifecycle,NodeRestriction,LimitRanger,ServiceAccount,DefaultStorageClass,ResourceQuota",
-								"--kubelet-preferred-address-types",
-								"ExternalIP,InternalIP,Hostname",
 								"--kubelet-client-certificate",
 								"/var/lib/kubernetes/tls/kube-apiserver/tls.crt",
 								"--kubelet-client-key",
 								"/var/lib/kubernetes/tls/kube-apiserver/tls.key",
-								"--requestheader-client-ca-file=/var/lib/kubernetes/tls/kube-apiserver/ca.crt",
-								"--requestheader-allowed-names=front-proxy-client",
-								"--requestheader-extra-headers-prefix=X-Remote-Extra-",
-								"--requestheader-group-headers=X-Remote-Group",
-								"--requestheader-username-headers=X-Remote-User",
-								"--proxy-client-cert-file=/var/lib/kubernetes/tls/kube-apiserver/tls.crt",
-								"--proxy-client-key-file=/var/lib/kubernetes/tls/kube-apiserver/tls.key",
-								"--enable-aggregator-routing=true",
-							},
+							}, KubeAPIServerArgs(kas)...),
 							Ports: []corev1.ContainerPort{
 								{Name: "https", ContainerPort: 6443},
 							},
@@ -407,4 +516,98 @@ func (r *KubeAPIServerReconciler) GenerateDeployment(kas clusterv1alpha1.KubeAPI
 			},
 		},
 	}
+
+	if kas.Spec.TLS.ETCDClientSecretName != "" {
+		podSpec := &deployment.Spec.Template.Spec
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{Name: "etcd-client", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: kas.Spec.TLS.ETCDClientSecretName}}})
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name != "kube-apiserver" {
+				continue
+			}
+			podSpec.Containers[i].Command = append(podSpec.Containers[i].Command,
+				"--etcd-cafile=/var/lib/kubernetes/tls/etcd/ca.crt",
+				"--etcd-certfile=/var/lib/kubernetes/tls/etcd/tls.crt",
+				"--etcd-keyfile=/var/lib/kubernetes/tls/etcd/tls.key",
+			)
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{Name: "etcd-client", MountPath: "/var/lib/kubernetes/tls/etcd"})
+		}
+	}
+
+	// Aggregation layer, the ca.crt of the front-proxy-client secret is the front-proxy CA. Without it the
+	// kube-apiserver certificate and its CA are used, as before the front-proxy certificates were issued.
+	podSpec := &deployment.Spec.Template.Spec
+	frontProxyDir := "/var/lib/kubernetes/tls/kube-apiserver"
+	if kas.Spec.TLS.FrontProxyClientSecretName != "" {
+		frontProxyDir = "/var/lib/kubernetes/tls/front-proxy"
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{Name: "front-proxy-client", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: kas.Spec.TLS.FrontProxyClientSecretName}}})
+	}
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name != "kube-apiserver" {
+			continue
+		}
+		podSpec.Containers[i].Command = append(podSpec.Containers[i].Command,
+			"--requestheader-client-ca-file="+frontProxyDir+"/ca.crt",
+			"--requestheader-allowed-names=front-proxy-client",
+			"--requestheader-extra-headers-prefix=X-Remote-Extra-",
+			"--requestheader-group-headers=X-Remote-Group",
+			"--requestheader-username-headers=X-Remote-User",
+			"--proxy-client-cert-file="+frontProxyDir+"/tls.crt",
+			"--proxy-client-key-file="+frontProxyDir+"/tls.key",
+			"--enable-aggregator-routing=true",
+		)
+		if kas.Spec.TLS.FrontProxyClientSecretName != "" {
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{Name: "front-proxy-client", MountPath: frontProxyDir})
+		}
+	}
+
+	configureAudit(kas, &deployment.Spec.Template.Spec)
+
+	if kas.Spec.Encryption != nil {
+		template := &deployment.Spec.Template
+		template.Annotations = map[string]string{EncryptionKeysAnnotation: encryptionKeyNames(kas.Status.Encryption)}
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{Name: "encryption", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: EncryptionSecretName(kas)}}})
+		for i := range template.Spec.Containers {
+			if template.Spec.Containers[i].Name != "kube-apiserver" {
+				continue
+			}
+			template.Spec.Containers[i].Command = append(template.Spec.Containers[i].Command, "--encryption-provider-config="+encryptionDir+"/encryption-config.yaml")
+			template.Spec.Containers[i].VolumeMounts = append(template.Spec.Containers[i].VolumeMounts, corev1.VolumeMount{Name: "encryption", MountPath: encryptionDir})
+		}
+	}
+
+	// KMS plugin sidecar, reached by kube-apiserver on a socket shared as the konnectivity one
+	if kas.Spec.Encryption != nil && kas.Spec.Encryption.KMS != nil {
+		kms := kas.Spec.Encryption.KMS
+		podSpec := &deployment.Spec.Template.Spec
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{Name: "kms-socket", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}})
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name != "kube-apiserver" {
+				continue
+			}
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{Name: "kms-socket", MountPath: kmsSocketDir})
+		}
+		podSpec.Containers = append(podSpec.Containers, corev1.Container{
+			Name:  "kms-plugin",
+			Image: kms.Image,
+			Args:  kms.Args,
+			Env:   append([]corev1.EnvVar{{Name: "KMS_SOCKET", Value: kmsEndpoint}}, kms.Env...),
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "kms-socket", MountPath: kmsSocketDir},
+			},
+		})
+	}
+
+	if flags := AuthenticationFlags(kas); len(flags) > 0 {
+		podSpec := &deployment.Spec.Template.Spec
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{Name: "authentication", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: AuthenticationConfigMapName(kas)}}}})
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name != "kube-apiserver" {
+				continue
+			}
+			podSpec.Containers[i].Command = append(podSpec.Containers[i].Command, flags...)
+			podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{Name: "authentication", MountPath: authenticationDir})
+		}
+	}
+
+	return deployment
 }
@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -25,8 +26,11 @@ import (
 	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 var _ = Describe("KubeApiServer controller", Ordered, func() {
@@ -112,6 +116,286 @@ var _ = Describe("KubeApiServer controller", Ordered, func() {
 			}
 			return false
 		}, timeout, interval).Should(BeTrue())
+
+		By("Reporting deployment availability in status")
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: crd.Name, Namespace: nsName}, crd)
+			return err == nil &&
+				crd.Status.ObservedGeneration == crd.Generation &&
+				meta.IsStatusConditionFalse(crd.Status.Conditions, clusterv1alpha1.ConditionAvailable)
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("Configures the aggregation layer with the front-proxy certificate", func() {
+		command := func() []string {
+			deployment := &appsv1.Deployment{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, deployment); err != nil {
+				return nil
+			}
+			for _, v := range deployment.Spec.Template.Spec.Containers {
+				if v.Name == "kube-apiserver" {
+					return v.Command
+				}
+			}
+			return nil
+		}
+
+		By("Falling back to the kube-apiserver certificate without front-proxy client")
+		Expect(command()).Should(ContainElements(
+			"--requestheader-client-ca-file=/var/lib/kubernetes/tls/kube-apiserver/ca.crt",
+			"--proxy-client-cert-file=/var/lib/kubernetes/tls/kube-apiserver/tls.crt",
+			"--enable-aggregator-routing=true",
+		))
+
+		By("Using the front-proxy client certificate once set")
+		frontProxy := GenerateSecret("front-proxy-client", nsName, map[string]string{"ca.crt": "", "tls.crt": "", "tls.key": ""})
+		Expect(k8sClient.Create(ctx, frontProxy)).Should(Succeed())
+
+		crd := &clusterv1alpha1.KubeAPIServer{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd)).Should(Succeed())
+		crd.Spec.TLS.FrontProxyClientSecretName = "front-proxy-client"
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		Eventually(command, timeout, interval).Should(ContainElements(
+			"--requestheader-client-ca-file=/var/lib/kubernetes/tls/front-proxy/ca.crt",
+			"--proxy-client-cert-file=/var/lib/kubernetes/tls/front-proxy/tls.crt",
+		))
+	})
+
+	It("Merges the extra args, feature gates and admission plugins over the defaults", func() {
+		crd := &clusterv1alpha1.KubeAPIServer{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd)).Should(Succeed())
+		crd.Spec.ExtraArgs = map[string]string{"authorization-mode": "Node,RBAC,Webhook", "profiling": "false"}
+		crd.Spec.FeatureGates = map[string]bool{"InPlacePodVerticalScaling": true, "APIListChunking": false}
+		crd.Spec.AdmissionPlugins = clusterv1alpha1.KubeAPIServerAdmissionPlugins{Enable: []string{"PodNodeSelector"}, Disable: []string{"DefaultStorageClass"}}
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		deployment := &appsv1.Deployment{}
+		Eventually(func() []string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, deployment); err != nil {
+				return nil
+			}
+			for _, v := range deployment.Spec.Template.Spec.Containers {
+				if v.Name == "kube-apiserver" {
+					return v.Command
+				}
+			}
+			return nil
+		}, timeout, interval).Should(And(
+			ContainElements(
+				"--authorization-mode=Node,RBAC,Webhook",
+				"--profiling=false",
+				"--runtime-config=api/all=true",
+				"--feature-gates=APIListChunking=false,InPlacePodVerticalScaling=true",
+				"--enable-admission-plugins=NamespaceLifecycle,NodeRestriction,LimitRanger,ServiceAccount,ResourceQuota,PodNodeSelector",
+				"--disable-admission-plugins=DefaultStorageClass",
+			),
+			Not(ContainElement("--authorization-mode=RBAC,Node")),
+		))
+
+		crd.Spec.ExtraArgs = nil
+		crd.Spec.FeatureGates = nil
+		crd.Spec.AdmissionPlugins = clusterv1alpha1.KubeAPIServerAdmissionPlugins{}
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+	})
+
+	It("Renders OIDC issuers to flags before v1.30 and to an AuthenticationConfiguration after", func() {
+		kasCommand := func() []string {
+			deployment := &appsv1.Deployment{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, deployment); err != nil {
+				return nil
+			}
+			for _, v := range deployment.Spec.Template.Spec.Containers {
+				if v.Name == "kube-apiserver" {
+					return v.Command
+				}
+			}
+			return nil
+		}
+
+		crd := &clusterv1alpha1.KubeAPIServer{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd)).Should(Succeed())
+		crd.Spec.Authentication.OIDC = []clusterv1alpha1.OIDCIssuer{
+			{IssuerURL: "https://sso.example.com", ClientID: "kubernetes", UsernameClaim: "email", GroupsClaim: "groups", RequiredClaims: map[string]string{"hd": "example.com"}},
+		}
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		By("Using the --oidc-* flags before v1.30")
+		Eventually(kasCommand, timeout, interval).Should(ContainElements(
+			"--oidc-issuer-url=https://sso.example.com",
+			"--oidc-client-id=kubernetes",
+			"--oidc-username-claim=email",
+			"--oidc-username-prefix=-",
+			"--oidc-groups-claim=groups",
+			"--oidc-required-claim=hd=example.com",
+		))
+
+		By("Using an AuthenticationConfiguration from v1.30")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd)).Should(Succeed())
+		crd.Spec.Version = "v1.30.0"
+		crd.Spec.Authentication.OIDC = append(crd.Spec.Authentication.OIDC, clusterv1alpha1.OIDCIssuer{IssuerURL: "https://partner.example.com", ClientID: "tenant"})
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		Eventually(kasCommand, timeout, interval).Should(And(
+			ContainElement("--authentication-config=/etc/kubernetes/authentication/authentication-config.json"),
+			Not(ContainElement("--oidc-issuer-url=https://sso.example.com")),
+		))
+
+		config := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver-authentication", Namespace: nsName}, config)).Should(Succeed())
+		Expect(config.Data["authentication-config.json"]).Should(And(
+			ContainSubstring(`"apiVersion":"apiserver.config.k8s.io/v1beta1"`),
+			ContainSubstring(`"url":"https://sso.example.com"`),
+			ContainSubstring(`"claimValidationRules":[{"claim":"hd","requiredValue":"example.com"}]`),
+			ContainSubstring(`"username":{"claim":"sub","prefix":"https://partner.example.com#"}`),
+		))
+	})
+
+	It("Mounts the audit policy and backends", func() {
+		crd := &clusterv1alpha1.KubeAPIServer{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd)).Should(Succeed())
+		crd.Spec.Audit = &clusterv1alpha1.KubeAPIServerAudit{
+			Policy:  "apiVersion: audit.k8s.io/v1\nkind: Policy\nrules:\n- level: Metadata\n",
+			Log:     &clusterv1alpha1.AuditLogBackend{ClaimName: "audit", Format: "json", MaxAge: 7, MaxSize: 100},
+			Webhook: &clusterv1alpha1.AuditWebhookBackend{Server: "https://siem.example.com/audit", Mode: "batch", BatchMaxWait: &metav1.Duration{Duration: 5 * time.Second}},
+		}
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		deployment := &appsv1.Deployment{}
+		Eventually(func() []string {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, deployment)
+			if err != nil {
+				return nil
+			}
+
+			for _, v := range deployment.Spec.Template.Spec.Containers {
+				if v.Name == "kube-apiserver" {
+					return v.Command
+				}
+			}
+			return nil
+		}, timeout, interval).Should(ContainElements(
+			"--audit-policy-file=/etc/kubernetes/audit-policy/policy.yaml",
+			"--audit-log-path=/var/log/kubernetes/audit/$(POD_NAME)/audit.log",
+			"--audit-log-maxage=7",
+			"--audit-webhook-config-file=/etc/kubernetes/audit-webhook/kubeconfig.yml",
+			"--audit-webhook-batch-max-wait=5s",
+		))
+		Expect(deployment.Spec.Template.Spec.Volumes).Should(ContainElement(
+			corev1.Volume{Name: "audit-log", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "audit"}}},
+		))
+
+		policy := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver-audit-policy", Namespace: nsName}, policy)).Should(Succeed())
+		Expect(policy.Data["policy.yaml"]).Should(Equal(crd.Spec.Audit.Policy))
+
+		webhook := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver-audit-webhook", Namespace: nsName}, webhook)).Should(Succeed())
+		Expect(string(webhook.Data["kubeconfig.yml"])).Should(ContainSubstring("server: https://siem.example.com/audit"))
+	})
+
+	It("Encrypts the Secrets and rotates the keys", func() {
+		// The test environment plays the guest cluster whose Secrets are rewritten
+		kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+			Clusters:       map[string]*clientcmdapi.Cluster{"default": {Server: cfg.Host, CertificateAuthorityData: cfg.CAData}},
+			AuthInfos:      map[string]*clientcmdapi.AuthInfo{"default": {ClientCertificateData: cfg.CertData, ClientKeyData: cfg.KeyData}},
+			Contexts:       map[string]*clientcmdapi.Context{"default": {Cluster: "default", AuthInfo: "default"}},
+			CurrentContext: "default",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(k8sClient.Create(ctx, GenerateSecret("admin-kubeconfig", nsName, map[string]string{"kubeconfig.yml": string(kubeconfig)}))).Should(Succeed())
+
+		crd := &clusterv1alpha1.KubeAPIServer{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd)).Should(Succeed())
+		crd.Spec.Encryption = &clusterv1alpha1.KubeAPIServerEncryption{Provider: clusterv1alpha1.EncryptionProviderAESCBC, AdminKubeconfigSecretName: "admin-kubeconfig"}
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		// Nothing runs the pods, the rollouts are completed by hand
+		encryptionDone := func() *clusterv1alpha1.KubeAPIServerEncryptionStatus {
+			deployment := &appsv1.Deployment{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, deployment); err == nil {
+				deployment.Status = appsv1.DeploymentStatus{
+					ObservedGeneration: deployment.Generation,
+					Replicas:           *deployment.Spec.Replicas,
+					UpdatedReplicas:    *deployment.Spec.Replicas,
+					AvailableReplicas:  *deployment.Spec.Replicas,
+				}
+				_ = k8sClient.Status().Update(ctx, deployment)
+			}
+
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd); err != nil || crd.Status.Encryption == nil || crd.Status.Encryption.Phase != "" {
+				return nil
+			}
+			return crd.Status.Encryption
+		}
+
+		By("Generating a first key")
+		Eventually(encryptionDone, timeout, interval).ShouldNot(BeNil())
+		first := crd.Status.Encryption.Keys[0].Name
+
+		By("Rotating to a new key")
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd); err != nil {
+				return err
+			}
+			crd.Spec.Encryption.RotateKeys = "1"
+			return k8sClient.Update(ctx, crd)
+		}, timeout, interval).Should(Succeed())
+		Eventually(func() []clusterv1alpha1.EncryptionKey {
+			if status := encryptionDone(); status != nil && status.ObservedRotation == "1" {
+				return status.Keys
+			}
+			return nil
+		}, timeout, interval).Should(And(HaveLen(1), Not(ContainElement(HaveField("Name", first)))))
+		Expect(meta.IsStatusConditionFalse(crd.Status.Conditions, clusterv1alpha1.ConditionRotatingKeys)).Should(BeTrue())
+
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver-encryption", Namespace: nsName}, secret)).Should(Succeed())
+		Expect(secret.Data).Should(HaveKey(crd.Status.Encryption.Keys[0].Name))
+		Expect(secret.Data).ShouldNot(HaveKey(first))
+		Expect(string(secret.Data["encryption-config.yaml"])).Should(ContainSubstring(`{"identity":{}}`))
+	})
+
+	It("Runs the KMS plugin next to kube-apiserver", func() {
+		crd := &clusterv1alpha1.KubeAPIServer{}
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, crd); err != nil {
+				return err
+			}
+			crd.Spec.Encryption.Provider = clusterv1alpha1.EncryptionProviderKMS
+			crd.Spec.Encryption.KMS = &clusterv1alpha1.KMSPlugin{Name: "mock", Image: "mock-kms-plugin:v1", Args: []string{"--listen-addr=$(KMS_SOCKET)"}}
+			return k8sClient.Update(ctx, crd)
+		}, timeout, interval).Should(Succeed())
+
+		deployment := &appsv1.Deployment{}
+		Eventually(func() []string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, deployment); err != nil {
+				return nil
+			}
+			names := []string{}
+			for _, v := range deployment.Spec.Template.Spec.Containers {
+				names = append(names, v.Name)
+			}
+			return names
+		}, timeout, interval).Should(ContainElements("konnectivity", "kube-apiserver", "kms-plugin"))
+
+		for _, v := range deployment.Spec.Template.Spec.Containers {
+			if v.Name == "kms-plugin" {
+				Expect(v.Env).Should(ContainElement(corev1.EnvVar{Name: "KMS_SOCKET", Value: "unix:///etc/kubernetes/kms-socket/kms.sock"}))
+			}
+			if v.Name == "kms-plugin" || v.Name == "kube-apiserver" {
+				Expect(v.VolumeMounts).Should(ContainElement(corev1.VolumeMount{Name: "kms-socket", MountPath: "/etc/kubernetes/kms-socket"}))
+			}
+		}
+
+		By("Adding the KMS as a read key first")
+		secret := &corev1.Secret{}
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver-encryption", Namespace: nsName}, secret); err != nil {
+				return ""
+			}
+			return string(secret.Data["encryption-config.yaml"])
+		}, timeout, interval).Should(ContainSubstring(`{"kms":{"apiVersion":"v2","name":"mock","endpoint":"unix:///etc/kubernetes/kms-socket/kms.sock","timeout":"3s"}}`))
 	})
 
 })
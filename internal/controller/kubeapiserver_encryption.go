@@ -0,0 +1,338 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// Directory where the encryption Secret is mounted in the kube-apiserver container
+const encryptionDir = "/etc/kubernetes/encryption"
+
+// Unix socket shared by kube-apiserver and the KMS plugin, in an emptyDir as the konnectivity socket
+const (
+	kmsSocketDir = "/etc/kubernetes/kms-socket"
+	kmsEndpoint  = "unix://" + kmsSocketDir + "/kms.sock"
+)
+
+// EncryptionKeysAnnotation is set on the kube-apiserver pod template to the keys of its EncryptionConfiguration,
+// a rotation step only starts once the pods running the keys of the previous one are rolled out
+const EncryptionKeysAnnotation = "cluster.kubeception.ulfo.fr/encryption-keys"
+
+// EncryptionSecretName is the Secret holding the EncryptionConfiguration and the keys of a KubeAPIServer
+func EncryptionSecretName(kas clusterv1alpha1.KubeAPIServer) string {
+	return fmt.Sprintf("%s-encryption", kas.Name)
+}
+
+// encryptionKeyNames returns the names of the keys in the order of the EncryptionConfiguration
+func encryptionKeyNames(status *clusterv1alpha1.KubeAPIServerEncryptionStatus) string {
+	if status == nil {
+		return ""
+	}
+	names := make([]string, 0, len(status.Keys))
+	for _, k := range status.Keys {
+		names = append(names, k.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// The subset of the apiserver.config.k8s.io EncryptionConfiguration set by the operator
+type encryptionConfiguration struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Resources  []encryptionResource `json:"resources"`
+}
+
+type encryptionResource struct {
+	Resources []string             `json:"resources"`
+	Providers []encryptionProvider `json:"providers"`
+}
+
+type encryptionProvider struct {
+	AESCBC    *encryptionKeys `json:"aescbc,omitempty"`
+	Secretbox *encryptionKeys `json:"secretbox,omitempty"`
+	KMS       *kmsProvider    `json:"kms,omitempty"`
+	Identity  *struct{}       `json:"identity,omitempty"`
+}
+
+type kmsProvider struct {
+	APIVersion string `json:"apiVersion"`
+	Name       string `json:"name"`
+	Endpoint   string `json:"endpoint"`
+	Timeout    string `json:"timeout"`
+}
+
+type encryptionKeys struct {
+	Keys []encryptionKeySecret `json:"keys"`
+}
+
+type encryptionKeySecret struct {
+	Name   string `json:"name"`
+	Secret string `json:"secret"`
+}
+
+// GenerateEncryptionConfig returns the EncryptionConfiguration of the keys, whose base64 encoded material is read from material.
+// The first key encrypts the Secrets, all of them decrypt and the identity provider reads the Secrets still in plaintext.
+func GenerateEncryptionConfig(encryption clusterv1alpha1.KubeAPIServerEncryption, keys []clusterv1alpha1.EncryptionKey, material map[string][]byte) ([]byte, error) {
+	providers := []encryptionProvider{}
+	for _, k := range keys {
+		p := encryptionProvider{}
+		if k.Provider == clusterv1alpha1.EncryptionProviderKMS {
+			timeout := 3 * time.Second
+			if encryption.KMS != nil && encryption.KMS.Timeout != nil {
+				timeout = encryption.KMS.Timeout.Duration
+			}
+			p.KMS = &kmsProvider{APIVersion: "v2", Name: k.Name, Endpoint: kmsEndpoint, Timeout: timeout.String()}
+			providers = append(providers, p)
+			continue
+		}
+
+		secret, ok := material[k.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing material of encryption key %s", k.Name)
+		}
+		provider := &encryptionKeys{Keys: []encryptionKeySecret{{Name: k.Name, Secret: string(secret)}}}
+		switch k.Provider {
+		case clusterv1alpha1.EncryptionProviderSecretbox:
+			p.Secretbox = provider
+		default:
+			p.AESCBC = provider
+		}
+		providers = append(providers, p)
+	}
+	providers = append(providers, encryptionProvider{Identity: &struct{}{}})
+
+	// JSON is valid YAML, kube-apiserver accepts both
+	return json.Marshal(encryptionConfiguration{
+		APIVersion: "apiserver.config.k8s.io/v1",
+		Kind:       "EncryptionConfiguration",
+		Resources:  []encryptionResource{{Resources: []string{"secrets"}, Providers: providers}},
+	})
+}
+
+// KMSPluginInUse returns the plugin of the encryption while one of its keys is in the EncryptionConfiguration, nil
+// otherwise. A rotation to another provider still needs it to decrypt the Secrets until they are all rewritten.
+func KMSPluginInUse(encryption *clusterv1alpha1.KubeAPIServerEncryption, status *clusterv1alpha1.KubeAPIServerEncryptionStatus) *clusterv1alpha1.KMSPlugin {
+	if encryption == nil || encryption.KMS == nil || status == nil {
+		return nil
+	}
+	for _, key := range status.Keys {
+		if key.Provider == clusterv1alpha1.EncryptionProviderKMS && key.Name == encryption.KMS.Name {
+			return encryption.KMS.DeepCopy()
+		}
+	}
+	return nil
+}
+
+// newEncryptionKey generates a 32 bytes key, used by both aescbc and secretbox, named after the current time.
+// A kms key is named after the plugin, its keys are generated by the KMS.
+func newEncryptionKey(encryption clusterv1alpha1.KubeAPIServerEncryption, material map[string][]byte) (clusterv1alpha1.EncryptionKey, []byte, error) {
+	provider := CoaleseString(encryption.Provider, clusterv1alpha1.EncryptionProviderAESCBC)
+	if provider == clusterv1alpha1.EncryptionProviderKMS {
+		if encryption.KMS == nil {
+			return clusterv1alpha1.EncryptionKey{}, nil, fmt.Errorf("the kms provider requires a plugin")
+		}
+		return clusterv1alpha1.EncryptionKey{Name: encryption.KMS.Name, Provider: provider}, nil, nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return clusterv1alpha1.EncryptionKey{}, nil, err
+	}
+
+	name := fmt.Sprintf("key-%d", time.Now().Unix())
+	for i := 1; material[name] != nil; i++ {
+		name = fmt.Sprintf("key-%d-%d", time.Now().Unix(), i)
+	}
+	return clusterv1alpha1.EncryptionKey{Name: name, Provider: provider}, []byte(base64.StdEncoding.EncodeToString(secret)), nil
+}
+
+// encryptionKeyCurrent reports whether the write key is the one of the spec, a kms key is the one of the plugin
+func encryptionKeyCurrent(encryption clusterv1alpha1.KubeAPIServerEncryption, key clusterv1alpha1.EncryptionKey) bool {
+	provider := CoaleseString(encryption.Provider, clusterv1alpha1.EncryptionProviderAESCBC)
+	if provider == clusterv1alpha1.EncryptionProviderKMS {
+		return key.Provider == provider && encryption.KMS != nil && key.Name == encryption.KMS.Name
+	}
+	return key.Provider == provider
+}
+
+// ReconcileEncryption advances the key rotation recorded in the status and writes the encryption Secret.
+// A step waits for every kube-apiserver replica to run the keys of the previous one. It returns true while
+// the rotation needs to be retried, when the Secrets could not be rewritten.
+func (r *KubeAPIServerReconciler) ReconcileEncryption(ctx context.Context, kas *clusterv1alpha1.KubeAPIServer) (bool, error) {
+	encryption := kas.Spec.Encryption
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: EncryptionSecretName(*kas), Namespace: kas.Namespace}}
+	material := map[string][]byte{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(secret), secret); client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	for k, v := range secret.Data {
+		material[k] = v
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: kas.Spec.Deployment.Name, Namespace: kas.Namespace}, deployment); client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+
+	if kas.Status.Encryption == nil {
+		kas.Status.Encryption = &clusterv1alpha1.KubeAPIServerEncryptionStatus{}
+	}
+	status := kas.Status.Encryption
+	// The cache may lag behind the last rollout, the annotation ties the Deployment to the keys of the status
+	rolledOut := rolledOut(*deployment) && deployment.Spec.Template.Annotations[EncryptionKeysAnnotation] == encryptionKeyNames(status)
+
+	retry := false
+	rotating := metav1.Condition{Type: clusterv1alpha1.ConditionRotatingKeys, Status: metav1.ConditionTrue, ObservedGeneration: kas.Generation}
+	switch {
+	case len(status.Keys) == 0:
+		key, data, err := newEncryptionKey(*encryption, material)
+		if err != nil {
+			return false, err
+		}
+		material[key.Name] = data
+		status.Keys = []clusterv1alpha1.EncryptionKey{key}
+		status.ObservedRotation = encryption.RotateKeys
+		// The Secrets written before encryption was enabled are stored in plaintext
+		if kas.Status.Version != "" {
+			now := metav1.Now()
+			status.Phase = clusterv1alpha1.EncryptionPhaseRewritingSecrets
+			status.LastRotationTime = &now
+		}
+
+	// The KMS rotates its own keys, the Secrets are rewritten to be encrypted with its current one
+	case status.Phase == "" && encryption.RotateKeys != status.ObservedRotation && encryptionKeyCurrent(*encryption, status.Keys[0]) &&
+		status.Keys[0].Provider == clusterv1alpha1.EncryptionProviderKMS:
+		now := metav1.Now()
+		status.Phase = clusterv1alpha1.EncryptionPhaseRewritingSecrets
+		status.ObservedRotation = encryption.RotateKeys
+		status.LastRotationTime = &now
+
+	case status.Phase == "" && (encryption.RotateKeys != status.ObservedRotation || !encryptionKeyCurrent(*encryption, status.Keys[0])):
+		key, data, err := newEncryptionKey(*encryption, material)
+		if err != nil {
+			return false, err
+		}
+		material[key.Name] = data
+		now := metav1.Now()
+		status.Keys = append([]clusterv1alpha1.EncryptionKey{status.Keys[0], key}, status.Keys[1:]...)
+		status.Phase = clusterv1alpha1.EncryptionPhaseAddingKey
+		status.ObservedRotation = encryption.RotateKeys
+		status.LastRotationTime = &now
+
+	case status.Phase == clusterv1alpha1.EncryptionPhaseAddingKey && rolledOut:
+		status.Keys[0], status.Keys[1] = status.Keys[1], status.Keys[0]
+		status.Phase = clusterv1alpha1.EncryptionPhasePromotingKey
+
+	case status.Phase == clusterv1alpha1.EncryptionPhasePromotingKey && rolledOut:
+		status.Phase = clusterv1alpha1.EncryptionPhaseRewritingSecrets
+
+	case status.Phase == clusterv1alpha1.EncryptionPhaseRewritingSecrets && rolledOut:
+		count, err := r.RewriteSecrets(ctx, *kas)
+		if err != nil {
+			r.log.Info("failed to rewrite Secrets with the new encryption key, retrying later", "error", err.Error(), "name", kas.Name, "namespace", kas.Namespace)
+			rotating.Message = fmt.Sprintf("Failed to rewrite the Secrets: %s", err)
+			retry = true
+			break
+		}
+		r.log.Info("rewrote Secrets with the new encryption key", "count", count, "key", status.Keys[0].Name, "name", kas.Name, "namespace", kas.Namespace)
+		status.Keys = status.Keys[:1]
+		status.Phase = clusterv1alpha1.EncryptionPhaseRemovingOldKeys
+
+	case status.Phase == clusterv1alpha1.EncryptionPhaseRemovingOldKeys && rolledOut:
+		status.Phase = ""
+	}
+
+	if status.Phase == "" {
+		rotating.Status = metav1.ConditionFalse
+		rotating.Reason = "UpToDate"
+		rotating.Message = fmt.Sprintf("Secrets are encrypted with %s", status.Keys[0].Name)
+	} else {
+		rotating.Reason = status.Phase
+		if rotating.Message == "" {
+			rotating.Message = fmt.Sprintf("Rotating to %s", status.Keys[0].Name)
+			if status.Phase == clusterv1alpha1.EncryptionPhaseAddingKey {
+				rotating.Message = fmt.Sprintf("Rotating to %s", status.Keys[1].Name)
+			}
+		}
+	}
+	meta.SetStatusCondition(&kas.Status.Conditions, rotating)
+
+	// Only the keys of the status are kept, a key generated before a failed status update is dropped
+	err := r.CreateOrPatch(ctx, secret, kas, func() error {
+		config, err := GenerateEncryptionConfig(*encryption, status.Keys, material)
+		if err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{"encryption-config.yaml": config}
+		for _, k := range status.Keys {
+			if k.Provider != clusterv1alpha1.EncryptionProviderKMS {
+				secret.Data[k.Name] = material[k.Name]
+			}
+		}
+		return nil
+	})
+	return retry, err
+}
+
+// RewriteSecrets updates every Secret of the cluster unchanged, kube-apiserver stores them again encrypted with its current key.
+// It returns the number of Secrets rewritten.
+func (r *KubeAPIServerReconciler) RewriteSecrets(ctx context.Context, kas clusterv1alpha1.KubeAPIServer) (int, error) {
+	kubeconfig := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: kas.Spec.Encryption.AdminKubeconfigSecretName, Namespace: kas.Namespace}, kubeconfig); err != nil {
+		return 0, err
+	}
+	guest, err := GuestClientFromKubeconfig(kubeconfig.Data["kubeconfig.yml"])
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	secrets := &corev1.SecretList{}
+	for {
+		if err := guest.List(ctx, secrets, client.Limit(500), client.Continue(secrets.Continue)); err != nil {
+			return count, err
+		}
+		for i := range secrets.Items {
+			// A Secret modified or deleted since it was listed is already stored with the current key
+			if err := guest.Update(ctx, &secrets.Items[i]); err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+				return count, err
+			}
+			count++
+		}
+		if secrets.Continue == "" {
+			return count, nil
+		}
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// KubeconfigRequestReconciler reconciles a KubeconfigRequest object
+type KubeconfigRequestReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+}
+
+func NewKubeconfigRequestReconciler(mgr manager.Manager) *KubeconfigRequestReconciler {
+	return &KubeconfigRequestReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		log:    log.Log.WithName("kubeconfigrequest-reconciler"),
+	}
+}
+
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=kubeconfigrequests,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=kubeconfigrequests/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=kubeconfigrequests/finalizers,verbs=update
+//+kubebuilder:rbac:groups=cluster.kubeception.ulfo.fr,resources=controlplanes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+//
+// The client certificate is signed by the operator with the CA of the ControlPlane whatever its PKI backend,
+// the kubeconfig points to the endpoint of its Loadbalancer. Certificates cannot be revoked: deleting the
+// request deletes the Secret but the certificate stays valid until it expires.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.14.4/pkg/reconcile
+func (r *KubeconfigRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+
+	kr := &clusterv1alpha1.KubeconfigRequest{}
+	if err := r.Get(ctx, req.NamespacedName, kr); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.log.Error(err, "failed to get KubeconfigRequest resource", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	if !kr.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Also enforced by the webhook, checked again as webhooks can be disabled
+	if reserved := reservedIdentities(kr.Spec); len(reserved) > 0 {
+		return ctrl.Result{}, r.UpdateCondition(ctx, kr, metav1.ConditionFalse, "ReservedIdentity", fmt.Sprintf("%s cannot be requested, they are reserved to the Kubernetes components", strings.Join(reserved, ", ")))
+	}
+
+	// With the Delete policy an expired request is garbage-collected, the Secret is owned by it
+	if kr.Spec.ExpirationPolicy == clusterv1alpha1.KubeconfigExpirationDelete && kr.Status.ExpirationTime != nil && !time.Now().Before(kr.Status.ExpirationTime.Time) {
+		r.log.Info("KubeconfigRequest expired, deleting it", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, kr))
+	}
+
+	cp := &clusterv1alpha1.ControlPlane{}
+	if err := r.Get(ctx, types.NamespacedName{Name: kr.Spec.ControlPlane, Namespace: req.Namespace}, cp); err != nil {
+		r.log.Info("failed to get ControlPlane for KubeconfigRequest, requeing", "name", kr.Spec.ControlPlane, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, kr, metav1.ConditionFalse, "ControlPlaneNotFound", fmt.Sprintf("ControlPlane %s not found", kr.Spec.ControlPlane))
+	}
+	cp.SetDefaults()
+
+	if cp.Status.Endpoint == "" {
+		r.log.Info("ControlPlane endpoint is not registered, requeing", "name", cp.Name, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, kr, metav1.ConditionFalse, "EndpointPending", fmt.Sprintf("Waiting for the Loadbalancer of ControlPlane %s", cp.Name))
+	}
+
+	caSecret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cp.Spec.PKI.CA.Name, Namespace: req.Namespace}, caSecret); err != nil {
+		r.log.Info("CA secret not found, requeing", "name", cp.Spec.PKI.CA.Name, "namespace", req.Namespace)
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateCondition(ctx, kr, metav1.ConditionFalse, "CAPending", fmt.Sprintf("Waiting for CA secret %s", cp.Spec.PKI.CA.Name))
+	}
+	caCert, caKey, err := parseKeyPair(caSecret.Data[corev1.TLSCertKey], caSecret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		r.log.Error(err, "failed to parse CA", "name", cp.Spec.PKI.CA.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdateCondition(ctx, kr, metav1.ConditionFalse, "InvalidCA", fmt.Sprintf("CA secret %s: %s", cp.Spec.PKI.CA.Name, err))
+	}
+
+	secretName := CoaleseString(kr.Spec.SecretName, fmt.Sprintf("%s-kubeconfig", kr.Name))
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: req.Namespace}}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	// The Secret is overwritten and garbage-collected with the request, it must not be one the request did not create
+	if secret.UID != "" && !metav1.IsControlledBy(secret, kr) {
+		r.log.Info("Secret is not controlled by the KubeconfigRequest", "name", secretName, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdateCondition(ctx, kr, metav1.ConditionFalse, "SecretConflict", fmt.Sprintf("Secret %s already exists and is not controlled by the KubeconfigRequest", secretName))
+	}
+
+	// The key algorithm and size follow the PKI profile, the certificate is only valid for client authentication
+	profile := ResolveProfile(clusterv1alpha1.CertificateProfile{
+		Algorithm: cp.Spec.PKI.Profile.Algorithm,
+		Size:      cp.Spec.PKI.Profile.Size,
+		Duration:  &kr.Spec.TTL,
+		Usages:    []string{"digital signature", "key encipherment", "client auth"},
+	}, false)
+	cert := CertificateRequest{
+		Name:          secretName,
+		CA:            cp.Spec.PKI.CA.Name,
+		CommonName:    kr.Spec.Username,
+		Organizations: kr.Spec.Groups,
+		Profile:       profile,
+	}
+
+	current, _, err := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil || kr.Status.ObservedGeneration != kr.Generation || current.CheckSignatureFrom(caCert) != nil ||
+		(kr.Spec.ExpirationPolicy != clusterv1alpha1.KubeconfigExpirationDelete && !time.Now().Before(renewalTime(current, profile))) {
+		// Every issuance gets a new key, a leaked key is useless once its certificate expires
+		key, err := generateKey(profile)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to generate private key: %w", err)
+		}
+		certPEM, caPEM, err := signCertificate(cert, key, caCert, caKey)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to sign certificate %s: %w", secretName, err)
+		}
+		keyPEM, err := encodePrivateKey(key)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		secret.Data = map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+			CACertKey:               caPEM,
+		}
+		if current, _, err = parseKeyPair(certPEM, keyPEM); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.log.Info("Issued client certificate", "name", req.Name, "namespace", req.Namespace, "username", kr.Spec.Username, "serial", current.SerialNumber.Text(16), "expiration", current.NotAfter)
+	}
+
+	data := secret.Data
+	err = r.CreateOrPatch(ctx, secret, kr, func() error {
+		// The kubeconfig is regenerated as the endpoint may have changed
+		secret.Data = data
+		kc, err := GenerateKubeconfigFromSecret(*secret, cp.Status.Endpoint)
+		if err != nil {
+			return err
+		}
+		secret.Data["kubeconfig.yml"] = kc
+		return nil
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	expiration := metav1.NewTime(current.NotAfter)
+	kr.Status.ObservedGeneration = kr.Generation
+	kr.Status.SecretName = secretName
+	kr.Status.Endpoint = cp.Status.Endpoint
+	kr.Status.SerialNumber = current.SerialNumber.Text(16)
+	kr.Status.ExpirationTime = &expiration
+	kr.Status.RenewalTime = nil
+	requeue := current.NotAfter
+	if kr.Spec.ExpirationPolicy != clusterv1alpha1.KubeconfigExpirationDelete {
+		renewal := metav1.NewTime(renewalTime(current, profile))
+		kr.Status.RenewalTime = &renewal
+		requeue = renewal.Time
+	}
+
+	return ctrl.Result{RequeueAfter: time.Until(requeue) + time.Second}, r.UpdateCondition(ctx, kr, metav1.ConditionTrue, "Issued", fmt.Sprintf("Kubeconfig of %s written to secret %s", kr.Spec.Username, secretName))
+}
+
+// reservedIdentities returns the user and groups of the request reserved to the Kubernetes components
+func reservedIdentities(spec clusterv1alpha1.KubeconfigRequestSpec) []string {
+	reserved := []string{}
+	for _, identity := range append([]string{spec.Username}, spec.Groups...) {
+		if strings.HasPrefix(identity, clusterv1alpha1.ReservedIdentityPrefix) {
+			reserved = append(reserved, identity)
+		}
+	}
+	return reserved
+}
+
+func (r *KubeconfigRequestReconciler) UpdateCondition(ctx context.Context, kr *clusterv1alpha1.KubeconfigRequest, status metav1.ConditionStatus, reason, message string) error {
+	meta.SetStatusCondition(&kr.Status.Conditions, metav1.Condition{
+		Type:               clusterv1alpha1.ConditionReady,
+		Status:             status,
+		ObservedGeneration: kr.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if err := r.Status().Update(ctx, kr); err != nil {
+		r.log.Error(err, "failed to update KubeconfigRequest status", "name", kr.Name, "namespace", kr.Namespace)
+		return err
+	}
+	return nil
+}
+
+// RequestsForControlPlane maps a ControlPlane to the KubeconfigRequests giving access to it
+func (r *KubeconfigRequestReconciler) RequestsForControlPlane(obj client.Object) []reconcile.Request {
+	krs := &clusterv1alpha1.KubeconfigRequestList{}
+	if err := r.List(context.Background(), krs, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.log.Error(err, "failed to list KubeconfigRequests", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, kr := range krs.Items {
+		if kr.Spec.ControlPlane == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: kr.Name, Namespace: kr.Namespace}})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KubeconfigRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1alpha1.KubeconfigRequest{}).
+		Owns(&corev1.Secret{}).
+		Watches(&source.Kind{Type: &clusterv1alpha1.ControlPlane{}}, handler.EnqueueRequestsFromMapFunc(r.RequestsForControlPlane)).
+		Complete(r)
+}
+
+func (r *KubeconfigRequestReconciler) CreateOrPatch(ctx context.Context, obj client.Object, owner metav1.Object, f controllerutil.MutateFn) error {
+	if err := ctrl.SetControllerReference(owner, obj, r.Scheme); err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to set controller reference on %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+
+	result, err := controllerutil.CreateOrPatch(ctx, r.Client, obj, f)
+	if err != nil {
+		r.log.Error(err, fmt.Sprintf("failed to create or patch %s", obj.GetObjectKind().GroupVersionKind().Kind), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+	r.log.Info(fmt.Sprintf("%s/%s was %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), result))
+	return nil
+}
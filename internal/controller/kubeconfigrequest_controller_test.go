@@ -0,0 +1,183 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+var _ = Describe("KubeconfigRequest controller", Ordered, func() {
+	ctx := context.Background()
+	nsName := "kubeconfig"
+
+	BeforeAll(func() {
+		By("Creating client namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+
+		By("Creating a ControlPlane exposed by its Loadbalancer")
+		cp := &clusterv1alpha1.ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: nsName},
+			Spec: clusterv1alpha1.ControlPlaneSpec{
+				Version: "v1.27.5",
+				PKI:     clusterv1alpha1.PkiSpec{Backend: clusterv1alpha1.PkiBackendNative},
+			},
+		}
+		Expect(k8sClient.Create(ctx, cp)).Should(Succeed())
+
+		service := &corev1.Service{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: "demo-kube-apiserver", Namespace: nsName}, service)
+		}, timeout, interval).Should(Succeed())
+		service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.20"}}
+		Expect(k8sClient.Status().Update(ctx, service)).Should(Succeed())
+
+		Eventually(func() string {
+			_ = k8sClient.Get(ctx, types.NamespacedName{Name: "demo", Namespace: nsName}, cp)
+			return cp.Status.Endpoint
+		}, timeout, interval).Should(Equal("https://10.0.0.20:6443"))
+	})
+
+	It("Writes a kubeconfig with a client certificate signed by the ControlPlane CA", func() {
+		kr := &clusterv1alpha1.KubeconfigRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane", Namespace: nsName},
+			Spec: clusterv1alpha1.KubeconfigRequestSpec{
+				ControlPlane:     "demo",
+				Username:         "jane@example.com",
+				Groups:           []string{"developers"},
+				TTL:              metav1.Duration{Duration: time.Hour},
+				ExpirationPolicy: clusterv1alpha1.KubeconfigExpirationRenew,
+			},
+		}
+		Expect(k8sClient.Create(ctx, kr)).Should(Succeed())
+
+		By("Waiting for the certificate to be issued")
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "jane", Namespace: nsName}, kr)
+			return err == nil && meta.IsStatusConditionTrue(kr.Status.Conditions, clusterv1alpha1.ConditionReady)
+		}, timeout, interval).Should(BeTrue())
+		Expect(kr.Status.SecretName).Should(Equal("jane-kubeconfig"))
+		Expect(kr.Status.ExpirationTime).ShouldNot(BeNil())
+		Expect(kr.Status.RenewalTime).ShouldNot(BeNil())
+		Expect(kr.Status.RenewalTime.Before(kr.Status.ExpirationTime)).Should(BeTrue())
+
+		By("Checking the kubeconfig points to the Loadbalancer")
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "jane-kubeconfig", Namespace: nsName}, secret)).Should(Succeed())
+		config, err := clientcmd.Load(secret.Data["kubeconfig.yml"])
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(config.Clusters["default"].Server).Should(Equal("https://10.0.0.20:6443"))
+
+		By("Checking the client certificate identity and signer")
+		block, _ := pem.Decode(config.AuthInfos["default-user"].ClientCertificateData)
+		Expect(block).ShouldNot(BeNil())
+		cert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(cert.Subject.CommonName).Should(Equal("jane@example.com"))
+		Expect(cert.Subject.Organization).Should(Equal([]string{"developers"}))
+		Expect(cert.ExtKeyUsage).Should(Equal([]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}))
+		Expect(cert.NotAfter.Equal(kr.Status.ExpirationTime.Time)).Should(BeTrue())
+
+		ca := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "demo-ca", Namespace: nsName}, ca)).Should(Succeed())
+		roots := x509.NewCertPool()
+		Expect(roots.AppendCertsFromPEM(ca.Data["tls.crt"])).Should(BeTrue())
+		_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		By("Reissuing the certificate when the groups change")
+		serial := kr.Status.SerialNumber
+		kr.Spec.Groups = []string{"developers", "viewers"}
+		Expect(k8sClient.Update(ctx, kr)).Should(Succeed())
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "jane", Namespace: nsName}, kr)
+			return err == nil && kr.Status.ObservedGeneration == kr.Generation && kr.Status.SerialNumber != serial
+		}, timeout, interval).Should(BeTrue())
+	})
+
+	It("Refuses the identities of the Kubernetes components", func() {
+		kr := &clusterv1alpha1.KubeconfigRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "masters", Namespace: nsName},
+			Spec: clusterv1alpha1.KubeconfigRequestSpec{
+				ControlPlane: "demo",
+				Username:     "mallory",
+				Groups:       []string{"system:masters"},
+				TTL:          metav1.Duration{Duration: time.Hour},
+			},
+		}
+		Expect(k8sClient.Create(ctx, kr)).Should(Succeed())
+
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "masters", Namespace: nsName}, kr); err != nil {
+				return ""
+			}
+			if c := meta.FindStatusCondition(kr.Status.Conditions, clusterv1alpha1.ConditionReady); c != nil {
+				return c.Reason
+			}
+			return ""
+		}, timeout, interval).Should(Equal("ReservedIdentity"))
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "masters-kubeconfig", Namespace: nsName}, &corev1.Secret{})).ShouldNot(Succeed())
+	})
+
+	It("Leaves a Secret it does not control untouched", func() {
+		foreign := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "imported-ca", Namespace: nsName},
+			Data:       map[string][]byte{"tls.crt": []byte("certificate"), "tls.key": []byte("key")},
+		}
+		Expect(k8sClient.Create(ctx, foreign)).Should(Succeed())
+
+		kr := &clusterv1alpha1.KubeconfigRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "conflict", Namespace: nsName},
+			Spec: clusterv1alpha1.KubeconfigRequestSpec{
+				ControlPlane: "demo",
+				Username:     "jane@example.com",
+				SecretName:   "imported-ca",
+				TTL:          metav1.Duration{Duration: time.Hour},
+			},
+		}
+		Expect(k8sClient.Create(ctx, kr)).Should(Succeed())
+
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "conflict", Namespace: nsName}, kr); err != nil {
+				return ""
+			}
+			if c := meta.FindStatusCondition(kr.Status.Conditions, clusterv1alpha1.ConditionReady); c != nil {
+				return c.Reason
+			}
+			return ""
+		}, timeout, interval).Should(Equal("SecretConflict"))
+
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "imported-ca", Namespace: nsName}, secret)).Should(Succeed())
+		Expect(secret.Data).Should(Equal(foreign.Data))
+		Expect(secret.OwnerReferences).Should(BeEmpty())
+	})
+})
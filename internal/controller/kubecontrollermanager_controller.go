@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -30,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -103,7 +105,9 @@ func (r *KubeControllerManagerReconciler) Reconcile(ctx context.Context, req ctr
 	////////////
 	// Controller manager kubeconfig
 	////////////
-	kubeControllerManagerKubeconfig := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager-kubeconfig", Namespace: req.Namespace}}
+	deploymentName := CoaleseString(kcm.Spec.Deployment.Name, "kube-controller-manager")
+	kubeconfigName := fmt.Sprintf("%s-kubeconfig", deploymentName)
+	kubeControllerManagerKubeconfig := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: kubeconfigName, Namespace: req.Namespace}}
 	err := r.CreateOrPatch(ctx, kubeControllerManagerKubeconfig, kcm, func() error {
 		k, err := GenerateKubeconfigFromSecret(*kubeControllerManagerSecret, fmt.Sprintf("https://%s:%d", kcm.Spec.KubeAPIServerService.Name, kcm.Spec.KubeAPIServerService.Port))
 		if err != nil {
@@ -122,7 +126,9 @@ func (r *KubeControllerManagerReconciler) Reconcile(ctx context.Context, req ctr
 	// Deployment
 	////////////
 
-	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager", Namespace: req.Namespace}}
+	clusterCIDR := CoaleseString(kcm.Spec.Options.ClusterCIDR, clusterv1alpha1.DefaultPodCIDR)
+
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: req.Namespace}}
 	err = r.CreateOrPatch(ctx, deployment, kcm, func() error {
 		var autoMountSA bool = false
 
@@ -138,24 +144,21 @@ func (r *KubeControllerManagerReconciler) Reconcile(ctx context.Context, req ctr
 				},
 				Spec: corev1.PodSpec{
 					Volumes: []corev1.Volume{
-						{Name: "kubeconfig", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "kube-controller-manager-kubeconfig"}}},
-						{Name: "kube-controller-manager", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "kube-controller-manager"}}},
-						{Name: "service-accounts", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "service-accounts"}}},
+						{Name: "kubeconfig", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: kubeconfigName}}},
+						{Name: "kube-controller-manager", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: kcm.Spec.TLS.KubeControllerManager}}},
+						{Name: "service-accounts", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: kcm.Spec.TLS.ServiceAccountsTLS}}},
 						{Name: "ca", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: kcm.Spec.TLS.CA}}},
 					},
 					Containers: []corev1.Container{
 						{
 							Name:  "kube-controller-manager",
 							Image: fmt.Sprintf("registry.k8s.io/kube-controller-manager:%s", kcm.Spec.Version),
-							Command: []string{
+							Command: append(append([]string{
 								"/usr/local/bin/kube-controller-manager",
-								"--authentication-skip-lookup",
-
 								"--cluster-cidr",
-								"10.200.0.0/16",
-
+								clusterCIDR,
 								"--service-cluster-ip-range",
-								"10.32.0.0/24",
+								CoaleseString(kcm.Spec.Options.ServiceClusterIpRange, clusterv1alpha1.DefaultServiceClusterIPRange),
 
 								"--tls-cert-file",
 								"/var/lib/kubernetes/tls/kcm/tls.crt",
@@ -169,8 +172,6 @@ func (r *KubeControllerManagerReconciler) Reconcile(ctx context.Context, req ctr
 								"--authorization-kubeconfig",
 								"/var/lib/kubernetes/auth/kubeconfig.yml",
 
-								"--use-service-account-credentials",
-
 								"--client-ca-file",
 								"/var/lib/kubernetes/tls/kcm/ca.crt",
 
@@ -180,17 +181,12 @@ func (r *KubeControllerManagerReconciler) Reconcile(ctx context.Context, req ctr
 								"--service-account-private-key-file",
 								"/var/lib/kubernetes/tls/sa/tls.key",
 
-								"--cloud-provider", "external",
-
 								"--cluster-signing-cert-file",
 								"/var/lib/kubernetes/tls/ca/tls.crt",
 
 								"--cluster-signing-key-file",
 								"/var/lib/kubernetes/tls/ca/tls.key",
-
-								"--controllers",
-								"*,bootstrapsigner,tokencleaner",
-							},
+							}, NodeCIDRMaskSizeArgs(clusterCIDR, kcm.Spec.Options.NodeCIDRMaskSize, kcm.Spec.Options.NodeCIDRMaskSizeIPv6)...), KubeControllerManagerArgs(kcm)...),
 							Ports: []corev1.ContainerPort{
 								{Name: "https", ContainerPort: 10257},
 							},
@@ -221,9 +217,27 @@ func (r *KubeControllerManagerReconciler) Reconcile(ctx context.Context, req ctr
 				},
 			},
 		}
-		return nil
+		return SetChecksumAnnotation(ctx, r.Client, req.Namespace, &deployment.Spec.Template)
 	})
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.log.Info("failed to compute mounted secrets checksum, requeing", "error", err.Error(), "name", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Update Status
+	kcm.Status.ObservedGeneration = kcm.Generation
+	kcm.Status.Replicas = deployment.Status.Replicas
+	kcm.Status.AvailableReplicas = deployment.Status.AvailableReplicas
+	kcm.Status.UpdatedReplicas = deployment.Status.UpdatedReplicas
+	if rolledOut(*deployment) {
+		kcm.Status.Version = kcm.Spec.Version
+	}
+	meta.SetStatusCondition(&kcm.Status.Conditions, availableCondition(*deployment, kcm.Generation))
+	if err := r.Status().Update(ctx, kcm); err != nil {
+		r.log.Error(err, "failed to update KubeControllerManager status", "name", req.Name, "namespace", req.Namespace)
 		return ctrl.Result{}, err
 	}
 
@@ -236,6 +250,8 @@ func (r *KubeControllerManagerReconciler) SetupWithManager(mgr ctrl.Manager) err
 		For(&clusterv1alpha1.KubeControllerManager{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Secret{}).
+		// Roll the Deployment when a mounted certificate is renewed
+		Watches(&source.Kind{Type: &corev1.Secret{}}, EnqueueDeploymentOwnersMounting(r.Client, "KubeControllerManager")).
 		Complete(r)
 }
 
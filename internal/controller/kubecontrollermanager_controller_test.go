@@ -110,4 +110,51 @@ var _ = Describe("KubeControllerManager controller", Ordered, func() {
 		}, timeout, interval).Should(BeTrue())
 	})
 
+	kcmCommand := func() []string {
+		deployment := &appsv1.Deployment{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-controller-manager", Namespace: nsName}, deployment); err != nil {
+			return nil
+		}
+		for _, v := range deployment.Spec.Template.Spec.Containers {
+			if v.Name == "kube-controller-manager" {
+				return v.Command
+			}
+		}
+		return nil
+	}
+
+	It("Allocates the node ranges from the networks of the options", func() {
+		Expect(kcmCommand()).Should(ContainElements("10.200.0.0/16", "10.32.0.0/24", "--allocate-node-cidrs=true"))
+
+		crd := &clusterv1alpha1.KubeControllerManager{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-controller-manager", Namespace: nsName}, crd)).Should(Succeed())
+		crd.Spec.Options = clusterv1alpha1.KubeControllerManagerOptions{ClusterCIDR: "10.244.0.0/16", ServiceClusterIpRange: "10.96.0.0/12", NodeCIDRMaskSize: 25}
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		Eventually(kcmCommand, timeout, interval).Should(ContainElements(
+			"--cluster-cidr", "10.244.0.0/16",
+			"--node-cidr-mask-size", "25",
+			"--service-cluster-ip-range", "10.96.0.0/12",
+		))
+	})
+
+	It("Allocates the node ranges of both families of a dual-stack network", func() {
+		crd := &clusterv1alpha1.KubeControllerManager{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-controller-manager", Namespace: nsName}, crd)).Should(Succeed())
+		crd.Spec.Options = clusterv1alpha1.KubeControllerManagerOptions{
+			ClusterCIDR:           "10.244.0.0/16,fd00:244::/56",
+			ServiceClusterIpRange: "10.96.0.0/12,fd00:96::/112",
+			NodeCIDRMaskSize:      25,
+		}
+		Expect(k8sClient.Update(ctx, crd)).Should(Succeed())
+
+		Eventually(kcmCommand, timeout, interval).Should(ContainElements(
+			"--cluster-cidr", "10.244.0.0/16,fd00:244::/56",
+			"--node-cidr-mask-size-ipv4", "25",
+			"--node-cidr-mask-size-ipv6", "64",
+			"--service-cluster-ip-range", "10.96.0.0/12,fd00:96::/112",
+		))
+		Expect(kcmCommand()).ShouldNot(ContainElement("--node-cidr-mask-size"))
+	})
+
 })
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -32,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -97,7 +99,9 @@ func (r *KubeSchedulerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	////////////
 	// Kube Scheduler kubeconfig
 	////////////
-	kubeSchedulerConfig := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kube-scheduler-config", Namespace: req.Namespace}}
+	deploymentName := CoaleseString(ks.Spec.Deployment.Name, "kube-scheduler")
+	configName := fmt.Sprintf("%s-config", deploymentName)
+	kubeSchedulerConfig := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: configName, Namespace: req.Namespace}}
 	err := r.CreateOrPatch(ctx, kubeSchedulerConfig, ks, func() error {
 
 		config := &kubescheduler.KubeSchedulerConfiguration{
@@ -111,7 +115,7 @@ func (r *KubeSchedulerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 		configyaml, err := json.Marshal(config)
 		if err != nil {
-			r.log.Error(err, "failed to marshal kube-scheduler config", "name", "kube-scheduler-config", "namespace", req.Namespace)
+			r.log.Error(err, "failed to marshal kube-scheduler config", "name", configName, "namespace", req.Namespace)
 			return err
 		}
 
@@ -136,7 +140,7 @@ func (r *KubeSchedulerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	// Deployment
 	////////////
 
-	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "kube-scheduler", Namespace: req.Namespace}}
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deploymentName, Namespace: req.Namespace}}
 	err = r.CreateOrPatch(ctx, deployment, ks, func() error {
 		var autoMountSA bool = false
 
@@ -152,19 +156,18 @@ func (r *KubeSchedulerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				},
 				Spec: corev1.PodSpec{
 					Volumes: []corev1.Volume{
-						{Name: "kubeconfig", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "kube-scheduler-config"}}},
+						{Name: "kubeconfig", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: configName}}},
 						{Name: "kube-scheduler", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: ks.Spec.KubeSchedulerTls}}},
 					},
 					Containers: []corev1.Container{
 						{
 							Name:  "kube-scheduler",
 							Image: fmt.Sprintf("registry.k8s.io/kube-scheduler:%s", ks.Spec.Version),
-							Command: []string{
+							Command: append([]string{
 								"/usr/local/bin/kube-scheduler",
 								"--config",
 								"/var/lib/kubernetes/auth/config.json",
 
-								"--authentication-skip-lookup",
 								"--authentication-kubeconfig",
 								"/var/lib/kubernetes/auth/kubeconfig.yml",
 								"--authorization-kubeconfig",
@@ -177,7 +180,7 @@ func (r *KubeSchedulerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 								"--client-ca-file",
 								"/var/lib/kubernetes/tls/ks/ca.crt",
-							},
+							}, KubeSchedulerArgs(ks)...),
 							Ports: []corev1.ContainerPort{
 								{Name: "https", ContainerPort: 10259},
 							},
@@ -206,9 +209,27 @@ func (r *KubeSchedulerReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				},
 			},
 		}
-		return nil
+		return SetChecksumAnnotation(ctx, r.Client, req.Namespace, &deployment.Spec.Template)
 	})
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.log.Info("failed to compute mounted secrets checksum, requeing", "error", err.Error(), "name", req.Name, "namespace", req.Namespace)
+			return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Update Status
+	ks.Status.ObservedGeneration = ks.Generation
+	ks.Status.Replicas = deployment.Status.Replicas
+	ks.Status.AvailableReplicas = deployment.Status.AvailableReplicas
+	ks.Status.UpdatedReplicas = deployment.Status.UpdatedReplicas
+	if rolledOut(*deployment) {
+		ks.Status.Version = ks.Spec.Version
+	}
+	meta.SetStatusCondition(&ks.Status.Conditions, availableCondition(*deployment, ks.Generation))
+	if err := r.Status().Update(ctx, ks); err != nil {
+		r.log.Error(err, "failed to update KubeScheduler status", "name", req.Name, "namespace", req.Namespace)
 		return ctrl.Result{}, err
 	}
 	return ctrl.Result{}, nil
@@ -220,6 +241,8 @@ func (r *KubeSchedulerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&clusterv1alpha1.KubeScheduler{}).
 		Owns(&corev1.Secret{}).
 		Owns(&appsv1.Deployment{}).
+		// Roll the Deployment when a mounted certificate is renewed
+		Watches(&source.Kind{Type: &corev1.Secret{}}, EnqueueDeploymentOwnersMounting(r.Client, "KubeScheduler")).
 		Complete(r)
 }
 
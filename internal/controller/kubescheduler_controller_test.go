@@ -102,4 +102,24 @@ var _ = Describe("KubeScheduler controller", Ordered, func() {
 		}, timeout, interval).Should(BeTrue())
 	})
 
+	It("Rolls the deployment when its certificate is renewed", func() {
+		deployment := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-scheduler", Namespace: nsName}, deployment)).Should(Succeed())
+		checksum := deployment.Spec.Template.Annotations[ChecksumAnnotation]
+		Expect(checksum).ShouldNot(BeEmpty())
+
+		By("Renewing the certificate")
+		secret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-scheduler", Namespace: nsName}, secret)).Should(Succeed())
+		secret.Data["tls.crt"] = []byte("renewed")
+		Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "kube-scheduler", Namespace: nsName}, deployment); err != nil {
+				return checksum
+			}
+			return deployment.Spec.Template.Annotations[ChecksumAnnotation]
+		}, timeout, interval).ShouldNot(Equal(checksum))
+	})
+
 })
@@ -91,9 +91,11 @@ func (r *LoadbalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			Type: corev1.ServiceTypeLoadBalancer,
 			Ports: []corev1.ServicePort{
 				{Name: "https", Port: lb.Spec.Port, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(6443)},
-				{Name: "konnectivity", Port: 8091, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(8091)},
+				{Name: "konnectivity", Port: KonnectivityAgentPort, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(KonnectivityAgentPort)},
 			},
-			Selector: labels,
+			Selector:       labels,
+			IPFamilyPolicy: lb.Spec.IPFamilyPolicy,
+			IPFamilies:     lb.Spec.IPFamilies,
 		}
 		return nil
 	})
@@ -102,9 +104,16 @@ func (r *LoadbalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	// Update Status
-	if len(service.Status.LoadBalancer.Ingress) > 0 {
-		lb.Status.IP = service.Status.LoadBalancer.Ingress[0].IP
+	// Update Status, a dual-stack Service has an ingress IP per family
+	ips := []string{}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			ips = append(ips, ingress.IP)
+		}
+	}
+	if len(ips) > 0 {
+		lb.Status.IP = ips[0]
+		lb.Status.IPs = ips
 		if err := r.Status().Update(ctx, lb); err != nil {
 			r.log.Error(err, "failed to update Loadbalancer IP Status, requing for 3 seconds", "name", req.Name, "namespace", req.Namespace)
 			return ctrl.Result{}, nil
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// CACertKey is the key of the CA certificate in certificate Secrets
+const CACertKey = "ca.crt"
+
+// CertificateRequest is a certificate of a Pki. It is written to the Secret named after it,
+// with the tls.crt, tls.key and ca.crt keys whatever the backend.
+type CertificateRequest struct {
+	// Name of the certificate and of its Secret
+	Name string
+	// Name of the CA certificate signing it, the certificate is a self-signed CA when empty
+	CA string
+	// The certificate is a CA
+	IsCA bool
+	// External cert-manager issuer signing the certificate instead of a CA of the Pki, only supported by the cert-manager backend
+	IssuerRef *clusterv1alpha1.PKIIssuerRef
+
+	CommonName    string
+	Organizations []string
+	IPAddresses   []string
+	DNSNames      []string
+
+	// Profile of the certificate with its defaults filled by ResolveProfile, durations left empty use the backend defaults
+	Profile clusterv1alpha1.CertificateProfile
+}
+
+// Key usages of the certificates whose profile does not set them
+var (
+	DefaultCAUsages = []string{"cert sign", "crl sign", "digital signature"}
+	DefaultUsages   = []string{"digital signature", "key encipherment", "server auth", "client auth"}
+)
+
+// ResolveProfile fills the key algorithm, size and usages left empty in a certificate profile
+func ResolveProfile(p clusterv1alpha1.CertificateProfile, isCA bool) clusterv1alpha1.CertificateProfile {
+	p.Algorithm = CoaleseString(p.Algorithm, clusterv1alpha1.KeyAlgorithmRSA)
+	if p.Size == 0 {
+		switch p.Algorithm {
+		case clusterv1alpha1.KeyAlgorithmRSA:
+			p.Size = 2048
+		case clusterv1alpha1.KeyAlgorithmECDSA:
+			p.Size = 256
+		}
+	}
+	if len(p.Usages) == 0 {
+		p.Usages = DefaultUsages
+		if isCA {
+			p.Usages = DefaultCAUsages
+		}
+	}
+	return p
+}
+
+// CertificateStatus is the state of an issued certificate
+type CertificateStatus struct {
+	// The certificate has been issued and its Secret is up to date
+	Ready bool
+	// Time the certificate will be renewed at, zero when unknown
+	RenewalTime time.Time
+}
+
+// PkiBackend issues the certificates of a Pki
+type PkiBackend interface {
+	// Issue creates the certificate Secret, or updates it when the request changed or the certificate is due for renewal.
+	// A CA is issued before the certificates it signs, they are not ready until it is.
+	Issue(ctx context.Context, pki *clusterv1alpha1.Pki, cert CertificateRequest) (CertificateStatus, error)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// CertManagerBackend issues certificates with cert-manager. A self-signed Issuer named after
+// the Pki signs the CAs, each CA gets an Issuer named after it signing the other certificates.
+// The CA secrets are read by the CA Issuers, they may be issued by cert-manager or imported by the Pki.
+type CertManagerBackend struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+}
+
+var _ PkiBackend = &CertManagerBackend{}
+
+func (b *CertManagerBackend) Issue(ctx context.Context, pki *clusterv1alpha1.Pki, cert CertificateRequest) (CertificateStatus, error) {
+	issuerRef := certmanagermetav1.ObjectReference{Name: cert.CA, Kind: "Issuer"}
+	switch {
+	case cert.IssuerRef != nil:
+		issuerRef = certmanagermetav1.ObjectReference{
+			Name:  cert.IssuerRef.Name,
+			Kind:  CoaleseString(cert.IssuerRef.Kind, "Issuer"),
+			Group: cert.IssuerRef.Group,
+		}
+	case cert.CA != "":
+		////////////
+		// CA ISSUER
+		////////////
+		caIssuer := &certmanagerv1.Issuer{ObjectMeta: metav1.ObjectMeta{Name: cert.CA, Namespace: pki.Namespace}}
+		err := b.CreateOrPatch(ctx, caIssuer, pki, func() error {
+			caIssuer.Spec.IssuerConfig = certmanagerv1.IssuerConfig{
+				CA: &certmanagerv1.CAIssuer{
+					SecretName: cert.CA,
+				},
+			}
+			return nil
+		})
+		if err != nil {
+			return CertificateStatus{}, err
+		}
+	default:
+		////////////
+		// Root ISSUER
+		////////////
+		rootIssuer := &certmanagerv1.Issuer{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.Name, Namespace: pki.Namespace}}
+		err := b.CreateOrPatch(ctx, rootIssuer, pki, func() error {
+			rootIssuer.Spec = certmanagerv1.IssuerSpec{
+				IssuerConfig: certmanagerv1.IssuerConfig{
+					SelfSigned: &certmanagerv1.SelfSignedIssuer{},
+				},
+			}
+			return nil
+		})
+		if err != nil {
+			return CertificateStatus{}, err
+		}
+		issuerRef.Name = pki.Spec.Name
+	}
+
+	certificate := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: cert.Name, Namespace: pki.Namespace}}
+	err := b.CreateOrPatch(ctx, certificate, pki, func() error {
+		certificate.Spec = certmanagerv1.CertificateSpec{
+			IsCA:        cert.IsCA,
+			CommonName:  cert.CommonName,
+			IPAddresses: cert.IPAddresses,
+			DNSNames:    cert.DNSNames,
+			SecretName:  cert.Name,
+			PrivateKey: &certmanagerv1.CertificatePrivateKey{
+				Algorithm: certmanagerv1.PrivateKeyAlgorithm(cert.Profile.Algorithm),
+				Size:      cert.Profile.Size,
+			},
+			Duration:    cert.Profile.Duration,
+			RenewBefore: cert.Profile.RenewBefore,
+			IssuerRef:   issuerRef,
+		}
+		for _, u := range cert.Profile.Usages {
+			certificate.Spec.Usages = append(certificate.Spec.Usages, certmanagerv1.KeyUsage(u))
+		}
+		if len(cert.Organizations) > 0 {
+			certificate.Spec.Subject = &certmanagerv1.X509Subject{Organizations: cert.Organizations}
+		}
+		return nil
+	})
+	if err != nil {
+		return CertificateStatus{}, err
+	}
+
+	status := CertificateStatus{Ready: certificateReady(certificate)}
+	if certificate.Status.RenewalTime != nil {
+		status.RenewalTime = certificate.Status.RenewalTime.Time
+	}
+	return status, nil
+}
+
+func (b *CertManagerBackend) CreateOrPatch(ctx context.Context, obj client.Object, owner metav1.Object, f controllerutil.MutateFn) error {
+	if err := ctrl.SetControllerReference(owner, obj, b.Scheme); err != nil {
+		b.log.Error(err, fmt.Sprintf("failed to set controller reference on %s", obj.GetName()), "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+
+	result, err := controllerutil.CreateOrPatch(ctx, b.Client, obj, f)
+	if err != nil {
+		b.log.Error(err, "failed to create or patch cert-manager resource", "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+	b.log.Info(fmt.Sprintf("%s was %s", obj.GetName(), result), "namespace", obj.GetNamespace())
+	return nil
+}
+
+// certificateReady reports whether cert-manager flagged the certificate as Ready
+func certificateReady(cert *certmanagerv1.Certificate) bool {
+	for _, c := range cert.Status.Conditions {
+		if c.Type == certmanagerv1.CertificateConditionReady {
+			return c.Status == certmanagermetav1.ConditionTrue
+		}
+	}
+	return false
+}
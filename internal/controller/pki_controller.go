@@ -19,10 +19,12 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -30,11 +32,12 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
-	certmanagermetav1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
 	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
 	"github.com/go-logr/logr"
 )
@@ -44,13 +47,19 @@ type PkiReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	log    logr.Logger
+
+	// Backend used by the Pki that do not select one
+	DefaultBackend string
+
+	certManagerAvailable bool
 }
 
-func NewPkiReconciler(mgr manager.Manager) *PkiReconciler {
+func NewPkiReconciler(mgr manager.Manager, defaultBackend string) *PkiReconciler {
 	return &PkiReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		log:    log.Log.WithName("pki-reconciler"),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		log:            log.Log.WithName("pki-reconciler"),
+		DefaultBackend: defaultBackend,
 	}
 }
 
@@ -82,78 +91,137 @@ func (r *PkiReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, err
 	}
 
-	///////////////
-	// Root ISSUER
-	///////////////
-	rootIssuer := &certmanagerv1.Issuer{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, rootIssuer, pki, func() error {
-		rootIssuer.Spec = certmanagerv1.IssuerSpec{
-			IssuerConfig: certmanagerv1.IssuerConfig{
-				SelfSigned: &certmanagerv1.SelfSignedIssuer{},
-			},
-		}
-		return nil
-	})
+	backend, backendName, err := r.Backend(pki)
+	if err != nil {
+		r.log.Error(err, "failed to select PKI backend", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdateStatus(ctx, pki, false)
+	}
+	pki.Status.Backend = backendName
 
-	////////////
-	// Root CA
-	///////////
+	// The cluster CA also signs the kubelet and user certificates, etcd must not trust them
+	if pki.Spec.ETCD.Server != "" && pki.Spec.ETCD.CA == "" {
+		r.log.Info("etcd certificates require a dedicated etcd CA", "name", req.Name, "namespace", req.Namespace)
+		return ctrl.Result{}, r.UpdateStatus(ctx, pki, false)
+	}
 
-	rootCa := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.CA.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, rootCa, pki, func() error {
-		rootCa.Spec = certmanagerv1.CertificateSpec{
-			IsCA:       true,
-			CommonName: "ca",
-			SecretName: pki.Spec.CA.Name,
-			DNSNames:   []string{"localhost"},
-			PrivateKey: &certmanagerv1.CertificatePrivateKey{
-				Algorithm: certmanagerv1.RSAKeyAlgorithm,
-				Size:      2048,
-			},
-			IssuerRef: certmanagermetav1.ObjectReference{
-				Name: pki.Spec.Name,
-				Kind: "Issuer",
-			},
+	if backendName == clusterv1alpha1.PkiBackendNative && r.certManagerAvailable {
+		// cert-manager would keep overwriting the secrets issued by the native backend
+		if err := r.DeleteCertManagerResources(ctx, pki); err != nil {
+			return ctrl.Result{}, err
 		}
-		return nil
-	})
+	}
 
-	////////////
-	// CA ISSUER
-	////////////
-	rootCaIssuer := &certmanagerv1.Issuer{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.CA.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, rootCaIssuer, pki, func() error {
-		rootCaIssuer.Spec.IssuerConfig = certmanagerv1.IssuerConfig{
-			CA: &certmanagerv1.CAIssuer{
-				SecretName: pki.Spec.CA.Name,
-			},
+	// The cluster CA is self-signed unless it is imported from a secret or signed by an external issuer
+	caProfile := ResolveProfile(pki.Spec.CAProfile(pki.Spec.CA.Profile), true)
+	caStatus := CertificateStatus{Ready: true}
+	certificates := []CertificateRequest{}
+	switch {
+	case pki.Spec.CA.ImportSecretName != "":
+		caStatus, err = r.ImportCA(ctx, pki, pki.Spec.CA.ImportSecretName)
+	case pki.Spec.CA.IssuerRef != nil && backendName != clusterv1alpha1.PkiBackendCertManager:
+		r.log.Info("External issuers require the cert-manager backend", "name", req.Name, "namespace", req.Namespace, "backend", backendName)
+		r.SetCACondition(pki, metav1.ConditionFalse, "UnsupportedBackend", fmt.Sprintf("External issuers require the %s backend", clusterv1alpha1.PkiBackendCertManager))
+		caStatus.Ready = false
+	case pki.Spec.CA.IssuerRef != nil:
+		caStatus, err = backend.Issue(ctx, pki, CertificateRequest{
+			Name:       ExternalCAName(pki),
+			IsCA:       true,
+			IssuerRef:  pki.Spec.CA.IssuerRef,
+			CommonName: CoaleseString(pki.Spec.CA.CommonName, "ca"),
+			Profile:    caProfile,
+		})
+		if err == nil && caStatus.Ready {
+			caStatus, err = r.ImportCA(ctx, pki, ExternalCAName(pki))
+		} else if err == nil {
+			r.log.Info("CA is not issued by the external issuer yet", "name", ExternalCAName(pki), "namespace", req.Namespace)
+			r.SetCACondition(pki, metav1.ConditionFalse, "CertificatePending", fmt.Sprintf("Waiting for %s to be issued", ExternalCAName(pki)))
 		}
-		return nil
-	})
+		// cert-manager renews the certificate, the import follows the secret
+		caStatus.RenewalTime = time.Time{}
+	default:
+		meta.RemoveStatusCondition(&pki.Status.Conditions, clusterv1alpha1.ConditionCAValid)
+		certificates = append(certificates, CertificateRequest{
+			Name:       pki.Spec.CA.Name,
+			IsCA:       true,
+			CommonName: "ca",
+			Profile:    caProfile,
+		})
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
-	////////////
-	// ADMIN CERT
-	////////////
-	adminCert := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.Admin.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, adminCert, pki, func() error {
-		adminCert.Spec = certmanagerv1.CertificateSpec{
-			CommonName: "cluster-admin",
-			Subject: &certmanagerv1.X509Subject{
-				Organizations: []string{"system:masters"},
+	// Etcd certificates do not depend on the Control Plane IP, issue them first so etcd can bootstrap
+	if pki.Spec.ETCD.CA != "" {
+		certificates = append(certificates, CertificateRequest{
+			Name:       pki.Spec.ETCD.CA,
+			IsCA:       true,
+			CommonName: "etcd-ca",
+			Profile:    ResolveProfile(pki.Spec.CAProfile(pki.Spec.ETCD.CAProfile), true),
+		})
+	}
+	if pki.Spec.ETCD.Server != "" {
+		etcdCA := pki.Spec.ETCD.CA
+		certificates = append(certificates,
+			CertificateRequest{
+				Name:        pki.Spec.ETCD.Server,
+				CA:          etcdCA,
+				CommonName:  "etcd-server",
+				IPAddresses: []string{"127.0.0.1"},
+				DNSNames:    pki.Spec.ETCD.DNSNames,
+				Profile:     r.Profile(pki, pki.Spec.ETCD.ServerProfile),
 			},
-			SecretName: pki.Spec.Admin.Name,
-			PrivateKey: &certmanagerv1.CertificatePrivateKey{
-				Algorithm: certmanagerv1.RSAKeyAlgorithm,
-				Size:      2048,
+			CertificateRequest{
+				Name:        pki.Spec.ETCD.Peer,
+				CA:          etcdCA,
+				CommonName:  "etcd-peer",
+				IPAddresses: []string{"127.0.0.1"},
+				DNSNames:    pki.Spec.ETCD.DNSNames,
+				Profile:     r.Profile(pki, pki.Spec.ETCD.PeerProfile),
 			},
-			IssuerRef: certmanagermetav1.ObjectReference{
-				Name: pki.Spec.CA.Name,
-				Kind: "Issuer",
+			CertificateRequest{
+				Name:       pki.Spec.ETCD.Client,
+				CA:         etcdCA,
+				CommonName: "kube-apiserver-etcd-client",
+				Profile:    r.Profile(pki, pki.Spec.ETCD.ClientProfile),
 			},
-		}
-		return nil
+		)
+	}
+	// Front-proxy certificates authenticate the requests kube-apiserver proxies to aggregated API servers
+	if pki.Spec.FrontProxyCA.Name != "" {
+		// Aggregated API servers only trust the front-proxy CA for proxied requests, it must not sign other certificates
+		certificates = append(certificates,
+			CertificateRequest{
+				Name:       pki.Spec.FrontProxyCA.Name,
+				IsCA:       true,
+				CommonName: "front-proxy-ca",
+				Profile:    ResolveProfile(pki.Spec.CAProfile(pki.Spec.FrontProxyCA.Profile), true),
+			},
+			CertificateRequest{
+				Name:       pki.Spec.FrontProxyClient.Name,
+				CA:         pki.Spec.FrontProxyCA.Name,
+				CommonName: "front-proxy-client",
+				Profile:    r.Profile(pki, pki.Spec.FrontProxyClient.Profile),
+			},
+		)
+	}
+	certificates = append(certificates, CertificateRequest{
+		Name:          pki.Spec.Admin.Name,
+		CA:            pki.Spec.CA.Name,
+		CommonName:    "cluster-admin",
+		Organizations: []string{"system:masters"},
+		Profile:       r.Profile(pki, pki.Spec.Admin.Profile),
 	})
 
+	ready, renewal, err := r.IssueCertificates(ctx, backend, pki, certificates)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	ready = ready && caStatus.Ready
+	if !caStatus.RenewalTime.IsZero() && (renewal.IsZero() || caStatus.RenewalTime.Before(renewal)) {
+		renewal = caStatus.RenewalTime
+	}
+
 	////////////
 	// ADMIN KUBECONFIG
 	////////////
@@ -161,13 +229,13 @@ func (r *PkiReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	adminCertSecret := &corev1.Secret{}
 	if err = r.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: pki.Spec.Admin.Name}, adminCertSecret); err != nil {
 		r.log.Info("Admin certificate secret not found retrying later", "name", pki.Spec.Admin.Name, "namespace", req.Namespace)
-		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateStatus(ctx, pki, false)
 	}
 
 	adminKubeconfigName := fmt.Sprintf("%s-kubeconfig", pki.Spec.Admin.Name)
 	adminKubeconfig := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: adminKubeconfigName, Namespace: req.Namespace}}
 	r.CreateOrPatch(ctx, adminKubeconfig, pki, func() error {
-		kc, err := GenerateKubeconfigFromSecret(*adminCertSecret, fmt.Sprintf("https://%s:6443", pki.Spec.ControlPlaneIP))
+		kc, err := GenerateKubeconfigFromSecret(*adminCertSecret, "https://"+net.JoinHostPort(pki.Spec.ControlPlaneIP, "6443"))
 		if err != nil {
 			r.log.Error(err, "failed to generate admin kubeconfig secret")
 			return err
@@ -179,138 +247,165 @@ func (r *PkiReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 
 	///
 
+	pki.Status.AdminKubeconfig = adminKubeconfigName
+
 	if pki.Spec.ControlPlaneIP == "" {
 		r.log.Info("Control Plane IP is not registered, retrying later", "name", req.Name, "namespace", req.Namespace)
-		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, r.UpdateStatus(ctx, pki, false)
 	}
 
-	////////////
-	// APIServer CERT
-	////////////
+	certificates = []CertificateRequest{
+		{
+			Name:          pki.Spec.KubeAPIServer.Name,
+			CA:            pki.Spec.CA.Name,
+			CommonName:    "kube-apiserver",
+			Organizations: []string{"kubernetes"},
+			IPAddresses:   appendMissing(pki.Spec.KubeAPIServer.IPAddresses, append([]string{pki.Spec.ControlPlaneIP}, pki.Spec.ControlPlaneIPs...)...),
+			DNSNames:      append(append([]string{}, pki.Spec.KubeAPIServer.DNSNames...), pki.Spec.ControlPlaneIP),
+			Profile:       r.Profile(pki, pki.Spec.KubeAPIServer.Profile),
+		},
+		{
+			Name:          pki.Spec.ServiceAccounts.Name,
+			CA:            pki.Spec.CA.Name,
+			CommonName:    "kubernetes",
+			Organizations: []string{"Kubernetes"},
+			Profile:       r.Profile(pki, pki.Spec.ServiceAccounts.Profile),
+		},
+		{
+			Name:          pki.Spec.KubeControllerManager.Name,
+			CA:            pki.Spec.CA.Name,
+			CommonName:    "system:kube-controller-manager",
+			Organizations: []string{"system:kube-controller-manager"},
+			Profile:       r.Profile(pki, pki.Spec.KubeControllerManager.Profile),
+		},
+		{
+			Name:          pki.Spec.KubeScheduler.Name,
+			CA:            pki.Spec.CA.Name,
+			CommonName:    "system:kube-scheduler",
+			Organizations: []string{"system:kube-scheduler"},
+			Profile:       r.Profile(pki, pki.Spec.KubeScheduler.Profile),
+		},
+		{
+			Name:       pki.Spec.Konnectivity.Name,
+			CA:         pki.Spec.CA.Name,
+			CommonName: "system:konnectivity-server",
+			// konnectivity agents connect to the Loadbalancer
+			IPAddresses: appendMissing(nil, append([]string{pki.Spec.ControlPlaneIP}, pki.Spec.ControlPlaneIPs...)...),
+			Profile:     r.Profile(pki, pki.Spec.Konnectivity.Profile),
+		},
+	}
 
-	kubeAPIServerCert := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.KubeAPIServer.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, kubeAPIServerCert, pki, func() error {
-		kubeAPIServerCert.Spec = certmanagerv1.CertificateSpec{
-			CommonName: "kube-apiserver",
-			Subject: &certmanagerv1.X509Subject{
-				Organizations: []string{"kubernetes"},
-			},
-			IPAddresses: append(pki.Spec.KubeAPIServer.IPAddresses, pki.Spec.ControlPlaneIP),
-			DNSNames:    append(pki.Spec.KubeAPIServer.DNSNames, pki.Spec.ControlPlaneIP),
-			SecretName:  pki.Spec.KubeAPIServer.Name,
-			PrivateKey: &certmanagerv1.CertificatePrivateKey{
-				Algorithm: certmanagerv1.RSAKeyAlgorithm,
-				Size:      2048,
-			},
-			IssuerRef: certmanagermetav1.ObjectReference{
-				Name: pki.Spec.CA.Name,
-				Kind: "Issuer",
-			},
-		}
-		return nil
-	})
+	componentsReady, componentsRenewal, err := r.IssueCertificates(ctx, backend, pki, certificates)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	ready = ready && componentsReady
+	if renewal.IsZero() || (!componentsRenewal.IsZero() && componentsRenewal.Before(renewal)) {
+		renewal = componentsRenewal
+	}
 
-	////////////
-	// Service Account cert
-	////////////
-	serviceAccountCert := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.ServiceAccounts.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, serviceAccountCert, pki, func() error {
-		serviceAccountCert.Spec = certmanagerv1.CertificateSpec{
-			CommonName: "kubernetes",
-			Subject: &certmanagerv1.X509Subject{
-				Organizations: []string{"Kubernetes"},
-			},
-			SecretName: pki.Spec.ServiceAccounts.Name,
-			PrivateKey: &certmanagerv1.CertificatePrivateKey{
-				Algorithm: certmanagerv1.RSAKeyAlgorithm,
-				Size:      2048,
-			},
-			IssuerRef: certmanagermetav1.ObjectReference{
-				Name: pki.Spec.CA.Name,
-				Kind: "Issuer",
-			},
-		}
-		return nil
-	})
+	result := ctrl.Result{}
+	if !renewal.IsZero() {
+		// The native backend renews certificates when it reconciles
+		result.RequeueAfter = time.Until(renewal) + time.Second
+	}
+	return result, r.UpdateStatus(ctx, pki, ready)
+}
 
-	////////////
-	// Controller manager cert
-	////////////
+// Profile returns the profile of a certificate signed by the CA, the PKI profile merged with the certificate one
+func (r *PkiReconciler) Profile(pki *clusterv1alpha1.Pki, override *clusterv1alpha1.CertificateProfile) clusterv1alpha1.CertificateProfile {
+	return ResolveProfile(pki.Spec.Profile.Merge(override), false)
+}
 
-	kubeControllerManagerCert := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.KubeControllerManager.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, kubeControllerManagerCert, pki, func() error {
-		kubeControllerManagerCert.Spec = certmanagerv1.CertificateSpec{
-			CommonName: "system:kube-controller-manager",
-			Subject: &certmanagerv1.X509Subject{
-				Organizations: []string{"system:kube-controller-manager"},
-			},
-			SecretName: pki.Spec.KubeControllerManager.Name,
-			PrivateKey: &certmanagerv1.CertificatePrivateKey{
-				Algorithm: certmanagerv1.RSAKeyAlgorithm,
-				Size:      2048,
-			},
-			IssuerRef: certmanagermetav1.ObjectReference{
-				Name: pki.Spec.CA.Name,
-				Kind: "Issuer",
-			},
+// IssueCertificates issues the certificates with the backend, it reports whether all of them are ready and the earliest renewal time
+func (r *PkiReconciler) IssueCertificates(ctx context.Context, backend PkiBackend, pki *clusterv1alpha1.Pki, certificates []CertificateRequest) (bool, time.Time, error) {
+	ready := true
+	renewal := time.Time{}
+	for _, cert := range certificates {
+		status, err := backend.Issue(ctx, pki, cert)
+		if err != nil {
+			r.log.Error(err, "failed to issue certificate", "name", cert.Name, "namespace", pki.Namespace)
+			return false, renewal, err
 		}
-		return nil
-	})
-
-	////////////
-	// Scheduler cert
-	////////////
-	kubeSchedulerCert := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.KubeScheduler.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, kubeSchedulerCert, pki, func() error {
-		kubeSchedulerCert.Spec = certmanagerv1.CertificateSpec{
-			CommonName: "system:kube-scheduler",
-			Subject: &certmanagerv1.X509Subject{
-				Organizations: []string{"system:kube-scheduler"},
-			},
-			SecretName: pki.Spec.KubeScheduler.Name,
-			PrivateKey: &certmanagerv1.CertificatePrivateKey{
-				Algorithm: certmanagerv1.RSAKeyAlgorithm,
-				Size:      2048,
-			},
-			IssuerRef: certmanagermetav1.ObjectReference{
-				Name: pki.Spec.CA.Name,
-				Kind: "Issuer",
-			},
+		if !status.Ready {
+			r.log.Info("Certificate is not ready yet", "name", cert.Name, "namespace", pki.Namespace)
+			ready = false
 		}
-		return nil
-	})
+		if !status.RenewalTime.IsZero() && (renewal.IsZero() || status.RenewalTime.Before(renewal)) {
+			renewal = status.RenewalTime
+		}
+	}
+	return ready, renewal, nil
+}
 
-	////////////
-	// Konnectivity CERT
-	////////////
-	konnectivityCert := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.Konnectivity.Name, Namespace: req.Namespace}}
-	r.CreateOrPatch(ctx, konnectivityCert, pki, func() error {
-		konnectivityCert.Spec = certmanagerv1.CertificateSpec{
-			CommonName: "system:konnectivity-server",
-			SecretName: pki.Spec.Konnectivity.Name,
-			PrivateKey: &certmanagerv1.CertificatePrivateKey{
-				Algorithm: certmanagerv1.RSAKeyAlgorithm,
-				Size:      2048,
-			},
-			IssuerRef: certmanagermetav1.ObjectReference{
-				Name: pki.Spec.CA.Name,
-				Kind: "Issuer",
-			},
+// DeleteCertManagerResources deletes the cert-manager Certificates and Issuers owned by the Pki, their secrets are kept
+func (r *PkiReconciler) DeleteCertManagerResources(ctx context.Context, pki *clusterv1alpha1.Pki) error {
+	certificates := &certmanagerv1.CertificateList{}
+	if err := r.List(ctx, certificates, client.InNamespace(pki.Namespace)); err != nil {
+		r.log.Error(err, "failed to list cert-manager certificates", "namespace", pki.Namespace)
+		return err
+	}
+	issuers := &certmanagerv1.IssuerList{}
+	if err := r.List(ctx, issuers, client.InNamespace(pki.Namespace)); err != nil {
+		r.log.Error(err, "failed to list cert-manager issuers", "namespace", pki.Namespace)
+		return err
+	}
+
+	objects := []client.Object{}
+	for i := range certificates.Items {
+		objects = append(objects, &certificates.Items[i])
+	}
+	for i := range issuers.Items {
+		objects = append(objects, &issuers.Items[i])
+	}
+	for _, obj := range objects {
+		if !metav1.IsControlledBy(obj, pki) {
+			continue
 		}
-		return nil
-	})
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			r.log.Error(err, "failed to delete cert-manager resource", "name", obj.GetName(), "namespace", obj.GetNamespace())
+			return err
+		}
+		r.log.Info("cert-manager resource deleted, the native backend now issues its certificate", "name", obj.GetName(), "namespace", obj.GetNamespace())
+	}
+	return nil
+}
 
-	return ctrl.Result{}, nil
+// Backend returns the backend selected by the Pki, or the default backend of the operator
+func (r *PkiReconciler) Backend(pki *clusterv1alpha1.Pki) (PkiBackend, string, error) {
+	name := CoaleseString(pki.Spec.Backend, r.DefaultBackend, clusterv1alpha1.PkiBackendCertManager)
+	switch name {
+	case clusterv1alpha1.PkiBackendCertManager:
+		if !r.certManagerAvailable {
+			return nil, name, fmt.Errorf("cert-manager is not installed in the cluster")
+		}
+		return &CertManagerBackend{Client: r.Client, Scheme: r.Scheme, log: r.log.WithName("cert-manager")}, name, nil
+	case clusterv1alpha1.PkiBackendNative:
+		return &NativeBackend{Client: r.Client, Scheme: r.Scheme, log: r.log.WithName("native")}, name, nil
+	}
+	return nil, name, fmt.Errorf("unknown PKI backend %q", name)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PkiReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&clusterv1alpha1.Pki{}).
-		Owns(&certmanagerv1.Certificate{}).
-		Owns(&certmanagerv1.Issuer{}).
 		Owns(&corev1.Secret{}).
-		Complete(r)
+		// CA secrets to import are not owned by the Pki
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.PkisImportingSecret))
+
+	// cert-manager resources are only watched when cert-manager is installed, the native backend works without it
+	_, err := mgr.GetRESTMapper().RESTMapping(certmanagerv1.SchemeGroupVersion.WithKind(certmanagerv1.CertificateKind).GroupKind(), certmanagerv1.SchemeGroupVersion.Version)
+	r.certManagerAvailable = err == nil
+	if r.certManagerAvailable {
+		builder = builder.
+			Owns(&certmanagerv1.Certificate{}).
+			Owns(&certmanagerv1.Issuer{})
+	} else {
+		r.log.Info("cert-manager is not installed, only the native PKI backend is available")
+	}
+
+	return builder.Complete(r)
 }
 
 func (r *PkiReconciler) CreateOrPatch(ctx context.Context, obj client.Object, owner metav1.Object, f controllerutil.MutateFn) error {
@@ -327,3 +422,12 @@ func (r *PkiReconciler) CreateOrPatch(ctx context.Context, obj client.Object, ow
 	r.log.Info(fmt.Sprintf("%s/%s cert was %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), result))
 	return nil
 }
+
+func (r *PkiReconciler) UpdateStatus(ctx context.Context, pki *clusterv1alpha1.Pki, ready bool) error {
+	pki.Status.Ready = ready
+	if err := r.Status().Update(ctx, pki); err != nil {
+		r.log.Error(err, "failed to update PKI status", "name", pki.Name, "namespace", pki.Namespace)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Pki controller", Ordered, func() {
+	ctx := context.Background()
+	nsName := "pki"
+
+	BeforeAll(func() {
+		By("Creating client namespace")
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: nsName}}
+		Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+	})
+
+	It("Issues certificates with the native backend", func() {
+		crd := &clusterv1alpha1.Pki{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pki",
+				Namespace: nsName,
+			},
+			Spec: clusterv1alpha1.PkiSpec{
+				Backend:               clusterv1alpha1.PkiBackendNative,
+				Name:                  "pki",
+				ControlPlaneIP:        "10.0.0.1",
+				ControlPlaneIPs:       []string{"10.0.0.1", "fd00::1"},
+				CA:                    clusterv1alpha1.PKICA{Name: "ca"},
+				ServiceAccounts:       clusterv1alpha1.PKIServiceAccounts{Name: "service-accounts"},
+				Admin:                 clusterv1alpha1.PKIAdmin{Name: "admin"},
+				KubeAPIServer:         clusterv1alpha1.PKIKubeAPIServer{Name: "kube-apiserver", DNSNames: []string{"kubernetes.default"}},
+				KubeControllerManager: clusterv1alpha1.PKIKubeControllerManager{Name: "kube-controller-manager"},
+				KubeScheduler:         clusterv1alpha1.PKIKubeScheduler{Name: "kube-scheduler"},
+				Konnectivity:          clusterv1alpha1.PKIKonnectivity{Name: "konnectivity"},
+				ETCD:                  clusterv1alpha1.PKIEtcd{CA: "etcd-ca", Server: "etcd-server", Peer: "etcd-peer", Client: "etcd-client"},
+				FrontProxyCA:          clusterv1alpha1.PKIFrontProxyCA{Name: "front-proxy-ca"},
+				FrontProxyClient:      clusterv1alpha1.PKIFrontProxyClient{Name: "front-proxy-client"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, crd)).Should(Succeed())
+
+		By("Waiting for the Pki to be ready")
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "pki", Namespace: nsName}, crd)
+			return err == nil && crd.Status.Ready
+		}, timeout, interval).Should(BeTrue())
+		Expect(crd.Status.Backend).Should(Equal(clusterv1alpha1.PkiBackendNative))
+
+		By("Checking the certificates are signed by the CA")
+		ca := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "ca", Namespace: nsName}, ca)).Should(Succeed())
+		roots := x509.NewCertPool()
+		Expect(roots.AppendCertsFromPEM(ca.Data["tls.crt"])).Should(BeTrue())
+
+		for _, name := range []string{"service-accounts", "admin", "kube-apiserver", "kube-controller-manager", "kube-scheduler", "konnectivity"} {
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: nsName}, secret)).Should(Succeed())
+			Expect(secret.Type).Should(Equal(corev1.SecretTypeTLS))
+			Expect(secret.Data).Should(HaveKey("tls.key"))
+			Expect(secret.Data["ca.crt"]).Should(Equal(ca.Data["tls.crt"]))
+
+			block, _ := pem.Decode(secret.Data["tls.crt"])
+			Expect(block).ShouldNot(BeNil())
+			cert, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			Expect(err).ShouldNot(HaveOccurred(), name)
+		}
+
+		By("Checking the kube-apiserver certificate holds every IP of the control plane")
+		kasSecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "kube-apiserver", Namespace: nsName}, kasSecret)).Should(Succeed())
+		block, _ := pem.Decode(kasSecret.Data["tls.crt"])
+		kasCert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ShouldNot(HaveOccurred())
+		ips := []string{}
+		for _, ip := range kasCert.IPAddresses {
+			ips = append(ips, ip.String())
+		}
+		Expect(ips).Should(ConsistOf("10.0.0.1", "fd00::1"))
+
+		By("Checking the konnectivity certificate is valid for the IPs the agents connect to")
+		konnectivitySecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "konnectivity", Namespace: nsName}, konnectivitySecret)).Should(Succeed())
+		block, _ = pem.Decode(konnectivitySecret.Data["tls.crt"])
+		konnectivityCert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(konnectivityCert.VerifyHostname("fd00::1")).Should(Succeed())
+
+		By("Checking the front-proxy-client certificate is signed by the front-proxy CA")
+		frontProxyCA := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "front-proxy-ca", Namespace: nsName}, frontProxyCA)).Should(Succeed())
+		frontProxyClient := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "front-proxy-client", Namespace: nsName}, frontProxyClient)).Should(Succeed())
+		Expect(frontProxyClient.Data["ca.crt"]).Should(Equal(frontProxyCA.Data["tls.crt"]))
+		Expect(frontProxyClient.Data["ca.crt"]).ShouldNot(Equal(ca.Data["tls.crt"]))
+
+		By("Checking the etcd certificates chain to the etcd CA and not to the cluster CA")
+		etcdCA := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "etcd-ca", Namespace: nsName}, etcdCA)).Should(Succeed())
+		etcdRoots := x509.NewCertPool()
+		Expect(etcdRoots.AppendCertsFromPEM(etcdCA.Data["tls.crt"])).Should(BeTrue())
+		for _, name := range []string{"etcd-server", "etcd-peer", "etcd-client"} {
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: nsName}, secret)).Should(Succeed())
+			Expect(secret.Data["ca.crt"]).Should(Equal(etcdCA.Data["tls.crt"]), name)
+
+			block, _ := pem.Decode(secret.Data["tls.crt"])
+			Expect(block).ShouldNot(BeNil())
+			cert, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = cert.Verify(x509.VerifyOptions{Roots: etcdRoots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			Expect(err).ShouldNot(HaveOccurred(), name)
+			_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			Expect(err).Should(HaveOccurred(), name)
+			if name == "etcd-client" {
+				Expect(cert.Subject.Organization).Should(BeEmpty())
+			}
+		}
+
+		By("Checking the admin kubeconfig")
+		kubeconfig := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "admin-kubeconfig", Namespace: nsName}, kubeconfig)).Should(Succeed())
+		Expect(kubeconfig.Data).Should(HaveKey("kubeconfig.yml"))
+	})
+
+	It("Imports an existing CA and only trusts it, not its chain", func() {
+		By("Creating a CA signed by an intermediate")
+		issueKeyPair := func(cert CertificateRequest, caCert *x509.Certificate, caKey crypto.Signer) ([]byte, []byte, *x509.Certificate, crypto.Signer) {
+			cert.Profile = ResolveProfile(cert.Profile, cert.IsCA)
+			key, err := generateKey(cert.Profile)
+			Expect(err).ShouldNot(HaveOccurred())
+			certPEM, _, err := signCertificate(cert, key, caCert, caKey)
+			Expect(err).ShouldNot(HaveOccurred())
+			keyPEM, err := encodePrivateKey(key)
+			Expect(err).ShouldNot(HaveOccurred())
+			parsed, _, err := parseKeyPair(certPEM, keyPEM)
+			Expect(err).ShouldNot(HaveOccurred())
+			return certPEM, keyPEM, parsed, key
+		}
+		intermediatePEM, _, intermediate, intermediateKey := issueKeyPair(CertificateRequest{IsCA: true, CommonName: "company-intermediate"}, nil, nil)
+		caPEM, caKeyPEM, _, _ := issueKeyPair(CertificateRequest{IsCA: true, CommonName: "tenant-ca"}, intermediate, intermediateKey)
+		leafPEM, leafKeyPEM, _, _ := issueKeyPair(CertificateRequest{CommonName: "not-a-ca"}, intermediate, intermediateKey)
+
+		secrets := map[string][]byte{"imported-ca-source": append(append([]byte{}, caPEM...), intermediatePEM...), "not-a-ca": leafPEM}
+		keys := map[string][]byte{"imported-ca-source": caKeyPEM, "not-a-ca": leafKeyPEM}
+		for name := range secrets {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsName},
+				Type:       corev1.SecretTypeTLS,
+				Data:       map[string][]byte{"tls.crt": secrets[name], "tls.key": keys[name]},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+		}
+
+		pki := func(name, source string) *clusterv1alpha1.Pki {
+			return &clusterv1alpha1.Pki{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: nsName},
+				Spec: clusterv1alpha1.PkiSpec{
+					Backend:               clusterv1alpha1.PkiBackendNative,
+					Name:                  name,
+					ControlPlaneIP:        "10.0.0.2",
+					CA:                    clusterv1alpha1.PKICA{Name: name + "-ca", ImportSecretName: source},
+					ServiceAccounts:       clusterv1alpha1.PKIServiceAccounts{Name: name + "-service-accounts"},
+					Admin:                 clusterv1alpha1.PKIAdmin{Name: name + "-admin"},
+					KubeAPIServer:         clusterv1alpha1.PKIKubeAPIServer{Name: name + "-kube-apiserver"},
+					KubeControllerManager: clusterv1alpha1.PKIKubeControllerManager{Name: name + "-kube-controller-manager"},
+					KubeScheduler:         clusterv1alpha1.PKIKubeScheduler{Name: name + "-kube-scheduler"},
+					Konnectivity:          clusterv1alpha1.PKIKonnectivity{Name: name + "-konnectivity"},
+				},
+			}
+		}
+		imported := pki("imported", "imported-ca-source")
+		Expect(k8sClient.Create(ctx, imported)).Should(Succeed())
+		invalid := pki("invalid", "not-a-ca")
+		Expect(k8sClient.Create(ctx, invalid)).Should(Succeed())
+
+		By("Waiting for the Pki importing the CA to be ready")
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "imported", Namespace: nsName}, imported)
+			return err == nil && imported.Status.Ready
+		}, timeout, interval).Should(BeTrue())
+		Expect(meta.IsStatusConditionTrue(imported.Status.Conditions, clusterv1alpha1.ConditionCAValid)).Should(BeTrue())
+
+		By("Checking only the imported CA is trusted")
+		ca := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "imported-ca", Namespace: nsName}, ca)).Should(Succeed())
+		Expect(ca.Data["tls.crt"]).Should(Equal(caPEM))
+		Expect(ca.Data["ca.crt"]).Should(Equal(caPEM))
+		Expect(ca.Data["tls.key"]).Should(Equal(caKeyPEM))
+		kas := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "imported-kube-apiserver", Namespace: nsName}, kas)).Should(Succeed())
+		Expect(kas.Data["ca.crt"]).Should(Equal(caPEM))
+
+		By("Checking a certificate which is not a CA is refused")
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Name: "invalid", Namespace: nsName}, invalid); err != nil {
+				return ""
+			}
+			if c := meta.FindStatusCondition(invalid.Status.Conditions, clusterv1alpha1.ConditionCAValid); c != nil && c.Status == metav1.ConditionFalse {
+				return c.Reason
+			}
+			return ""
+		}, timeout, interval).Should(Equal("InvalidCA"))
+		Expect(invalid.Status.Ready).Should(BeFalse())
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "invalid-ca", Namespace: nsName}, &corev1.Secret{})).ShouldNot(Succeed())
+	})
+})
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+// ExternalCAName is the name of the certificate signed by the external issuer of a Pki, it is imported into the CA secret once issued
+func ExternalCAName(pki *clusterv1alpha1.Pki) string {
+	return fmt.Sprintf("%s-external", pki.Spec.CA.Name)
+}
+
+// ImportCA validates the CA held by the source secret and copies it into the CA secret of the Pki.
+// Only the CA certificate is copied, not the rest of its chain: the CA secret is the trust anchor of the cluster components
+// and trusting the intermediates above it would let any certificate they sign authenticate against the cluster.
+func (r *PkiReconciler) ImportCA(ctx context.Context, pki *clusterv1alpha1.Pki, source string) (CertificateStatus, error) {
+	if r.certManagerAvailable {
+		// A CA previously issued by cert-manager would overwrite the imported one
+		certificate := &certmanagerv1.Certificate{}
+		err := r.Get(ctx, types.NamespacedName{Name: pki.Spec.CA.Name, Namespace: pki.Namespace}, certificate)
+		if err == nil && metav1.IsControlledBy(certificate, pki) {
+			if err := r.Delete(ctx, certificate); client.IgnoreNotFound(err) != nil {
+				r.log.Error(err, "failed to delete the self-signed CA certificate", "name", certificate.Name, "namespace", pki.Namespace)
+				return CertificateStatus{}, err
+			}
+		} else if err != nil && !apierrors.IsNotFound(err) {
+			return CertificateStatus{}, err
+		}
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: source, Namespace: pki.Namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.log.Info("CA secret to import not found, waiting for it", "name", source, "namespace", pki.Namespace)
+			r.SetCACondition(pki, metav1.ConditionFalse, "SecretNotFound", fmt.Sprintf("Secret %s not found", source))
+			return CertificateStatus{}, nil
+		}
+		return CertificateStatus{}, err
+	}
+
+	caCert, err := validateCA(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], time.Now())
+	if err != nil {
+		// The secret is watched, the PKI is reconciled again once it is fixed
+		r.log.Info("Refusing to import CA", "name", source, "namespace", pki.Namespace, "reason", err.Error())
+		r.SetCACondition(pki, metav1.ConditionFalse, "InvalidCA", fmt.Sprintf("Secret %s: %s", source, err))
+		return CertificateStatus{}, nil
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw})
+	ca := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: pki.Spec.CA.Name, Namespace: pki.Namespace}}
+	err = r.CreateOrPatch(ctx, ca, pki, func() error {
+		ca.Type = corev1.SecretTypeTLS
+		ca.Data = map[string][]byte{
+			corev1.TLSCertKey:       caPEM,
+			corev1.TLSPrivateKeyKey: secret.Data[corev1.TLSPrivateKeyKey],
+			CACertKey:               caPEM,
+		}
+		return nil
+	})
+	if err != nil {
+		return CertificateStatus{}, err
+	}
+
+	r.SetCACondition(pki, metav1.ConditionTrue, "CAImported", fmt.Sprintf("CA %s imported from secret %s", caCert.Subject.CommonName, source))
+	// Reconciling at expiry flags the CA as invalid
+	return CertificateStatus{Ready: true, RenewalTime: caCert.NotAfter}, nil
+}
+
+// SetCACondition sets the CAValid condition of the Pki
+func (r *PkiReconciler) SetCACondition(pki *clusterv1alpha1.Pki, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&pki.Status.Conditions, metav1.Condition{
+		Type:               clusterv1alpha1.ConditionCAValid,
+		Status:             status,
+		ObservedGeneration: pki.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// PkisImportingSecret maps a secret to the Pki importing their CA from it
+func (r *PkiReconciler) PkisImportingSecret(obj client.Object) []reconcile.Request {
+	pkis := &clusterv1alpha1.PkiList{}
+	if err := r.List(context.Background(), pkis, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.log.Error(err, "failed to list PKIs", "namespace", obj.GetNamespace())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range pkis.Items {
+		pki := &pkis.Items[i]
+		if (pki.Spec.CA.ImportSecretName != "" && pki.Spec.CA.ImportSecretName == obj.GetName()) ||
+			(pki.Spec.CA.IssuerRef != nil && ExternalCAName(pki) == obj.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pki)})
+		}
+	}
+	return requests
+}
+
+// validateCA checks that the first certificate of the PEM chain is a CA currently valid, allowed to sign certificates and matching the private key
+func validateCA(certPEM, keyPEM []byte, now time.Time) (*x509.Certificate, error) {
+	cert, key, err := parseKeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key pair: %w", err)
+	}
+	if !cert.BasicConstraintsValid || !cert.IsCA {
+		return nil, fmt.Errorf("certificate %s is not a CA", cert.Subject.CommonName)
+	}
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, fmt.Errorf("certificate %s is not allowed to sign certificates", cert.Subject.CommonName)
+	}
+	if now.Before(cert.NotBefore) {
+		return nil, fmt.Errorf("certificate %s is not valid before %s", cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("certificate %s expired at %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+	}
+	public, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(cert.PublicKey) {
+		return nil, fmt.Errorf("private key does not match certificate %s", cert.Subject.CommonName)
+	}
+	return cert, nil
+}
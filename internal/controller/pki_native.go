@@ -0,0 +1,476 @@
+/*
+Copyright 2023 Ulysse FONTAINE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
+)
+
+const (
+	// NativeCADuration is the validity of the CAs issued by the native backend
+	NativeCADuration = 10 * 365 * 24 * time.Hour
+	// NativeCertificateDuration is the validity of the certificates issued by the native backend
+	NativeCertificateDuration = 365 * 24 * time.Hour
+
+	// nativeBackdate is subtracted from the certificates NotBefore to tolerate clock skew
+	nativeBackdate = 5 * time.Minute
+)
+
+// nativeKeyUsages and nativeExtKeyUsages map the profile usages to x509 usages
+var (
+	nativeKeyUsages = map[string]x509.KeyUsage{
+		"signing":            x509.KeyUsageDigitalSignature,
+		"digital signature":  x509.KeyUsageDigitalSignature,
+		"content commitment": x509.KeyUsageContentCommitment,
+		"key encipherment":   x509.KeyUsageKeyEncipherment,
+		"key agreement":      x509.KeyUsageKeyAgreement,
+		"data encipherment":  x509.KeyUsageDataEncipherment,
+		"cert sign":          x509.KeyUsageCertSign,
+		"crl sign":           x509.KeyUsageCRLSign,
+		"encipher only":      x509.KeyUsageEncipherOnly,
+		"decipher only":      x509.KeyUsageDecipherOnly,
+	}
+	nativeExtKeyUsages = map[string]x509.ExtKeyUsage{
+		"any":              x509.ExtKeyUsageAny,
+		"server auth":      x509.ExtKeyUsageServerAuth,
+		"client auth":      x509.ExtKeyUsageClientAuth,
+		"code signing":     x509.ExtKeyUsageCodeSigning,
+		"email protection": x509.ExtKeyUsageEmailProtection,
+		"ipsec end system": x509.ExtKeyUsageIPSECEndSystem,
+		"ipsec tunnel":     x509.ExtKeyUsageIPSECTunnel,
+		"ipsec user":       x509.ExtKeyUsageIPSECUser,
+		"timestamping":     x509.ExtKeyUsageTimeStamping,
+		"ocsp signing":     x509.ExtKeyUsageOCSPSigning,
+	}
+)
+
+// NativeBackend issues certificates with crypto/x509 and writes them to Secrets.
+// Certificates are renewed renewBefore their expiry, or once two thirds of their lifetime have passed.
+// Private keys are kept on renewal unless the profile key algorithm or size changed.
+type NativeBackend struct {
+	client.Client
+	Scheme *runtime.Scheme
+	log    logr.Logger
+}
+
+var _ PkiBackend = &NativeBackend{}
+
+func (b *NativeBackend) Issue(ctx context.Context, pki *clusterv1alpha1.Pki, cert CertificateRequest) (CertificateStatus, error) {
+	if cert.IssuerRef != nil {
+		return CertificateStatus{}, fmt.Errorf("certificate %s is signed by an external issuer, which requires the cert-manager backend", cert.Name)
+	}
+
+	// Signer of the certificate, the certificate signs itself when it has no CA
+	var caCert *x509.Certificate
+	var caKey crypto.Signer
+	if cert.CA != "" {
+		caSecret := &corev1.Secret{}
+		if err := b.Get(ctx, types.NamespacedName{Name: cert.CA, Namespace: pki.Namespace}, caSecret); err != nil {
+			if apierrors.IsNotFound(err) {
+				b.log.Info("CA secret not found, waiting for it", "name", cert.Name, "ca", cert.CA, "namespace", pki.Namespace)
+				return CertificateStatus{}, nil
+			}
+			return CertificateStatus{}, err
+		}
+
+		var err error
+		caCert, caKey, err = parseKeyPair(caSecret.Data[corev1.TLSCertKey], caSecret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return CertificateStatus{}, fmt.Errorf("failed to parse CA %s: %w", cert.CA, err)
+		}
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: cert.Name, Namespace: pki.Namespace}}
+	if err := b.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil && !apierrors.IsNotFound(err) {
+		return CertificateStatus{}, err
+	}
+
+	current, key, err := parseKeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		// Missing or unreadable, a new key is generated
+		current = nil
+		key = nil
+	}
+
+	if key != nil && !keyMatches(key, cert.Profile) {
+		b.log.Info("Private key does not match the profile, generating a new one", "name", cert.Name, "namespace", pki.Namespace)
+		current = nil
+		key = nil
+	}
+
+	if current != nil && !needsIssuing(current, secret.Data[corev1.TLSCertKey], secret.Data[CACertKey], caCert, cert) {
+		return CertificateStatus{Ready: true, RenewalTime: renewalTime(current, cert.Profile)}, nil
+	}
+
+	if key == nil {
+		key, err = generateKey(cert.Profile)
+		if err != nil {
+			return CertificateStatus{}, fmt.Errorf("failed to generate private key: %w", err)
+		}
+	}
+
+	certPEM, caPEM, err := signCertificate(cert, key, caCert, caKey)
+	if err != nil {
+		return CertificateStatus{}, fmt.Errorf("failed to sign certificate %s: %w", cert.Name, err)
+	}
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return CertificateStatus{}, err
+	}
+
+	err = b.CreateOrPatch(ctx, secret, pki, func() error {
+		secret.Type = corev1.SecretTypeTLS
+		secret.Data = map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+			CACertKey:               caPEM,
+		}
+		return nil
+	})
+	if err != nil {
+		return CertificateStatus{}, err
+	}
+
+	issued, _, err := parseKeyPair(certPEM, keyPEM)
+	if err != nil {
+		return CertificateStatus{}, err
+	}
+	return CertificateStatus{Ready: true, RenewalTime: renewalTime(issued, cert.Profile)}, nil
+}
+
+func (b *NativeBackend) CreateOrPatch(ctx context.Context, obj client.Object, owner metav1.Object, f controllerutil.MutateFn) error {
+	if err := ctrl.SetControllerReference(owner, obj, b.Scheme); err != nil {
+		b.log.Error(err, "failed to set controller reference on certificate secret", "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+
+	result, err := controllerutil.CreateOrPatch(ctx, b.Client, obj, f)
+	if err != nil {
+		b.log.Error(err, "failed to create or patch certificate secret", "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return err
+	}
+	b.log.Info(fmt.Sprintf("certificate secret %s was %s", obj.GetName(), result), "namespace", obj.GetNamespace())
+	return nil
+}
+
+// renewalTime is renewBefore the certificate expiry, or the time two thirds of its lifetime have passed
+func renewalTime(cert *x509.Certificate, profile clusterv1alpha1.CertificateProfile) time.Time {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	if profile.RenewBefore != nil && profile.RenewBefore.Duration < lifetime {
+		return cert.NotAfter.Add(-profile.RenewBefore.Duration)
+	}
+	return cert.NotBefore.Add(lifetime * 2 / 3)
+}
+
+// certificateDuration is the validity of the certificate, the profile duration or the backend default
+func certificateDuration(cert CertificateRequest) time.Duration {
+	if cert.Profile.Duration != nil {
+		return cert.Profile.Duration.Duration
+	}
+	if cert.IsCA {
+		return NativeCADuration
+	}
+	return NativeCertificateDuration
+}
+
+// certificateUsages returns the x509 usages of the profile
+func certificateUsages(profile clusterv1alpha1.CertificateProfile) (x509.KeyUsage, []x509.ExtKeyUsage) {
+	var usage x509.KeyUsage
+	ext := []x509.ExtKeyUsage{}
+	for _, u := range profile.Usages {
+		if k, ok := nativeKeyUsages[u]; ok {
+			usage |= k
+		}
+		if e, ok := nativeExtKeyUsages[u]; ok && !containsExtKeyUsage(ext, e) {
+			ext = append(ext, e)
+		}
+	}
+	return usage, ext
+}
+
+func containsExtKeyUsage(usages []x509.ExtKeyUsage, usage x509.ExtKeyUsage) bool {
+	for _, u := range usages {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
+
+// generateKey generates a private key of the profile algorithm and size
+func generateKey(profile clusterv1alpha1.CertificateProfile) (crypto.Signer, error) {
+	switch profile.Algorithm {
+	case clusterv1alpha1.KeyAlgorithmECDSA:
+		curve, err := ecdsaCurve(profile.Size)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case clusterv1alpha1.KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return rsa.GenerateKey(rand.Reader, profile.Size)
+}
+
+func ecdsaCurve(size int) (elliptic.Curve, error) {
+	switch size {
+	case 256:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	case 521:
+		return elliptic.P521(), nil
+	}
+	return nil, fmt.Errorf("unsupported ECDSA key size %d", size)
+}
+
+// keyMatches is true when the private key has the profile algorithm and size
+func keyMatches(key crypto.Signer, profile clusterv1alpha1.CertificateProfile) bool {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return profile.Algorithm == clusterv1alpha1.KeyAlgorithmRSA && k.N.BitLen() == profile.Size
+	case *ecdsa.PrivateKey:
+		return profile.Algorithm == clusterv1alpha1.KeyAlgorithmECDSA && k.Curve.Params().BitSize == profile.Size
+	case ed25519.PrivateKey:
+		return profile.Algorithm == clusterv1alpha1.KeyAlgorithmEd25519
+	}
+	return false
+}
+
+// needsIssuing is true when the certificate is due for renewal, does not match the request or was not signed by the current CA
+func needsIssuing(current *x509.Certificate, certPEM, caPEM []byte, caCert *x509.Certificate, cert CertificateRequest) bool {
+	if !time.Now().Before(renewalTime(current, cert.Profile)) {
+		return true
+	}
+
+	usage, ext := certificateUsages(cert.Profile)
+	if current.KeyUsage != usage || !equalExtKeyUsages(current.ExtKeyUsage, ext) {
+		return true
+	}
+
+	// Certificates are backdated by nativeBackdate, and cannot outlive their CA
+	lifetime := current.NotAfter.Sub(current.NotBefore) - nativeBackdate
+	if lifetime != certificateDuration(cert) && (caCert == nil || !current.NotAfter.Equal(caCert.NotAfter)) {
+		return true
+	}
+
+	if current.IsCA != cert.IsCA ||
+		current.Subject.CommonName != cert.CommonName ||
+		!equalStrings(current.Subject.Organization, cert.Organizations) ||
+		!equalStrings(current.DNSNames, cert.DNSNames) ||
+		!equalStrings(ipStrings(current.IPAddresses), normalizeIPs(cert.IPAddresses)) {
+		return true
+	}
+
+	if caCert == nil {
+		// Self-signed, ca.crt is the certificate itself
+		return !bytes.Equal(certPEM, caPEM)
+	}
+	if current.CheckSignatureFrom(caCert) != nil {
+		return true
+	}
+	return !bytes.Equal(caPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}))
+}
+
+// signCertificate signs the certificate with the CA, or self-signs it without CA. It returns the PEM encoded certificate and CA.
+func signCertificate(cert CertificateRequest, key crypto.Signer, caCert *x509.Certificate, caKey crypto.Signer) ([]byte, []byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().Truncate(time.Second)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   cert.CommonName,
+			Organization: cert.Organizations,
+		},
+		DNSNames:              cert.DNSNames,
+		NotBefore:             now.Add(-nativeBackdate),
+		NotAfter:              now.Add(certificateDuration(cert)),
+		IsCA:                  cert.IsCA,
+		BasicConstraintsValid: true,
+	}
+	template.KeyUsage, template.ExtKeyUsage = certificateUsages(cert.Profile)
+	for _, ip := range cert.IPAddresses {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			template.IPAddresses = append(template.IPAddresses, parsed)
+		}
+	}
+
+	parent, signer := template, key
+	if caCert != nil {
+		parent, signer = caCert, caKey
+		// A certificate cannot outlive its CA
+		if template.NotAfter.After(caCert.NotAfter) {
+			template.NotAfter = caCert.NotAfter
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if caCert == nil {
+		return certPEM, certPEM, nil
+	}
+	return certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}), nil
+}
+
+// parseKeyPair parses a PEM encoded certificate and its private key
+func parseKeyPair(certPEM, keyPEM []byte) (*x509.Certificate, crypto.Signer, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, nil, fmt.Errorf("no certificate found")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, nil, fmt.Errorf("no private key found")
+	}
+	var key any
+	switch keyBlock.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(keyBlock.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return cert, signer, nil
+}
+
+// encodePrivateKey PEM encodes a private key the way cert-manager does, PKCS#1 for RSA keys and SEC 1 for ECDSA keys
+func encodePrivateKey(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode private key: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func equalExtKeyUsages(a, b []x509.ExtKeyUsage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, u := range a {
+		if !containsExtKeyUsage(b, u) {
+			return false
+		}
+	}
+	return true
+}
+
+func ipStrings(ips []net.IP) []string {
+	s := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		s = append(s, ip.String())
+	}
+	return s
+}
+
+// normalizeIPs formats the IPs the way they are read back from a certificate, invalid IPs are dropped
+func normalizeIPs(ips []string) []string {
+	s := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			s = append(s, parsed.String())
+		}
+	}
+	return s
+}
+
+// equalStrings compares two lists ignoring order and duplicates
+func equalStrings(a, b []string) bool {
+	set := func(l []string) []string {
+		m := map[string]bool{}
+		for _, v := range l {
+			m[v] = true
+		}
+		out := make([]string, 0, len(m))
+		for v := range m {
+			out = append(out, v)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	sa, sb := set(a), set(b)
+	if len(sa) != len(sb) {
+		return false
+	}
+	for i := range sa {
+		if sa[i] != sb[i] {
+			return false
+		}
+	}
+	return true
+}
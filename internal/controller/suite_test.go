@@ -99,7 +99,7 @@ var _ = BeforeSuite(func() {
 	err = NewLoadbalancerReconciler(mgr).SetupWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
-	err = NewPkiReconciler(mgr).SetupWithManager(mgr)
+	err = NewPkiReconciler(mgr, clusterv1alpha1.PkiBackendCertManager).SetupWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
 	err = NewKubeAPIServerReconciler(mgr).SetupWithManager(mgr)
@@ -111,6 +111,37 @@ var _ = BeforeSuite(func() {
 	err = NewKubeSchedulerReconciler(mgr).SetupWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = NewEtcdReconciler(mgr).SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = NewEtcdBackupReconciler(mgr).SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = NewEtcdRestoreReconciler(mgr).SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = NewKubeconfigRequestReconciler(mgr).SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	// The guest clusters of the tests are the test environment itself
+	guestClient := func(context.Context, client.Client, *clusterv1alpha1.ControlPlane) (client.Client, error) {
+		return k8sClient, nil
+	}
+	bootstrapTokens := NewBootstrapTokenReconciler(mgr)
+	bootstrapTokens.GuestClient = guestClient
+	err = bootstrapTokens.SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	addons := NewAddonsReconciler(mgr)
+	addons.GuestClient = guestClient
+	err = addons.SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	guestResourceSets := NewGuestResourceSetReconciler(mgr)
+	guestResourceSets.GuestClient = guestClient
+	err = guestResourceSets.SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
 	// Run controller
 	go func() {
 		defer GinkgoRecover()
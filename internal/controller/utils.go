@@ -19,16 +19,28 @@ package controller
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
+	"net"
+	"sort"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	clusterv1alpha1 "github.com/elssuy/kubeception-operator/api/v1alpha1"
 )
 
 func GenerateKubeconfigFromSecret(tls corev1.Secret, host string) ([]byte, error) {
@@ -80,6 +92,201 @@ func labels(component, instance string, more map[string]string) map[string]strin
 	return l
 }
 
+// availableCondition builds the Available condition of a component from the status of its Deployment
+func availableCondition(deployment appsv1.Deployment, generation int64) metav1.Condition {
+	var desired int32 = 1
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	condition := metav1.Condition{
+		Type:               clusterv1alpha1.ConditionAvailable,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             "DeploymentUnavailable",
+		Message:            fmt.Sprintf("%d/%d replicas available", deployment.Status.AvailableReplicas, desired),
+	}
+
+	for _, c := range deployment.Status.Conditions {
+		if c.Type == appsv1.DeploymentAvailable && c.Status == corev1.ConditionTrue && deployment.Status.AvailableReplicas > 0 {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "DeploymentAvailable"
+		}
+	}
+
+	return condition
+}
+
+// rolledOut is true once every replica of the Deployment runs its latest template and is available
+func rolledOut(deployment appsv1.Deployment) bool {
+	var desired int32 = 1
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == desired &&
+		deployment.Status.Replicas == desired &&
+		deployment.Status.AvailableReplicas == desired
+}
+
+// ChecksumAnnotation is set on pod templates to the checksum of the mounted Secrets and ConfigMaps,
+// a renewed certificate or a rewritten kubeconfig changes it and rolls the pods
+const ChecksumAnnotation = "cluster.kubeception.ulfo.fr/config-checksum"
+
+// SetChecksumAnnotation sets ChecksumAnnotation on the pod template from the Secrets and ConfigMaps it mounts
+func SetChecksumAnnotation(ctx context.Context, c client.Client, namespace string, template *corev1.PodTemplateSpec) error {
+	checksum, err := VolumesChecksum(ctx, c, namespace, template.Spec.Volumes)
+	if err != nil {
+		return err
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[ChecksumAnnotation] = checksum
+	return nil
+}
+
+// mountedSource is a Secret or a ConfigMap mounted by a volume
+type mountedSource struct {
+	secret   bool
+	name     string
+	optional bool
+}
+
+func mountedSources(volumes []corev1.Volume) []mountedSource {
+	isOptional := func(optional *bool) bool { return optional != nil && *optional }
+
+	sources := []mountedSource{}
+	for _, v := range volumes {
+		switch {
+		case v.Secret != nil:
+			sources = append(sources, mountedSource{secret: true, name: v.Secret.SecretName, optional: isOptional(v.Secret.Optional)})
+		case v.ConfigMap != nil:
+			sources = append(sources, mountedSource{name: v.ConfigMap.Name, optional: isOptional(v.ConfigMap.Optional)})
+		case v.Projected != nil:
+			for _, p := range v.Projected.Sources {
+				if p.Secret != nil {
+					sources = append(sources, mountedSource{secret: true, name: p.Secret.Name, optional: isOptional(p.Secret.Optional)})
+				}
+				if p.ConfigMap != nil {
+					sources = append(sources, mountedSource{name: p.ConfigMap.Name, optional: isOptional(p.ConfigMap.Optional)})
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// VolumesChecksum returns the sha256 checksum of the data of every Secret and ConfigMap mounted by the volumes.
+// Missing optional sources are skipped.
+func VolumesChecksum(ctx context.Context, c client.Client, namespace string, volumes []corev1.Volume) (string, error) {
+	h := sha256.New()
+
+	for _, source := range mountedSources(volumes) {
+		key := types.NamespacedName{Name: source.name, Namespace: namespace}
+
+		if source.secret {
+			secret := &corev1.Secret{}
+			if err := c.Get(ctx, key, secret); err != nil {
+				if apierrors.IsNotFound(err) && source.optional {
+					continue
+				}
+				return "", fmt.Errorf("failed to get mounted secret %s: %w", source.name, err)
+			}
+			writeChecksumData(h, "secret/"+source.name, secret.Data)
+			continue
+		}
+
+		configMap := &corev1.ConfigMap{}
+		if err := c.Get(ctx, key, configMap); err != nil {
+			if apierrors.IsNotFound(err) && source.optional {
+				continue
+			}
+			return "", fmt.Errorf("failed to get mounted configmap %s: %w", source.name, err)
+		}
+		data := make(map[string][]byte, len(configMap.Data)+len(configMap.BinaryData))
+		for k, d := range configMap.Data {
+			data[k] = []byte(d)
+		}
+		for k, d := range configMap.BinaryData {
+			data[k] = d
+		}
+		writeChecksumData(h, "configmap/"+source.name, data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeChecksumData writes the data to the hash in a stable order
+func writeChecksumData(h hash.Hash, name string, data map[string][]byte) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(h, "%s\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%d\n", k, len(data[k]))
+		h.Write(data[k])
+	}
+}
+
+// mountsObject is true when the pod spec mounts the Secret or ConfigMap
+func mountsObject(spec corev1.PodSpec, obj client.Object) bool {
+	_, isSecret := obj.(*corev1.Secret)
+
+	for _, source := range mountedSources(spec.Volumes) {
+		if source.secret == isSecret && source.name == obj.GetName() {
+			return true
+		}
+	}
+	return false
+}
+
+// EnqueueDeploymentOwnersMounting enqueues the owners of kind ownerKind of the Deployments mounting a Secret or a ConfigMap.
+// Secrets renewed by cert-manager are not owned by the components, they are matched through the Deployments mounting them.
+func EnqueueDeploymentOwnersMounting(c client.Client, ownerKind string) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		deployments := &appsv1.DeploymentList{}
+		if err := c.List(context.Background(), deployments, client.InNamespace(obj.GetNamespace())); err != nil {
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for _, d := range deployments.Items {
+			owner := metav1.GetControllerOf(&d)
+			if owner == nil || owner.Kind != ownerKind || owner.APIVersion != clusterv1alpha1.GroupVersion.String() {
+				continue
+			}
+			if mountsObject(d.Spec.Template.Spec, obj) {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: owner.Name, Namespace: d.Namespace}})
+			}
+		}
+		return requests
+	})
+}
+
+// appendMissing returns a copy of values followed by the ones of more it does not hold
+func appendMissing(values []string, more ...string) []string {
+	result := append([]string{}, values...)
+	for _, value := range more {
+		found := false
+		for _, v := range result {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
 func CoaleseString(args ...string) string {
 	for _, v := range args {
 		if len(v) > 0 {
@@ -103,3 +310,20 @@ func (r *KubeAPIServerReconciler) CreateOrPatch(ctx context.Context, obj client.
 	r.log.Info(fmt.Sprintf("%s/%s cert was %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), result))
 	return nil
 }
+
+// IPOfFamily returns the first of ips of the family of the cidr, the first of ips when none matches
+func IPOfFamily(ips []string, cidr string) string {
+	if len(ips) == 0 {
+		return ""
+	}
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return ips[0]
+	}
+	for _, value := range ips {
+		if ip := net.ParseIP(value); ip != nil && (ip.To4() == nil) == (ipnet.IP.To4() == nil) {
+			return value
+		}
+	}
+	return ips[0]
+}